@@ -19,14 +19,14 @@ var Assets = func() http.FileSystem {
 	fs := vfsgen۰FS{
 		"/": &vfsgen۰DirInfo{
 			name:    "/",
-			modTime: time.Date(2019, 1, 1, 0, 1, 0, 0, time.UTC),
+			modTime: time.Date(2020, 3, 2, 14, 40, 44, 0, time.UTC),
 		},
 		"/istio.yaml": &vfsgen۰CompressedFileInfo{
 			name:             "istio.yaml",
-			modTime:          time.Date(2019, 1, 1, 0, 1, 0, 0, time.UTC),
-			uncompressedSize: 1652,
+			modTime:          time.Date(2019, 1, 1, 1, 1, 0, 0, time.UTC),
+			uncompressedSize: 1858,
 
-			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x54\xc1\x6e\xdb\x30\x0c\xbd\xfb\x2b\x88\xde\xe3\xc6\x5d\x3a\x74\xba\x05\x45\x87\x05\x6b\xb1\x60\x49\x77\x1d\x18\x99\x73\xb4\xca\x92\x20\xd1\x6e\xdc\xaf\x1f\x64\x3b\x8e\xd3\xb4\x58\x73\xb3\xc9\xc7\x47\x89\x8f\x4f\xe8\xd4\x2f\xf2\x41\x59\x23\x40\x05\x56\x36\xdd\xa0\x79\x41\x25\xb5\xad\xf2\x54\xd9\xcb\x3a\xdb\x10\x63\x96\x3c\x29\x93\x0b\x58\x44\x48\x52\x12\x63\x8e\x8c\x22\x01\x30\x58\x92\x80\x92\xc2\x36\x09\x8e\x64\x0c\xd5\x7b\xc2\x8b\x2c\x9d\xa5\xb3\x8b\x04\xa0\x64\x1d\x04\xfc\x41\x1d\x28\x01\xc0\x8a\xed\x43\x1b\x61\x5f\xc5\x40\x2c\xbf\xdb\x39\x34\x5d\x5d\x1f\x95\xd6\xb0\xb7\x7a\xa9\xd1\xd0\x8a\x64\xe5\x15\x37\x77\x06\x37\x9a\xf2\x01\x13\x54\x4e\x12\xfd\xc2\xfc\x25\xc9\xd6\xc7\xf6\x00\x9e\x9e\xbd\x62\x9a\x3b\xf7\x6d\xbd\x5e\x2e\xbd\xdd\xd0\x50\xa0\x4a\x2c\x68\x59\x69\xbd\xb4\x5a\xc9\x46\xc0\x5c\x3f\x63\x13\x12\x80\x02\x99\xe2\x67\xc7\xa1\x4c\xe1\x29\xf4\x3f\x00\x25\xee\x7e\x92\xd3\x4a\x62\x10\x90\xf5\x41\x67\x3d\x0f\x88\x49\x3f\x8a\xc0\xc8\x55\x98\xc4\x5c\x9f\xe9\x80\x02\xb2\xeb\xe9\xd5\xf4\x10\xf3\x96\xad\xb4\x5a\xc0\xfa\x76\x39\x44\x19\x7d\x41\xbc\x3c\xc1\xef\xd9\xb7\xcc\xee\xea\x15\xef\xcd\x39\xa4\x37\x6f\x31\x86\x57\x8c\xb3\xd9\xa7\x33\x28\x0f\xe8\x3d\x27\xeb\xd7\x8c\xd9\xf5\x79\x9c\x63\xfc\xc0\x2a\xdd\x04\x75\x98\x9c\xb2\x5f\x4f\x3f\x4f\xcf\x19\xc2\x18\x3f\x66\x7f\x51\xee\x49\x99\xb7\x1a\x7c\x99\x65\xd9\x39\x0d\x06\x3c\x1d\xad\x11\xed\xd7\x77\x6f\x05\xa7\xb4\xe5\x2e\x7b\xb4\x62\x9d\xc6\xed\xb2\x0a\x18\x59\xf2\xb2\x35\xe9\xa4\x2b\x6b\xed\x35\xd9\xbc\xc8\xa4\x3d\xd3\xae\x11\xff\xa9\x8a\x98\xfa\xea\xa8\x0e\xa0\x0a\xf4\xd0\x1b\xfa\x6e\x27\xb7\x68\x0a\xfa\xaa\x34\x93\x1f\x3c\x13\xcf\x5d\xdb\x66\x2e\x25\x85\x70\x6f\x8b\x15\xf9\x5a\x49\x3a\xb9\xd4\x00\x07\xd8\xda\xc0\xf1\x08\xf2\xa9\x41\x9f\x87\xa8\x5b\x7a\xf8\x0b\x4d\x60\x2a\xd3\x50\xcb\x54\xea\x2a\x30\xf9\x54\x5b\x89\x3a\x79\x4f\x54\x96\xee\x3b\x91\x43\xad\xea\xa1\x6d\xf4\x28\x93\xaf\x51\x0b\xc8\xa6\x61\xac\xce\x86\x82\x80\xc3\xba\xb1\x8a\x02\x1f\x30\xac\xc3\x8a\x98\x95\x29\xc2\x81\xac\xb4\x39\x09\x58\xac\xd6\x8b\x1f\xbf\x1f\x1e\xd7\x8f\xf3\xfb\xf8\x30\xa9\x1d\xf9\xf3\xe5\xe9\xca\x8e\xc7\x5c\x56\x9a\xd5\x6d\x77\xdb\x55\xe5\xba\x3b\xf6\x13\x63\xd2\x54\x12\xfb\xe6\x9d\x56\xae\x7b\xab\x4e\x93\x71\xc7\xd8\xa3\x54\xa6\x10\xc9\xdb\x5a\xc4\x74\xd4\xb2\xdb\xed\x36\xd4\x7d\xee\xaf\x8e\x79\xde\xae\xe8\x48\xad\x0e\xf0\x01\xc1\xc4\xd8\x18\x1f\x1d\xeb\xbf\x00\x00\x00\xff\xff\x77\x8c\x4d\x79\x74\x06\x00\x00"),
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x54\x4d\x93\xe2\x36\x10\xbd\xfb\x57\x74\xed\x1d\x2f\x26\x90\xda\xe8\x46\x6d\x4d\x2a\x54\x76\x2a\x54\x60\x73\xdd\x6a\xe4\x8e\x51\x90\x25\x95\xd4\xf6\xe0\xf9\xf5\x29\xf9\x0b\x33\x90\xc9\x70\xb3\x5a\xef\xbd\xb6\xf4\x5e\x0b\x9d\xfa\x8b\x7c\x50\xd6\x08\x50\x81\x95\x4d\x0f\x68\x5e\x51\x49\x6d\xab\x3c\x55\xf6\x73\x9d\x1d\x88\x31\x4b\x4e\xca\xe4\x02\x36\x11\x92\x94\xc4\x98\x23\xa3\x48\x00\x0c\x96\x24\xa0\xa4\x70\x4c\x82\x23\x19\x4b\xf5\x20\xf8\x29\x4b\x97\xe9\xf2\x53\x02\x50\xb2\x0e\x02\xfe\x46\x1d\x28\x01\xc0\x8a\xed\x73\x5b\x61\x5f\xc5\x42\xa4\x3f\x9d\x1d\x9a\x8e\xd7\x57\xa5\x35\xec\xad\xde\x6a\x34\xb4\x23\x59\x79\xc5\xcd\x93\xc1\x83\xa6\x7c\xc4\x04\x95\x93\x44\xbf\x31\xff\x90\x64\xeb\x63\x7b\x00\x55\x62\x41\x02\x72\x2b\x4f\xe4\xe3\x21\xda\x93\x7d\xee\xb1\x3f\xd4\x00\x6e\x7f\xaf\x65\x78\x7a\xf1\x8a\x69\xed\xdc\x6f\xfb\xfd\x76\xeb\xed\x81\xc6\x16\xce\xdb\x73\xb3\x31\x8a\xdf\x15\x6f\x51\xf5\x62\xd4\x94\x8a\x31\x27\xfd\x2e\x67\xc0\x0c\x9c\x02\xb5\xa6\xe6\x5d\x4a\x0f\x19\x18\x2d\x6a\x5b\x69\xbd\xb5\x5a\xc9\x46\xc0\x5a\xbf\x60\x13\x5a\x2d\xa6\xf8\xd9\xab\x99\xc2\x53\xe8\x17\x00\x25\x9e\xff\x24\xa7\x95\xc4\x20\x20\xeb\x8b\xce\x7a\x1e\x11\xb3\xde\xd8\xc0\xc8\x55\x98\xc5\xbd\x7e\x07\x20\x2e\x04\x64\xab\xf9\x62\x7e\xa9\x79\xcb\x56\x5a\x2d\x60\xff\x75\x3b\x56\x19\x7d\x41\xbc\xbd\xc1\x0f\xea\x47\x66\xb7\x18\xd1\x9d\xee\x97\x47\x44\xbf\xdc\x53\x0c\x6f\x14\x97\xcb\x9f\x1e\x90\xbc\xa0\x07\x4d\xd6\x6f\x15\xb3\xd5\x63\x9a\xd9\xea\x8e\xaa\x74\x33\xd4\x61\x76\xab\xbe\x9a\xff\x3c\x7f\xe4\x12\xa6\xf8\xa9\xfa\xab\x72\x27\x65\xee\x35\xf8\x65\x99\x65\x8f\x34\x18\xf1\x74\x15\x23\x1a\x86\x71\x18\x6c\xa7\xb4\xed\xc7\xe4\x2a\x62\x8b\x69\xa4\x27\x0f\x4c\x17\xea\x59\x47\x6b\x33\x3d\x3b\xbc\xca\x61\xe6\xc4\xff\xb0\xa6\x13\xd7\xf3\x00\xaa\x40\xcf\xfd\xf3\xf4\x74\x96\x47\x34\x05\xfd\xaa\x34\x93\x1f\xe7\x19\x80\x4c\x6d\x9b\xb5\x94\x14\xc2\x37\x5b\xec\xc8\xd7\x4a\xd2\xcd\xa1\x46\x38\xc0\xd1\x06\x16\x70\x40\x79\x6a\xd0\xe7\x21\xfa\x96\x5e\x56\xa1\x09\x4c\x65\x1a\x6a\x99\x4a\x5d\x05\x26\x9f\x6a\x2b\x51\x27\x57\x77\x3e\x31\x89\xa5\xfb\x9d\xc8\xa1\x56\xf5\xd8\x36\xce\x28\x93\xaf\x51\x0b\xc8\xe6\x13\xc7\xe2\x5b\x14\x04\x5c\xe2\xc6\xaa\xa4\x29\x86\x75\xd8\x11\xb3\x32\xc5\x68\x0c\x40\x69\x73\x12\xb0\xd9\xed\x37\x7f\xfc\x78\xfe\xbe\xff\xbe\xfe\x16\x9f\x59\x75\x26\xff\xb8\x3d\x1d\xed\xfa\x9a\xcb\x4a\xb3\xfa\xda\x9d\x76\x57\xb9\x2e\xb8\xfd\x8d\x31\x69\x2a\x89\x7d\xf3\x1f\xad\x5c\xf7\x56\xdd\x6e\xc6\x4c\xb2\x47\xa9\x4c\x21\x92\xfb\x5e\xc4\xed\xe8\x65\x97\xed\x16\xd4\x7d\x0e\x47\xc7\x3c\x6f\x23\x3a\x71\xab\x03\x7c\xc0\x30\x31\x1d\x8c\x8f\x5e\xeb\xbf\x03\x00\x65\xab\xe8\x46\x42\x07\x00\x00"),
 		},
 	}
 	fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
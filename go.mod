@@ -6,7 +6,9 @@ require (
 	emperror.dev/errors v0.4.2
 	github.com/AlecAivazis/survey/v2 v2.0.2
 	github.com/MakeNowJust/heredoc v0.0.0-20171113091838-e9091a26100e
+	github.com/Masterminds/semver v1.4.2
 	github.com/Masterminds/sprig v2.20.0+incompatible // indirect
+	github.com/andybalholm/brotli v1.0.2
 	github.com/banzaicloud/istio-client-go v0.0.0-20191203163313-928801ec5028
 	github.com/banzaicloud/istio-operator v0.0.0-20191212123221-6e3658721f00
 	github.com/banzaicloud/k8s-objectmatcher v1.0.1
@@ -25,6 +27,8 @@ require (
 	github.com/olekukonko/tablewriter v0.0.1
 	github.com/pborman/uuid v1.2.0
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
+	github.com/prometheus/client_golang v1.0.0
+	github.com/prometheus/common v0.6.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/cast v1.3.0
 	github.com/spf13/cobra v0.0.5
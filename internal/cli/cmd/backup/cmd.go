@@ -0,0 +1,92 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/util/homedir"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/backup"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+// DefaultDir is the directory holding the backups by default
+var DefaultDir = filepath.Join(homedir.HomeDir(), ".banzai/backyards/backups")
+
+func NewRootCmd(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up and restore the resources managed through Backyards",
+		Long: `Back up and restore the resources managed through Backyards.
+
+The backups hold the Istio custom resource and the VirtualServices, DestinationRules, Sidecars, ServiceEntries,
+Policies and PeerAuthentications created through Backyards, those are saved as YAML manifests to a local directory.`,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+	}
+
+	cmd.AddCommand(
+		NewCreateCommand(cli),
+		NewListCommand(cli),
+		NewRestoreCommand(cli),
+	)
+
+	return cmd
+}
+
+// AddDirFlag registers the flag of the backup directory
+func AddDirFlag(flags *pflag.FlagSet, dir *string) {
+	flags.StringVar(dir, "backup-dir", DefaultDir, "Directory of the backups")
+}
+
+// Create saves the resources managed through Backyards to a new backup in the given directory
+func Create(cli cli.CLI, dir string) (*backup.Backup, error) {
+	client, err := cli.GetK8sClient()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get k8s client")
+	}
+
+	objects, err := backup.Collect(client)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not collect resources")
+	}
+
+	header := fmt.Sprintf("Backyards backup created with CLI version %s at %s", cli.GetRootCommand().Version, time.Now().Format(time.RFC3339))
+	b, err := backup.NewStore(dir).Save(objects, header)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("%d resources saved to backup %s", b.Objects, b.Name)
+
+	return b, nil
+}
+
+// RestoreCommand returns the command restoring the backup
+func RestoreCommand(b *backup.Backup, dir string) string {
+	command := "backyards backup restore " + b.Name
+	if dir != DefaultDir {
+		command += " --backup-dir " + dir
+	}
+
+	return command
+}
@@ -0,0 +1,52 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+func NewCreateCommand(cli cli.CLI) *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "create [flags]",
+		Short: "Back up the resources managed through Backyards",
+		Example: `  # Create a backup.
+  backyards backup create`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			b, err := Create(cli, dir)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cli.Out(), "To restore the backup use:\n> %s\n", RestoreCommand(b, dir))
+
+			return nil
+		},
+	}
+
+	AddDirFlag(cmd.Flags(), &dir)
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/pkg/backup"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type BackupOut struct {
+	Name      string `json:"name"`
+	Time      string `json:"time"`
+	Resources int    `json:"resources"`
+	Path      string `json:"path"`
+}
+
+func NewListCommand(cli cli.CLI) *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:     "list [flags]",
+		Aliases: []string{"ls"},
+		Short:   "List the backups",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			backups, err := backup.NewStore(dir).List()
+			if err != nil {
+				return err
+			}
+
+			return showBackups(cli, backups)
+		},
+	}
+
+	AddDirFlag(cmd.Flags(), &dir)
+
+	return cmd
+}
+
+func showBackups(cli cli.CLI, backups []backup.Backup) error {
+	outs := make([]BackupOut, 0, len(backups))
+	for _, b := range backups {
+		outs = append(outs, BackupOut{
+			Name:      b.Name,
+			Time:      b.Time.Format(time.RFC3339),
+			Resources: b.Objects,
+			Path:      b.Path,
+		})
+	}
+
+	err := output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Name", "Time", "Resources", "Path"},
+		Headers: []string{"Name", "Time", "Resources", "Path"},
+	}, outs)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
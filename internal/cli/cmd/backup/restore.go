@@ -0,0 +1,103 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
+	"github.com/banzaicloud/backyards-cli/pkg/backup"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
+)
+
+type restoreOptions struct {
+	dir           string
+	dumpResources bool
+	applyOptions  k8s.ApplyOptions
+}
+
+func NewRestoreCommand(cli cli.CLI) *cobra.Command {
+	options := &restoreOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "restore NAME|FILE [flags]",
+		Short: "Restore the resources of a backup",
+		Long: `Applies the resources saved to a backup.
+
+The Istio operator and its custom resource definitions must be installed before restoring a backup,
+for example with the 'backyards istio install' command.`,
+		Example: `  # List the backups.
+  backyards backup list
+
+  # Restore a backup.
+  backyards backup restore backup-20200101-120000.000`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := common.ValidateApplyFlags(options.applyOptions)
+			if err != nil {
+				return err
+			}
+
+			objects, err := backup.NewStore(options.dir).Objects(args[0])
+			if errors.Is(err, backup.ErrBackupNotFound) {
+				return errors.Errorf("backup %s not found, use the 'backyards backup list' command to list the backups", args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			if options.dumpResources {
+				yaml, err := objects.YAMLManifest()
+				if err != nil {
+					return errors.WrapIf(err, "could not render YAML manifest")
+				}
+				fmt.Fprint(cli.Out(), yaml)
+
+				return nil
+			}
+
+			return cli.IfConfirmed(fmt.Sprintf("Restore %d resources from backup %s. Are you sure to proceed?", len(objects), args[0]), func() error {
+				client, err := cli.GetK8sClient()
+				if err != nil {
+					return errors.WrapIf(err, "could not get k8s client")
+				}
+
+				m := resourcemanager.New(client, cli.LabelManager())
+				m.SetApplyOptions(options.applyOptions)
+				m.SetObjects(objects)
+				err = m.Install().Do()
+				if err != nil {
+					return errors.WrapIf(err, "could not restore backup")
+				}
+
+				return nil
+			})
+		},
+	}
+
+	AddDirFlag(cmd.Flags(), &options.dir)
+	cmd.Flags().BoolVarP(&options.dumpResources, "dump-resources", "d", false, "Dump resources to stdout instead of applying them")
+	common.AddApplyFlags(cmd.Flags(), &options.applyOptions)
+
+	return cmd
+}
@@ -18,21 +18,22 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"emperror.dev/errors"
 	"github.com/spf13/cobra"
 	"istio.io/operator/pkg/object"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/canary_operator"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
 )
 
 const (
@@ -53,8 +54,12 @@ type InstallOptions struct {
 	canaryOperatorNamespace string
 	istioNamespace          string
 	prometheusURL           string
+	valueOverrides          helm.ValueOverrides
 
 	DumpResources bool
+	Plan          bool
+	ImageRegistry string
+	ApplyOptions  k8s.ApplyOptions
 }
 
 // NewInstallOptions get InstallOptions
@@ -75,7 +80,8 @@ func NewInstallCommand(cli cli.CLI, options *InstallOptions) *cobra.Command {
 		Long: `Installs canary feature.
 
 The command automatically applies the resources.
-It can only dump the applicable resources with the '--dump-resources' option.
+It can only dump the applicable resources with the '--dump-resources' option,
+or show the changes to the cluster without applying them with the '--plan' option.
 `,
 		Example: `  # Default install.
   backyards canary install
@@ -86,6 +92,11 @@ It can only dump the applicable resources with the '--dump-resources' option.
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 
+			err := common.ValidateApplyFlags(options.ApplyOptions)
+			if err != nil {
+				return err
+			}
+
 			return c.run(cli, options)
 		},
 	}
@@ -93,9 +104,13 @@ It can only dump the applicable resources with the '--dump-resources' option.
 	cmd.Flags().StringVar(&options.releaseName, "release-name", "canary-operator", "Name of the release")
 	cmd.Flags().StringVar(&options.istioNamespace, "istio-namespace", "istio-system", "Namespace of Istio sidecar injector")
 	cmd.Flags().StringVar(&options.canaryOperatorNamespace, "canary-namespace", "backyards-canary", "Namespace for the canary operator")
+	common.AddValueOverrideFlags(cmd.Flags(), &options.valueOverrides, "f")
 	cmd.Flags().StringVar(&options.prometheusURL, "prometheus-url", "http://backyards-prometheus.backyards-system:59090/prometheus", "Prometheus URL for metrics")
 
 	cmd.Flags().BoolVarP(&options.DumpResources, "dump-resources", "d", options.DumpResources, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.Plan, "plan", options.Plan, "Show the changes to the cluster without applying them")
+	common.AddImageRegistryFlag(cmd.Flags(), &options.ImageRegistry)
+	common.AddApplyFlags(cmd.Flags(), &options.ApplyOptions)
 
 	return cmd
 }
@@ -104,14 +119,21 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	err := c.validate(options.istioNamespace)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, istioNotFoundErrorTemplate, err)
-		return nil
+		if !options.Plan {
+			return nil
+		}
 	}
 
-	objects, err := getCanaryOperatorObjects(options.releaseName, options.canaryOperatorNamespace, options.prometheusURL)
+	objects, err := getCanaryOperatorObjects(options.releaseName, options.canaryOperatorNamespace, options.prometheusURL, options.valueOverrides)
 	if err != nil {
 		return err
 	}
 	objects.Sort(helm.InstallObjectOrder())
+	images.Relocate(objects, options.ImageRegistry)
+
+	if options.Plan {
+		return common.PrintPlan(cli, "canary operator", objects, nil)
+	}
 
 	if !options.DumpResources {
 		client, err := cli.GetK8sClient()
@@ -119,17 +141,10 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 			return err
 		}
 
-		err = k8s.ApplyResources(client, cli.LabelManager(), objects)
-		if err != nil {
-			return err
-		}
-
-		err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(objects), wait.Backoff{
-			Duration: time.Second * 5,
-			Factor:   1,
-			Jitter:   0,
-			Steps:    24,
-		}, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
+		m := resourcemanager.New(client, cli.LabelManager())
+		m.SetApplyOptions(options.ApplyOptions)
+		m.SetObjects(objects)
+		err = m.Install().Do()
 		if err != nil {
 			return err
 		}
@@ -147,7 +162,12 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	return nil
 }
 
-func getCanaryOperatorObjects(releaseName, canaryOperatorNamespace, prometheusURL string) (object.K8sObjects, error) {
+// GetDefaultObjects renders the canary operator chart with the default install options
+func GetDefaultObjects() (object.K8sObjects, error) {
+	return getCanaryOperatorObjects("canary-operator", "backyards-canary", "", helm.ValueOverrides{})
+}
+
+func getCanaryOperatorObjects(releaseName, canaryOperatorNamespace, prometheusURL string, valueOverrides helm.ValueOverrides) (object.K8sObjects, error) {
 	var values Values
 
 	valuesYAML, err := helm.GetDefaultValues(canary_operator.Chart)
@@ -167,6 +187,11 @@ func getCanaryOperatorObjects(releaseName, canaryOperatorNamespace, prometheusUR
 		return nil, errors.WrapIf(err, "could not marshal yaml values")
 	}
 
+	rawValues, err = valueOverrides.Apply(rawValues)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not apply value overrides")
+	}
+
 	objects, err := helm.Render(canary_operator.Chart, string(rawValues), helm.ReleaseOptions{
 		Name:      "canary-operator",
 		IsInstall: true,
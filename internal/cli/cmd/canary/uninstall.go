@@ -87,7 +87,7 @@ The command can uninstall every component at once with the '--uninstall-everythi
 }
 
 func (c *uninstallCommand) run(cli cli.CLI, options *UninstallOptions) error {
-	objects, err := getCanaryOperatorObjects(options.releaseName, options.canaryOperatorNamespace, "")
+	objects, err := getCanaryOperatorObjects(options.releaseName, options.canaryOperatorNamespace, "", helm.ValueOverrides{})
 	if err != nil {
 		return err
 	}
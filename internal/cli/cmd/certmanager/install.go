@@ -19,7 +19,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"emperror.dev/errors"
 	"github.com/MakeNowJust/heredoc"
@@ -28,16 +27,18 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 
 	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
 
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/certmanager"
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/certmanagercainjector"
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/certmanagercrds"
+	cmdCommon "github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
 )
 
 type installCommand struct {
@@ -45,7 +46,11 @@ type installCommand struct {
 }
 
 type InstallOptions struct {
-	DumpResources bool
+	DumpResources  bool
+	Plan           bool
+	ImageRegistry  string
+	ApplyOptions   k8s.ApplyOptions
+	ValueOverrides helm.ValueOverrides
 }
 
 func NewInstallOptions() *InstallOptions {
@@ -64,7 +69,11 @@ func NewInstallCommand(cli cli.CLI, options *InstallOptions) *cobra.Command {
 		Long: `Installs cert-manager.
 
 The command automatically applies the resources.
-It can only dump the applicable resources with the '--dump-resources' option.`,
+It can only dump the applicable resources with the '--dump-resources' option,
+or show the changes to the cluster without applying them with the '--plan' option.
+
+The embedded chart values can be overridden with values files ('-f') and '--set key=value' options,
+the values under the 'cainjector' key are passed to the cainjector chart.`,
 		Example: `  # Install to the cert-manager namespace. This command will fail if cert-manager is already installed from a different source.
   backyards cert-manager install
 `,
@@ -72,11 +81,20 @@ It can only dump the applicable resources with the '--dump-resources' option.`,
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 
+			err := cmdCommon.ValidateApplyFlags(options.ApplyOptions)
+			if err != nil {
+				return err
+			}
+
 			return c.run(cli, options)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&options.DumpResources, "dump-resources", "d", options.DumpResources, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.Plan, "plan", options.Plan, "Show the changes to the cluster without applying them")
+	cmdCommon.AddValueOverrideFlags(cmd.Flags(), &options.ValueOverrides, "f")
+	cmdCommon.AddImageRegistryFlag(cmd.Flags(), &options.ImageRegistry)
+	cmdCommon.AddApplyFlags(cmd.Flags(), &options.ApplyOptions)
 
 	return cmd
 }
@@ -85,14 +103,21 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	err := c.validate(CertManagerNamespace)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "cert-manager validation failed: %s", err)
-		return nil
+		if !options.Plan {
+			return nil
+		}
 	}
 
-	objects, err := getCertManagerObjects(CertManagerNamespace)
+	objects, err := getCertManagerObjects(CertManagerNamespace, options.ValueOverrides)
 	if err != nil {
 		return err
 	}
 	objects.Sort(helm.InstallObjectOrder())
+	images.Relocate(objects, options.ImageRegistry)
+
+	if options.Plan {
+		return cmdCommon.PrintPlan(cli, "cert-manager", objects, nil)
+	}
 
 	if !options.DumpResources {
 		client, err := cli.GetK8sClient()
@@ -100,17 +125,10 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 			return err
 		}
 
-		err = k8s.ApplyResources(client, cli.LabelManager(), objects)
-		if err != nil {
-			return err
-		}
-
-		err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(objects), wait.Backoff{
-			Duration: time.Second * 5,
-			Factor:   1,
-			Jitter:   0,
-			Steps:    24,
-		}, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
+		m := resourcemanager.New(client, cli.LabelManager())
+		m.SetApplyOptions(options.ApplyOptions)
+		m.SetObjects(objects)
+		err = m.Install().Do()
 		if err != nil {
 			return err
 		}
@@ -184,12 +202,27 @@ func getCertManagerCRDs() (object.K8sObjects, error) {
 	return object.ParseK8sObjectsFromYAMLManifest(buf.String())
 }
 
-func getCertManagerObjects(namespace string) (object.K8sObjects, error) {
+// GetDefaultObjects renders the cert-manager charts with the default install options
+func GetDefaultObjects() (object.K8sObjects, error) {
+	return getCertManagerObjects(CertManagerNamespace, helm.ValueOverrides{})
+}
+
+func getCertManagerObjects(namespace string, valueOverrides helm.ValueOverrides) (object.K8sObjects, error) {
+	overrides, err := valueOverrides.Load()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not load value overrides")
+	}
+
 	valuesYAML, err := helm.GetDefaultValues(certmanager.Chart)
 	if err != nil {
 		return nil, errors.WrapIf(err, "could not get helm default values")
 	}
 
+	valuesYAML, err = helm.ApplyValues(valuesYAML, overrides)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not apply value overrides")
+	}
+
 	objects, err := helm.Render(certmanager.Chart, string(valuesYAML), helm.ReleaseOptions{
 		Name:      certManagerReleaseName,
 		IsInstall: true,
@@ -205,6 +238,13 @@ func getCertManagerObjects(namespace string) (object.K8sObjects, error) {
 		return nil, errors.WrapIf(err, "could not get helm default values")
 	}
 
+	if caInjectorOverrides, ok := overrides["cainjector"].(map[string]interface{}); ok {
+		caInjectorValuesYAML, err = helm.ApplyValues(caInjectorValuesYAML, caInjectorOverrides)
+		if err != nil {
+			return nil, errors.WrapIf(err, "could not apply cainjector value overrides")
+		}
+	}
+
 	cainjectorObjects, err := helm.Render(certmanagercainjector.Chart, string(caInjectorValuesYAML), helm.ReleaseOptions{
 		Name:      certManagerReleaseName,
 		IsInstall: true,
@@ -98,7 +98,7 @@ func (c *uninstallCommand) run(cli cli.CLI, options *UninstallOptions) error {
 		return err
 	}
 
-	objects, err := getCertManagerObjects(CertManagerNamespace)
+	objects, err := getCertManagerObjects(CertManagerNamespace, helm.ValueOverrides{})
 	if err != nil {
 		return err
 	}
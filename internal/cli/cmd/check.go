@@ -0,0 +1,140 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+	"github.com/banzaicloud/backyards-cli/pkg/preflight"
+)
+
+type checkOptions struct {
+	includeDemo bool
+}
+
+func NewCheckCommand(cli cli.CLI) *cobra.Command {
+	options := &checkOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check whether the cluster is ready for installing Backyards",
+		Long: `Runs preflight checks against the current cluster and reports each of them as pass, warn or fail.
+
+The following are checked:
+  - the Kubernetes version is supported
+  - the current user is allowed to create and update the resources of the embedded charts
+  - the custom resource definitions of the embedded charts do not conflict with existing ones
+  - there is no Istio installation which is not managed by the Istio operator
+  - there is a default StorageClass
+  - the nodes have enough allocatable capacity for the resource requests of the charts
+  - the services of the admission webhooks have ready endpoints
+
+The command exits with a non-zero code when any of the checks fails.`,
+		Example: `  # Run the preflight checks.
+  backyards check
+
+  # Run the preflight checks and produce machine-readable output.
+  backyards check -o json`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return runChecks(cli, options)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.includeDemo, "demo", false, "Include the demo application in the checks")
+
+	return cmd
+}
+
+func runChecks(cli cli.CLI, options *checkOptions) error {
+	charts, err := getEmbeddedChartObjects(cli)
+	if err != nil {
+		return err
+	}
+
+	objects := make(object.K8sObjects, 0)
+	for _, chart := range charts {
+		if chart.component == "demo" && !options.includeDemo {
+			continue
+		}
+		objects = append(objects, chart.objects...)
+	}
+
+	client, err := cli.GetK8sClient()
+	if err != nil {
+		return err
+	}
+
+	config, err := cli.GetK8sConfig()
+	if err != nil {
+		return err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return errors.WrapIf(err, "could not create discovery client")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	results := preflight.Run([]preflight.Check{
+		preflight.KubernetesVersion(discoveryClient),
+		preflight.RBACPermissions(client, mapper, objects),
+		preflight.CRDConflicts(client, objects),
+		preflight.UnmanagedIstio(client),
+		preflight.DefaultStorageClass(client),
+		preflight.NodeCapacity(client, objects),
+		preflight.WebhookReachability(client),
+	})
+
+	err = showCheckResults(cli, results)
+	if err != nil {
+		return err
+	}
+
+	if failed := preflight.Failed(results); failed > 0 {
+		return errors.Errorf("%d of %d preflight checks failed", failed, len(results))
+	}
+
+	return nil
+}
+
+func showCheckResults(cli cli.CLI, data interface{}) error {
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Check", "Status", "Message"},
+		Headers: []string{"Check", "Status", "Message"},
+	}
+
+	err := output.Output(ctx, data)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+)
+
+// PrintPlan shows the changes applying the objects and removing the stale ones would make to the cluster, without modifying anything.
+// A non-interactive label manager is used, so resources not yet managed by the CLI are reported as skipped.
+func PrintPlan(cli cli.CLI, title string, objects, stale object.K8sObjects) error {
+	items, err := PlanItems(cli, objects, stale)
+	if err != nil {
+		return err
+	}
+
+	PrintPlanItems(cli, title, items)
+
+	return nil
+}
+
+// PlanItems calculates the planned changes of the objects to apply and the stale objects to remove
+func PlanItems(cli cli.CLI, objects, stale object.K8sObjects) ([]k8s.PlanItem, error) {
+	client, err := cli.GetK8sClient()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get k8s client")
+	}
+
+	labelManager := internalk8s.NewLabelManager(false, cli.GetRootCommand().Version)
+
+	items, err := k8s.PlanResources(client, labelManager, objects, cli.Color())
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not plan resources")
+	}
+
+	deletions, err := k8s.PlanDeletions(client, labelManager, stale, internalk8s.CLIVersionLabel, cli.Color())
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not plan resource deletions")
+	}
+
+	return append(items, deletions...), nil
+}
+
+// PrintPlanItems writes the planned changes under a title
+func PrintPlanItems(cli cli.CLI, title string, items []k8s.PlanItem) {
+	fmt.Fprintf(cli.Out(), "Planned changes of %s\n\n", title)
+	k8s.PrintPlan(cli.Out(), items, cli.Color())
+	fmt.Fprintln(cli.Out())
+}
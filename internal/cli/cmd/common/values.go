@@ -0,0 +1,49 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"emperror.dev/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+)
+
+// AddValueOverrideFlags registers the flags for overriding the embedded chart values of an install command
+func AddValueOverrideFlags(flags *pflag.FlagSet, overrides *helm.ValueOverrides, valuesShorthand string) {
+	flags.StringArrayVarP(&overrides.ValueFiles, "values", valuesShorthand, overrides.ValueFiles, "Chart values file to merge over the embedded defaults (can be repeated)")
+	flags.StringArrayVar(&overrides.Values, "set", overrides.Values, "Chart value to set in key=value format, applied after the values files (can be repeated)")
+}
+
+// AddImageRegistryFlag registers the flag for relocating the images of an install command to a private registry
+func AddImageRegistryFlag(flags *pflag.FlagSet, registry *string) {
+	flags.StringVar(registry, "image-registry", *registry, "Private registry to pull every image from, the original registry host is replaced (see 'backyards images relocate')")
+}
+
+// AddApplyFlags registers the flags selecting how the resources of an install command are applied
+func AddApplyFlags(flags *pflag.FlagSet, options *k8s.ApplyOptions) {
+	flags.BoolVar(&options.ServerSide, "server-side", options.ServerSide, "Apply the resources with server-side apply using the '"+k8s.FieldManager+"' field manager")
+	flags.BoolVar(&options.ForceConflicts, "force-conflicts", options.ForceConflicts, "Take over the fields owned by other field managers on server-side apply")
+}
+
+// ValidateApplyFlags rejects the apply flags which would be ignored in the given combination
+func ValidateApplyFlags(options k8s.ApplyOptions) error {
+	if options.ForceConflicts && !options.ServerSide {
+		return errors.New("--force-conflicts can only be used with --server-side")
+	}
+
+	return nil
+}
@@ -31,8 +31,12 @@ func NewConfigCmd(cli cli.CLI) *cobra.Command {
 
 	cmd.AddCommand(
 		NewViewCmd(cli),
+		NewGetCmd(cli),
+		NewSetCmd(cli),
+		NewUnsetCmd(cli),
 		NewEditCmd(cli),
 		NewDeleteCmd(cli),
+		NewContextCmd(cli),
 	)
 
 	return cmd
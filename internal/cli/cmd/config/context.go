@@ -0,0 +1,249 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type ContextOut struct {
+	Current     bool   `json:"current"`
+	Name        string `json:"name"`
+	KubeContext string `json:"kubeContext"`
+	Namespace   string `json:"namespace,omitempty"`
+	URL         string `json:"url,omitempty"`
+	CACert      string `json:"cacert,omitempty"`
+	Token       string `json:"token"`
+}
+
+func NewContextCmd(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Manage named Backyards contexts",
+		Long: `Manage named Backyards contexts.
+
+A context binds a kubeconfig context to a Backyards installation: its namespace,
+and optionally the external URL, the CA and the token to reach it with.
+The current context is used unless another one is selected with --backyards-context.`,
+	}
+
+	cmd.AddCommand(
+		NewContextAddCmd(cli),
+		NewContextUseCmd(cli),
+		NewContextListCmd(cli),
+		NewContextDeleteCmd(cli),
+	)
+
+	return cmd
+}
+
+func NewContextAddCmd(c cli.CLI) *cobra.Command {
+	var use bool
+
+	cmd := &cobra.Command{
+		Use:   "add NAME [--context KUBECONTEXT] [--backyards-namespace NS] [--base-url URL] [--cacert FILE] [--token TOKEN]",
+		Short: "Add or replace a context",
+		Long: `Add or replace a context.
+
+The kubeconfig context and the Backyards settings are taken from the global flags,
+the kubeconfig context defaults to the one in use.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			kubeContext := viper.GetString("kubecontext")
+			if kubeContext == "" {
+				var err error
+				kubeContext, err = cli.CurrentKubeContext()
+				if err != nil {
+					return err
+				}
+			}
+
+			exists, err := cli.KubeContextExists(kubeContext)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return errors.NewWithDetails("kubeconfig context not found", "context", kubeContext)
+			}
+
+			// the CA file is read from other working directories later on
+			caCert := changedFlag(cmd, "cacert")
+			if caCert != "" {
+				caCert, err = filepath.Abs(caCert)
+				if err != nil {
+					return errors.WrapIf(err, "could not resolve CA file path")
+				}
+			}
+
+			context := cli.Context{
+				Name:        args[0],
+				KubeContext: kubeContext,
+				Namespace:   changedFlag(cmd, "backyards-namespace"),
+				URL:         changedFlag(cmd, "base-url"),
+				CACert:      caCert,
+				Token:       changedFlag(cmd, "token"),
+			}
+
+			config := c.GetPersistentGlobalConfig()
+			err = config.SetContext(context)
+			if err != nil {
+				return err
+			}
+			if use || config.CurrentContext() == "" {
+				err = config.UseContext(context.Name)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = config.PersistConfig()
+			if err != nil {
+				return err
+			}
+
+			logrus.Infof("context %s added", context.Name)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&use, "use", false, "Switch to the context")
+
+	return cmd
+}
+
+func NewContextUseCmd(cli cli.CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Switch to a context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			config := cli.GetPersistentGlobalConfig()
+			err := config.UseContext(args[0])
+			if err != nil {
+				return err
+			}
+
+			err = config.PersistConfig()
+			if err != nil {
+				return err
+			}
+
+			logrus.Infof("switched to context %s", args[0])
+
+			return nil
+		},
+	}
+}
+
+func NewContextListCmd(cli cli.CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the contexts",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			config := cli.GetPersistentGlobalConfig()
+			contexts, err := config.Contexts()
+			if err != nil {
+				return err
+			}
+
+			outs := make([]ContextOut, 0, len(contexts))
+			for _, c := range contexts {
+				token := "unset"
+				if c.Token != "" {
+					token = "set"
+				}
+				outs = append(outs, ContextOut{
+					Current:     c.Name == config.CurrentContext(),
+					Name:        c.Name,
+					KubeContext: c.KubeContext,
+					Namespace:   c.Namespace,
+					URL:         c.URL,
+					CACert:      c.CACert,
+					Token:       token,
+				})
+			}
+
+			err = output.Output(&output.Context{
+				Out:     cli.Out(),
+				Color:   cli.Color(),
+				Format:  cli.OutputFormat(),
+				Fields:  []string{"Current", "Name", "KubeContext", "Namespace", "URL", "CACert", "Token"},
+				Headers: []string{"Current", "Name", "Kube context", "Namespace", "URL", "CA", "Token"},
+			}, outs)
+			if err != nil {
+				return errors.WrapIf(err, "could not produce output")
+			}
+
+			return nil
+		},
+	}
+}
+
+func NewContextDeleteCmd(cli cli.CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			config := cli.GetPersistentGlobalConfig()
+			err := config.DeleteContext(args[0])
+			if err != nil {
+				return err
+			}
+
+			err = config.PersistConfig()
+			if err != nil {
+				return err
+			}
+
+			logrus.Infof("context %s deleted", args[0])
+
+			return nil
+		},
+	}
+}
+
+// changedFlag returns the value of an explicitly given flag, or an empty string
+func changedFlag(cmd *cobra.Command, name string) string {
+	flag := cmd.Flag(name)
+	if flag == nil || !flag.Changed {
+		return ""
+	}
+
+	return flag.Value.String()
+}
@@ -17,7 +17,9 @@ package config
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
+	"emperror.dev/errors"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -25,43 +27,71 @@ import (
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 )
 
-func NewEditCmd(cli cli.CLI) *cobra.Command {
+func NewEditCmd(c cli.CLI) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "edit",
 		Short: "Edit persistent configuration",
 	}
-	cmd.Run = func(cmd *cobra.Command, args []string) {
-		file := cli.GetPersistentConfig().GetConfigFileUsed()
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
 
-		bytes, err := ioutil.ReadFile(cli.GetPersistentConfig().GetConfigFileUsed())
-		if err != nil {
-			logrus.Error(err, "failed to read config file")
+		config := c.GetPersistentConfig()
+		file := config.GetConfigFileUsed()
+
+		mode := os.FileMode(0600)
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WrapIf(err, "failed to read config file")
+		}
+		if fileInfo, err := os.Stat(file); err == nil {
+			mode = fileInfo.Mode()
 		}
 
 		content := string(bytes)
-		err = survey.AskOne(&survey.Editor{
-			Message:       file,
-			Default:       content,
-			HideDefault:   true,
-			AppendDefault: true,
-		}, &content)
+		for {
+			err = survey.AskOne(&survey.Editor{
+				Message:       file,
+				Default:       content,
+				HideDefault:   true,
+				AppendDefault: true,
+			}, &content)
+			if err != nil {
+				return err
+			}
 
-		if err != nil {
-			logrus.Error(err)
-			return
+			err = cli.PersistentSettings.Validate([]byte(content))
+			if err == nil {
+				break
+			}
+			for _, e := range errors.GetErrors(err) {
+				logrus.Error(e)
+			}
+
+			again := false
+			err = survey.AskOne(&survey.Confirm{
+				Message: "The config is invalid, edit it again?",
+				Default: true,
+			}, &again)
+			if err != nil {
+				return err
+			}
+			if !again {
+				return errors.New("config is invalid, changes are discarded")
+			}
 		}
 
-		fileInfo, err := os.Stat(file)
+		err = os.MkdirAll(filepath.Dir(file), 0700)
 		if err != nil {
-			logrus.Error(err)
+			return errors.WrapIf(err, "failed to create config dir")
 		}
-
-		err = ioutil.WriteFile(file, []byte(content), fileInfo.Mode())
+		err = ioutil.WriteFile(file, []byte(content), mode)
 		if err != nil {
-			logrus.Error(err)
+			return errors.WrapIf(err, "failed to write config file")
 		}
-		// short circuit here, to avoid overwriting manually edited contents
-		os.Exit(0)
+
+		// reload the config to avoid overwriting the manually edited contents
+		return config.Reload()
 	}
 	return cmd
 }
@@ -20,6 +20,8 @@ import (
 	"os"
 	"os/signal"
 
+	"emperror.dev/errors"
+	"github.com/MakeNowJust/heredoc"
 	"github.com/pkg/browser"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -32,9 +34,22 @@ import (
 
 type dashboardCommand struct{}
 
+// dashboardViews are the pages of the UI selectable with the --view flag
+var dashboardViews = map[string]string{
+	"topology": "/topology",
+	"tap":      "/tap",
+	"mtls":     "/mtls",
+}
+
 type DashboardOptions struct {
+	// Path is the page of the UI to open, filters of the page are set as query params
+	Path         string
 	QueryParams  map[string]string
 	WrappedToken string
+
+	view      string
+	printURL  bool
+	noBrowser bool
 }
 
 func NewDashboardOptions() *DashboardOptions {
@@ -47,28 +62,102 @@ func NewDashboardCommand(cli cli.CLI, options *DashboardOptions) *cobra.Command
 	c := dashboardCommand{}
 
 	cmd := &cobra.Command{
-		Use:         "dashboard [flags]",
-		Short:       "Open the Backyards dashboard in a web browser",
-		Args:        cobra.NoArgs,
+		Use:   "dashboard [namespace/servicename] [flags]",
+		Short: "Open the Backyards dashboard in a web browser",
+		Example: heredoc.Doc(`
+			# open the topology view filtered to the backyards-demo namespace
+			backyards dashboard --view topology backyards-demo/reviews
+
+			# print a link to the page of a workload on a headless machine
+			backyards dashboard workload backyards-demo/reviews-v1 --no-browser`),
+		Args:        cobra.MaximumNArgs(1),
 		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := login.Login(cli, func(authInfo *auth.Credentials) {
-				options.WrappedToken = authInfo.User.WrappedToken
-			})
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			var resourceID string
+			if len(args) > 0 {
+				resourceID = args[0]
+			}
+			err := options.selectPage("service", resourceID)
 			if err != nil {
 				return err
 			}
-			err = c.run(cli, options)
+
+			return c.open(cli, options)
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "workload namespace/name [flags]",
+		Short: "Open the dashboard on the page of a workload",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := options.selectPage("workload", args[0])
 			if err != nil {
 				return err
 			}
-			return nil
+
+			return c.open(cli, options)
 		},
-	}
+	})
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&options.view, "view", "", "Page of the dashboard to open (topology|tap|mtls)")
+	flags.BoolVar(&options.printURL, "print-url", false, "Print a shareable link of the dashboard page")
+	flags.BoolVar(&options.noBrowser, "no-browser", false, "Do not open a browser, only print the link and keep serving the dashboard")
 
 	return cmd
 }
 
+// selectPage sets the page to open for the view and the optional namespace/name of a service or workload,
+// a resource without a view opens its own page, otherwise the view is filtered to the resource
+func (o *DashboardOptions) selectPage(kind string, resourceID string) error {
+	if o.view != "" {
+		path, ok := dashboardViews[o.view]
+		if !ok {
+			return errors.Errorf("invalid view '%s': must be one of topology|tap|mtls", o.view)
+		}
+		o.Path = path
+	}
+
+	if resourceID == "" {
+		return nil
+	}
+
+	resource, err := util.ParseK8sResourceID(resourceID)
+	if err != nil {
+		return err
+	}
+
+	o.QueryParams["namespaces"] = resource.Namespace
+	if o.view == "" {
+		o.Path = fmt.Sprintf("/%ss/%s/%s", kind, resource.Namespace, resource.Name)
+	} else {
+		o.QueryParams[kind] = resource.Name
+	}
+
+	return nil
+}
+
+func (c *dashboardCommand) open(cli cli.CLI, options *DashboardOptions) error {
+	// the short lived wrapped token is only needed to log in in the browser
+	if !options.noBrowser {
+		err := login.Login(cli, func(authInfo *auth.Credentials) {
+			options.WrappedToken = authInfo.User.WrappedToken
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.run(cli, options)
+}
+
 func (c *dashboardCommand) run(cli cli.CLI, options *DashboardOptions) error {
 	var err error
 	var url string
@@ -78,7 +167,7 @@ func (c *dashboardCommand) run(cli cli.CLI, options *DashboardOptions) error {
 		return err
 	}
 
-	url = endpoint.URLForPath("")
+	url = endpoint.URLForPath(options.Path)
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
@@ -92,14 +181,30 @@ func (c *dashboardCommand) run(cli cli.CLI, options *DashboardOptions) error {
 		return err
 	}
 
+	// the printed link does not contain the login token, so it can be shared
+	if options.printURL || options.noBrowser {
+		fmt.Fprintln(cli.Out(), url)
+	}
+	if options.noBrowser {
+		if cli.InteractiveTerminal() {
+			log.Info("Serving the Backyards UI, press Ctrl+C to stop")
+		}
+		return nil
+	}
+
 	log.Infof("Opening Backyards UI at %s", url)
 
 	if options.WrappedToken != "" {
-		url, err = withQueryParams(url, map[string]string{"wrapped-token": options.WrappedToken})
+		// the login endpoint redirects to the page with its filters
+		params := map[string]string{"wrapped-token": options.WrappedToken}
+		if options.Path != "" {
+			params["redirect"] = options.Path
+		}
+		url, err = withQueryParams(endpoint.URLForPath("/api/login"), options.QueryParams)
 		if err != nil {
 			return err
 		}
-		url, err = withPath(url, "/api/login")
+		url, err = withQueryParams(url, params)
 		if err != nil {
 			return err
 		}
@@ -128,14 +233,3 @@ func withQueryParams(url string, params map[string]string) (string, error) {
 
 	return uri.String(), nil
 }
-
-func withPath(url string, path string) (string, error) {
-	uri, err := neturl.ParseRequestURI(url)
-	if err != nil {
-		return "", err
-	}
-
-	uri.Path = fmt.Sprintf("%s%s", uri.Path, path)
-
-	return uri.String(), nil
-}
@@ -0,0 +1,110 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		view        string
+		kind        string
+		resourceID  string
+		path        string
+		queryParams map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "no view and no resource",
+			kind:        "service",
+			queryParams: map[string]string{},
+		},
+		{
+			name:        "view without resource",
+			view:        "tap",
+			kind:        "service",
+			path:        "/tap",
+			queryParams: map[string]string{},
+		},
+		{
+			name:        "view with service",
+			view:        "topology",
+			kind:        "service",
+			resourceID:  "backyards-demo/reviews",
+			path:        "/topology",
+			queryParams: map[string]string{"namespaces": "backyards-demo", "service": "reviews"},
+		},
+		{
+			name:        "service without view",
+			kind:        "service",
+			resourceID:  "backyards-demo/reviews",
+			path:        "/services/backyards-demo/reviews",
+			queryParams: map[string]string{"namespaces": "backyards-demo"},
+		},
+		{
+			name:        "workload without view",
+			kind:        "workload",
+			resourceID:  "backyards-demo/reviews-v1",
+			path:        "/workloads/backyards-demo/reviews-v1",
+			queryParams: map[string]string{"namespaces": "backyards-demo"},
+		},
+		{
+			name:        "workload with view",
+			view:        "mtls",
+			kind:        "workload",
+			resourceID:  "backyards-demo/reviews-v1",
+			path:        "/mtls",
+			queryParams: map[string]string{"namespaces": "backyards-demo", "workload": "reviews-v1"},
+		},
+		{
+			name:    "invalid view",
+			view:    "graph",
+			kind:    "service",
+			wantErr: true,
+		},
+		{
+			name:       "invalid resource",
+			kind:       "workload",
+			resourceID: "reviews-v1",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			options := NewDashboardOptions()
+			options.view = tt.view
+
+			err := options.selectPage(tt.kind, tt.resourceID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if options.Path != tt.path {
+				t.Errorf("expected path %q, got %q", tt.path, options.Path)
+			}
+			if !reflect.DeepEqual(options.QueryParams, tt.queryParams) {
+				t.Errorf("expected query params %v, got %v", tt.queryParams, options.QueryParams)
+			}
+		})
+	}
+}
@@ -18,21 +18,22 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"emperror.dev/errors"
 	"github.com/spf13/cobra"
 	"istio.io/operator/pkg/object"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/backyards_demo"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
 )
 
 const (
@@ -65,8 +66,12 @@ type InstallOptions struct {
 	istioNamespace  string
 	peerCluster     bool
 	enabledServices []string
+	valueOverrides  helm.ValueOverrides
 
 	DumpResources bool
+	Plan          bool
+	ImageRegistry string
+	ApplyOptions  k8s.ApplyOptions
 }
 
 func NewInstallOptions() *InstallOptions {
@@ -85,7 +90,8 @@ func NewInstallCommand(cli cli.CLI, options *InstallOptions) *cobra.Command {
 		Long: `Installs demo application.
 
 The command automatically applies the resources.
-It can only dump the applicable resources with the '--dump-resources' option.`,
+It can only dump the applicable resources with the '--dump-resources' option,
+or show the changes to the cluster without applying them with the '--plan' option.`,
 		Example: `  # Default install.
   backyards demoapp install
 
@@ -95,6 +101,11 @@ It can only dump the applicable resources with the '--dump-resources' option.`,
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 
+			err := common.ValidateApplyFlags(options.ApplyOptions)
+			if err != nil {
+				return err
+			}
+
 			if options.namespace == "" {
 				options.namespace = backyardsDemoNamespace
 			}
@@ -114,7 +125,11 @@ It can only dump the applicable resources with the '--dump-resources' option.`,
 	cmd.Flags().StringVar(&options.istioNamespace, "istio-namespace", "istio-system", "Namespace of Istio sidecar injector")
 	cmd.Flags().BoolVar(&options.peerCluster, "peer", options.peerCluster, "The destination cluster is a peer in a multi-cluster mesh")
 	cmd.Flags().StringSliceVarP(&options.enabledServices, "enabled-services", "s", options.enabledServices, "Enabled services of the demo app")
+	common.AddValueOverrideFlags(cmd.Flags(), &options.valueOverrides, "f")
 	cmd.Flags().BoolVarP(&options.DumpResources, "dump-resources", "d", options.DumpResources, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.Plan, "plan", options.Plan, "Show the changes to the cluster without applying them")
+	common.AddImageRegistryFlag(cmd.Flags(), &options.ImageRegistry)
+	common.AddApplyFlags(cmd.Flags(), &options.ApplyOptions)
 
 	return cmd
 }
@@ -123,14 +138,21 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	err := c.validate(options.istioNamespace)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, istioNotFoundErrorTemplate, err)
-		return nil
+		if !options.Plan {
+			return nil
+		}
 	}
 
-	objects, err := getBackyardsDemoObjects(options.namespace, options.peerCluster, options.enabledServices...)
+	objects, err := getBackyardsDemoObjects(options.namespace, options.peerCluster, options.valueOverrides, options.enabledServices...)
 	if err != nil {
 		return err
 	}
 	objects.Sort(helm.InstallObjectOrder())
+	images.Relocate(objects, options.ImageRegistry)
+
+	if options.Plan {
+		return common.PrintPlan(cli, "demo application", objects, nil)
+	}
 
 	if !options.DumpResources {
 		client, err := cli.GetK8sClient()
@@ -138,17 +160,10 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 			return err
 		}
 
-		err = k8s.ApplyResources(client, cli.LabelManager(), objects)
-		if err != nil {
-			return err
-		}
-
-		err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(objects), wait.Backoff{
-			Duration: time.Second * 5,
-			Factor:   1,
-			Jitter:   0,
-			Steps:    24,
-		}, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
+		m := resourcemanager.New(client, cli.LabelManager())
+		m.SetApplyOptions(options.ApplyOptions)
+		m.SetObjects(objects)
+		err = m.Install().Do()
 		if err != nil {
 			return err
 		}
@@ -166,7 +181,12 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	return nil
 }
 
-func getBackyardsDemoObjects(namespace string, peerCluster bool, enabledServices ...string) (object.K8sObjects, error) {
+// GetDefaultObjects renders the demo application chart with every service enabled
+func GetDefaultObjects() (object.K8sObjects, error) {
+	return getBackyardsDemoObjects(backyardsDemoNamespace, false, helm.ValueOverrides{})
+}
+
+func getBackyardsDemoObjects(namespace string, peerCluster bool, valueOverrides helm.ValueOverrides, enabledServices ...string) (object.K8sObjects, error) {
 	var values Values
 
 	valuesYAML, err := helm.GetDefaultValues(backyards_demo.Chart)
@@ -190,6 +210,11 @@ func getBackyardsDemoObjects(namespace string, peerCluster bool, enabledServices
 		return nil, errors.WrapIf(err, "could not marshal yaml values")
 	}
 
+	rawValues, err = valueOverrides.Apply(rawValues)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not apply value overrides")
+	}
+
 	objects, err := helm.Render(backyards_demo.Chart, string(rawValues), helm.ReleaseOptions{
 		Name:      "backyards-demo",
 		IsInstall: true,
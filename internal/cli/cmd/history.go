@@ -0,0 +1,254 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+	"github.com/banzaicloud/backyards-cli/pkg/release"
+)
+
+type ReleaseOut struct {
+	Revision   int    `json:"revision"`
+	Time       string `json:"time"`
+	Action     string `json:"action"`
+	CLIVersion string `json:"cliVersion"`
+	Resources  int    `json:"resources"`
+	Source     string `json:"source,omitempty"`
+}
+
+func NewHistoryCommand(cli cli.CLI) *cobra.Command {
+	var releaseName string
+
+	cmd := &cobra.Command{
+		Use:   "history [flags]",
+		Short: "List the recorded revisions of the Backyards release",
+		Long: `Lists the revisions recorded by the install, upgrade, uninstall and rollback commands.
+
+Every revision holds the resources applied or removed by the command, the CLI version and the chart values,
+those are stored compressed in Secrets in the Backyards namespace. The latest ` + strconv.Itoa(release.HistoryLimit) + ` revisions are kept.
+
+A revision can be applied again with the rollback command.`,
+		Example: `  # List the revisions.
+  backyards history
+
+  # Roll back to revision 2.
+  backyards rollback --to 2`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			store, err := newReleaseStore(cli, releaseName)
+			if err != nil {
+				return err
+			}
+
+			releases, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			outs := make([]ReleaseOut, 0, len(releases))
+			for _, r := range releases {
+				o := ReleaseOut{
+					Revision:   r.Revision,
+					Time:       r.Time.Format(time.RFC3339),
+					Action:     string(r.Action),
+					CLIVersion: r.CLIVersion,
+				}
+				if r.Source != 0 {
+					o.Source = strconv.Itoa(r.Source)
+				}
+				objects, err := r.Objects()
+				if err != nil {
+					return err
+				}
+				o.Resources = len(objects)
+				outs = append(outs, o)
+			}
+
+			err = output.Output(&output.Context{
+				Out:     cli.Out(),
+				Color:   cli.Color(),
+				Format:  cli.OutputFormat(),
+				Fields:  []string{"Revision", "Time", "Action", "CLIVersion", "Resources", "Source"},
+				Headers: []string{"Revision", "Time", "Action", "CLI version", "Resources", "Rolled back to"},
+			}, outs)
+			if err != nil {
+				return errors.WrapIf(err, "could not produce output")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&releaseName, "release-name", defaultReleaseName, "Name of the release")
+
+	return cmd
+}
+
+func newReleaseStore(cli cli.CLI, releaseName string) (*release.Store, error) {
+	client, err := cli.GetK8sClient()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get k8s client")
+	}
+
+	return release.NewStore(client, cli.GetPersistentConfig().Namespace(), releaseName), nil
+}
+
+// saveRelease records the objects of a release as a new revision
+func saveRelease(cli cli.CLI, releaseName string, r *release.Release, objects object.K8sObjects) error {
+	manifest, err := objects.YAMLManifest()
+	if err != nil {
+		return errors.WrapIf(err, "could not render release manifest")
+	}
+	r.Manifest = manifest
+	r.CLIVersion = cli.GetRootCommand().Version
+
+	store, err := newReleaseStore(cli, releaseName)
+	if err != nil {
+		return err
+	}
+
+	err = store.Save(r)
+	if err != nil {
+		return errors.WrapIf(err, "could not record release history")
+	}
+
+	log.Infof("%s recorded as revision %d of release %s", r.Action, r.Revision, releaseName)
+
+	return nil
+}
+
+func (c *installCommand) recordRelease(options *InstallOptions, action release.Action) error {
+	// nothing was applied in dump and plan mode, or when the requirements were not met
+	if c.appliedValues == nil {
+		return nil
+	}
+
+	return saveRelease(c.cli, options.releaseName, &release.Release{
+		Action: action,
+		Values: string(c.appliedValues),
+	}, c.appliedObjects)
+}
+
+// removeStaleObjects removes the objects of the latest release which are no longer part of the applied objects,
+// in plan mode only the planned deletions are shown
+func (c *installCommand) removeStaleObjects(options *InstallOptions) error {
+	// without the Backyards objects, e.g. when the requirements were not met, every object of the release would be stale
+	if options.dumpResources || (!options.plan && c.appliedValues == nil) {
+		return nil
+	}
+
+	store, err := newReleaseStore(c.cli, options.releaseName)
+	if err != nil {
+		return err
+	}
+
+	releases, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	stale, err := staleReleaseObjects(releases, c.appliedObjects, c.cli.GetPersistentConfig().Namespace())
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	if options.plan {
+		return common.PrintPlan(c.cli, "resources removed from the release", nil, stale)
+	}
+
+	client, err := c.cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	m := resourcemanager.New(client, c.cli.LabelManager())
+	m.SetObjects(stale)
+	err = m.Uninstall().Do()
+	if err != nil {
+		return errors.WrapIf(err, "could not remove resources no longer part of the release")
+	}
+
+	return nil
+}
+
+// staleReleaseObjects returns the objects of the latest release which are not part of the applied objects
+func staleReleaseObjects(releases []release.Release, applied object.K8sObjects, namespace string) (object.K8sObjects, error) {
+	if len(applied) == 0 || len(releases) == 0 || releases[len(releases)-1].Action == release.UninstallAction {
+		return nil, nil
+	}
+
+	latestObjects, err := releases[len(releases)-1].Objects()
+	if err != nil {
+		return nil, err
+	}
+
+	return missingObjects(latestObjects, applied, namespace), nil
+}
+
+func (c *uninstallCommand) recordRelease(options *UninstallOptions) error {
+	// the history is removed together with the namespace
+	if c.removedValues == nil || !options.keepHistory {
+		return nil
+	}
+
+	err := saveRelease(c.cli, options.releaseName, &release.Release{
+		Action: release.UninstallAction,
+		Values: string(c.removedValues),
+	}, c.removedObjects)
+	if err != nil {
+		return err
+	}
+
+	if options.uninstallEverything {
+		log.Warn("The removed Istio, cert-manager, canary and demo application resources are not recorded, a rollback restores Backyards only")
+	}
+	fmt.Fprintf(c.cli.Out(), "The Backyards namespace is kept with the release history, to remove it use:\n"+
+		"> backyards uninstall\n\n")
+
+	return nil
+}
+
+// withoutNamespace leaves out the given namespace from the objects
+func withoutNamespace(objects object.K8sObjects, namespace string) object.K8sObjects {
+	result := make(object.K8sObjects, 0, len(objects))
+	for _, o := range objects {
+		if o.Kind == "Namespace" && o.Name == namespace {
+			continue
+		}
+		result = append(result, o)
+	}
+
+	return result
+}
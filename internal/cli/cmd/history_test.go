@@ -0,0 +1,95 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"istio.io/operator/pkg/object"
+
+	"github.com/banzaicloud/backyards-cli/pkg/release"
+)
+
+const releaseManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: backyards-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backyards
+  namespace: backyards-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: backyards-node-exporter
+  namespace: backyards-system
+`
+
+func TestRemoveStaleObjectsWithoutBackyards(t *testing.T) {
+	nodeExporter, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: backyards-node-exporter
+  namespace: backyards-system
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the requirements were not met, so only the node exporter was applied, the CLI is not reached
+	c := &installCommand{
+		appliedObjects: nodeExporter,
+	}
+	err = c.removeStaleObjects(&InstallOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestStaleReleaseObjects(t *testing.T) {
+	applied, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: backyards-node-exporter
+  namespace: backyards-system
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases := []release.Release{{Revision: 1, Action: release.InstallAction, Manifest: releaseManifest}}
+
+	stale, err := staleReleaseObjects(releases, applied, "backyards-system")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(stale) != 1 || stale[0].Kind != "Deployment" || stale[0].Name != "backyards" {
+		t.Errorf("only the deployment missing from the applied objects should be stale, got %v", stale)
+	}
+
+	stale, err = staleReleaseObjects(releases, nil, "backyards-system")
+	if err != nil || len(stale) != 0 {
+		t.Errorf("nothing should be stale without applied objects, got %v, %v", stale, err)
+	}
+
+	releases = append(releases, release.Release{Revision: 2, Action: release.UninstallAction, Manifest: releaseManifest})
+	stale, err = staleReleaseObjects(releases, applied, "backyards-system")
+	if err != nil || len(stale) != 0 {
+		t.Errorf("nothing should be stale after an uninstall, got %v, %v", stale, err)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/canary"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/certmanager"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/demoapp"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
+	"github.com/banzaicloud/backyards-cli/pkg/nodeexporter"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type ImageOut struct {
+	Image      string `json:"image"`
+	Components string `json:"components"`
+}
+
+type RelocatedImageOut struct {
+	Image     string `json:"image"`
+	Relocated string `json:"relocated"`
+}
+
+type relocateOptions struct {
+	registry  string
+	imageList string
+}
+
+type chartObjects struct {
+	component string
+	objects   object.K8sObjects
+}
+
+func NewImagesCommand(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "images",
+		Short:       "List and relocate the container images of the embedded charts",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+	}
+
+	cmd.AddCommand(
+		newImagesListCommand(cli),
+		newImagesRelocateCommand(cli),
+	)
+
+	return cmd
+}
+
+func newImagesListCommand(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls", "l"},
+		Short:   "List the container images referenced by the embedded charts",
+		Long: `Lists the container images referenced by the embedded charts of Backyards,
+the Istio operator and the Istio CR, the canary operator, cert-manager, the node exporter and the demo application.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			charts, err := getEmbeddedChartObjects(cli)
+			if err != nil {
+				return err
+			}
+
+			components := make(map[string][]string)
+			for _, chart := range charts {
+				for _, image := range images.List(chart.objects) {
+					components[image] = append(components[image], chart.component)
+				}
+			}
+
+			outs := make([]ImageOut, 0, len(components))
+			for image, names := range components {
+				outs = append(outs, ImageOut{
+					Image:      image,
+					Components: strings.Join(names, ", "),
+				})
+			}
+			sort.Slice(outs, func(i, j int) bool {
+				return outs[i].Image < outs[j].Image
+			})
+
+			return showImages(cli, outs, []string{"Image", "Components"}, []string{"Image", "Components"})
+		},
+	}
+
+	return cmd
+}
+
+func newImagesRelocateCommand(cli cli.CLI) *cobra.Command {
+	options := &relocateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "relocate --registry registry.example.com/path [--image-list images.txt]",
+		Short: "Show the relocated references of the images of the embedded charts",
+		Long: `Shows where the images of the embedded charts are relocated to in a private registry.
+The original registry host is replaced, the repository path and the tag are kept.
+
+The images have to be copied to the registry by a separate tool, the '--image-list' option
+writes a file with a line for every image holding the source and the relocated reference separated by a space.
+
+The install commands pull the relocated images with the same registry given in the '--image-registry' option.`,
+		Example: `  # Show the relocated images.
+  backyards images relocate --registry registry.corp/mesh
+
+  # Write the list of the relocated images, copy them, then install using the private registry.
+  backyards images relocate --registry registry.corp/mesh --image-list backyards-images.txt
+  while read source target; do crane copy "$source" "$target"; done < backyards-images.txt
+  backyards install -a --image-registry registry.corp/mesh`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return relocateImages(cli, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.registry, "registry", "", "Private registry to relocate the images to")
+	cmd.Flags().StringVar(&options.imageList, "image-list", "", "Filename of the list of the source and relocated images to write")
+
+	_ = cmd.MarkFlagRequired("registry")
+
+	return cmd
+}
+
+func relocateImages(cli cli.CLI, options *relocateOptions) error {
+	charts, err := getEmbeddedChartObjects(cli)
+	if err != nil {
+		return err
+	}
+
+	relocated := make(map[string]string)
+	for _, chart := range charts {
+		for image, target := range images.Relocate(chart.objects, options.registry) {
+			relocated[image] = target
+		}
+	}
+
+	if options.imageList != "" {
+		file, err := os.Create(options.imageList)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not create image list file", "file", options.imageList)
+		}
+		defer file.Close()
+
+		err = images.WriteImageList(file, relocated)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Image list written to %s\n", options.imageList)
+	}
+
+	outs := make([]RelocatedImageOut, 0, len(relocated))
+	for image, target := range relocated {
+		outs = append(outs, RelocatedImageOut{
+			Image:     image,
+			Relocated: target,
+		})
+	}
+	sort.Slice(outs, func(i, j int) bool {
+		return outs[i].Image < outs[j].Image
+	})
+
+	return showImages(cli, outs, []string{"Image", "Relocated"}, []string{"Image", "Relocated"})
+}
+
+func getEmbeddedChartObjects(cli cli.CLI) ([]chartObjects, error) {
+	values, err := getValues(defaultReleaseName, istio.IstioNamespace, func(values *Values) {
+		values.Application.Image.Tag = backyardsImageTag
+		values.Web.Image.Tag = backyardsImageTag
+		values.AuditSink.Enabled = true
+		values.CertManager.Enabled = true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	backyardsObjects, err := getBackyardsObjects(values, helm.ValueOverrides{}, cli)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeExporter, err := nodeexporter.NewNodeExporterManager(resourcemanager.New(nil, nil), cli.GetPersistentConfig().Namespace())
+	if err != nil {
+		return nil, err
+	}
+
+	charts := []chartObjects{
+		{component: "backyards", objects: backyardsObjects},
+		{component: "node-exporter", objects: nodeExporter.Resources()},
+	}
+
+	for _, chart := range []struct {
+		component string
+		render    func() (object.K8sObjects, error)
+	}{
+		{"istio", istio.GetDefaultObjects},
+		{"canary", canary.GetDefaultObjects},
+		{"cert-manager", certmanager.GetDefaultObjects},
+		{"demo", demoapp.GetDefaultObjects},
+	} {
+		objects, err := chart.render()
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not render chart", "component", chart.component)
+		}
+		charts = append(charts, chartObjects{component: chart.component, objects: objects})
+	}
+
+	return charts, nil
+}
+
+func showImages(cli cli.CLI, data interface{}, fields, headers []string) error {
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  fields,
+		Headers: headers,
+	}
+
+	err := output.Output(ctx, data)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
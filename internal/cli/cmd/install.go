@@ -20,35 +20,42 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"emperror.dev/errors"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/multierr"
 	"istio.io/operator/pkg/object"
 	v1 "k8s.io/api/core/v1"
+	k8sapimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/backyards"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/canary"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/certmanager"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/demoapp"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
 	"github.com/banzaicloud/backyards-cli/pkg/nodeexporter"
+	"github.com/banzaicloud/backyards-cli/pkg/release"
+	"github.com/banzaicloud/istio-operator/pkg/apis/istio/v1beta1"
 )
 
 const (
 	requirementNotFoundErrorTemplate = "Unable to install Backyards: %s\n"
 	defaultReleaseName               = "backyards"
+	backyardsImageTag                = "1.1.3"
 )
 
 var (
@@ -63,12 +70,18 @@ type installCommand struct {
 	shouldInstallCanary      bool
 	shouldInstallCertManager bool
 	shouldRunDemo            bool
+
+	// values and objects applied by the command, recorded in the release history,
+	// the objects are also collected in plan mode to show the resources removed from the release
+	appliedValues  []byte
+	appliedObjects object.K8sObjects
 }
 
 type InstallOptions struct {
 	releaseName    string
 	istioNamespace string
 	dumpResources  bool
+	plan           bool
 
 	enableAuditSink   bool
 	anonymousAuth     bool
@@ -77,6 +90,9 @@ type InstallOptions struct {
 
 	apiImage       string
 	webImage       string
+	valueOverrides helm.ValueOverrides
+	imageRegistry  string
+	applyOptions   k8s.ApplyOptions
 	trackingIDFunc func() string
 }
 
@@ -105,26 +121,46 @@ func NewInstallCommand(cli cli.CLI, options *InstallOptions) *cobra.Command {
 		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
 		Long: `Installs Backyards.
 
-The command automatically applies the resources.
-It can only dump the applicable resources with the '--dump-resources' option.
+The command automatically applies the resources,
+and removes the resources of the previously recorded release which are no longer part of it.
+It can only dump the applicable resources with the '--dump-resources' option,
+or show the changes to the cluster without applying them with the '--plan' option.
+
+The command can install every component at once with the '--install-everything' option.
+
+The embedded chart values can be overridden with values files ('-f') and '--set key=value' options,
+those are applied over the Backyards chart only, so they cannot be combined with installing the other components.
 
-The command can install every component at once with the '--install-everything' option.`,
+The '--image-registry' option relocates the images of Backyards and every installed component to a private registry.`,
 		Example: `  # Default install.
   backyards install
 
   # Install Backyards into a non-default namespace.
-  backyards install -n backyards-system`,
+  backyards install -n backyards-system
+
+  # Install everything with the images pulled from a private registry.
+  backyards install -a --image-registry registry.corp/mesh`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var err error
 
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 
+			err = common.ValidateApplyFlags(options.applyOptions)
+			if err != nil {
+				return err
+			}
+
 			err = c.shouldInstallComponents(options)
 			if err != nil {
 				return err
 			}
 
+			if !options.valueOverrides.Empty() && (c.shouldInstallIstio || c.shouldInstallCertManager || c.shouldInstallCanary || c.shouldRunDemo) {
+				return errors.New("the '--values' and '--set' options override the Backyards chart only, " +
+					"install Istio, cert-manager, the canary operator and the demo application with their own install commands to override their values")
+			}
+
 			err = c.runSubcommands(options)
 			if err != nil {
 				return err
@@ -140,6 +176,16 @@ The command can install every component at once with the '--install-everything'
 				return err
 			}
 
+			err = c.removeStaleObjects(options)
+			if err != nil {
+				return err
+			}
+
+			err = c.recordRelease(options, release.InstallAction)
+			if err != nil {
+				return err
+			}
+
 			err = c.runDemoInstall(options)
 			if err != nil {
 				return err
@@ -149,23 +195,32 @@ The command can install every component at once with the '--install-everything'
 		},
 	}
 
-	cmd.Flags().StringVar(&options.releaseName, "release-name", defaultReleaseName, "Name of the release")
-	cmd.Flags().StringVar(&options.istioNamespace, "istio-namespace", istio.DefaultNamespace, "Namespace of Istio sidecar injector")
+	addBackyardsFlags(cmd.Flags(), options)
 
 	cmd.Flags().BoolVarP(&options.installEverything, "install-everything", "a", options.installEverything, "Install all required components at once")
 	cmd.Flags().BoolVar(&options.runDemo, "run-demo", options.runDemo, "Install demo application, send load and open up the dashboard")
 
-	cmd.Flags().BoolVar(&options.enableAuditSink, "enable-auditsink", options.enableAuditSink, "Enable deploying the auditsink service and sending audit logs over http")
-	cmd.Flags().BoolVar(&options.anonymousAuth, "anonymous-auth", options.anonymousAuth, "Switch to anonymous mode")
-
-	cmd.Flags().StringVar(&options.apiImage, "api-image", options.apiImage, "Image for the API")
-	cmd.Flags().StringVar(&options.webImage, "web-image", options.webImage, "Image for the frontend")
-
 	cmd.Flags().BoolVarP(&options.dumpResources, "dump-resources", "d", options.dumpResources, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.plan, "plan", options.plan, "Show the changes to the cluster without applying them")
 
 	return cmd
 }
 
+// addBackyardsFlags registers the flags affecting the rendered Backyards resources, shared by the install and upgrade commands
+func addBackyardsFlags(flags *pflag.FlagSet, options *InstallOptions) {
+	flags.StringVar(&options.releaseName, "release-name", defaultReleaseName, "Name of the release")
+	flags.StringVar(&options.istioNamespace, "istio-namespace", istio.DefaultNamespace, "Namespace of Istio sidecar injector")
+
+	flags.BoolVar(&options.enableAuditSink, "enable-auditsink", options.enableAuditSink, "Enable deploying the auditsink service and sending audit logs over http")
+	flags.BoolVar(&options.anonymousAuth, "anonymous-auth", options.anonymousAuth, "Switch to anonymous mode")
+
+	flags.StringVar(&options.apiImage, "api-image", options.apiImage, "Image for the API")
+	flags.StringVar(&options.webImage, "web-image", options.webImage, "Image for the frontend")
+	common.AddValueOverrideFlags(flags, &options.valueOverrides, "f")
+	common.AddImageRegistryFlag(flags, &options.imageRegistry)
+	common.AddApplyFlags(flags, &options.applyOptions)
+}
+
 func (c *installCommand) run(options *InstallOptions) error {
 	err := c.validate(options)
 	if err != nil {
@@ -175,12 +230,14 @@ func (c *installCommand) run(options *InstallOptions) error {
 			errorItems += "\n - " + e.Error()
 		}
 		fmt.Fprintf(os.Stderr, requirementNotFoundErrorTemplate, errorItems)
-		return nil
+		if !options.plan {
+			return nil
+		}
 	}
 
 	values, err := getValues(options.releaseName, options.istioNamespace, func(values *Values) {
-		values.Application.Image.Tag = "1.1.3"
-		values.Web.Image.Tag = "1.1.3"
+		values.Application.Image.Tag = backyardsImageTag
+		values.Web.Image.Tag = backyardsImageTag
 
 		if options.enableAuditSink {
 			values.AuditSink.Enabled = true
@@ -222,17 +279,36 @@ func (c *installCommand) run(options *InstallOptions) error {
 		return err
 	}
 
-	err = c.setTracingAddress(values)
-	if err != nil {
-		return err
+	if !options.plan {
+		err = c.setTracingAddress(values)
+		if err != nil {
+			return err
+		}
 	}
 
-	objects, err := getBackyardsObjects(values, c.cli)
+	objects, err := getBackyardsObjects(values, options.valueOverrides, c.cli)
 	if err != nil {
 		return err
 	}
 
 	objects.Sort(helm.InstallObjectOrder())
+	images.Relocate(objects, options.imageRegistry)
+
+	if options.plan {
+		c.appliedObjects = append(c.appliedObjects, objects...)
+
+		items, err := common.PlanItems(c.cli, objects, nil)
+		if err != nil {
+			return err
+		}
+		tracing, err := c.planTracingAddress(values)
+		if err != nil {
+			return err
+		}
+		common.PrintPlanItems(c.cli, "Backyards", append(items, tracing))
+
+		return nil
+	}
 
 	if !options.dumpResources {
 		client, err := c.cli.GetK8sClient()
@@ -240,20 +316,19 @@ func (c *installCommand) run(options *InstallOptions) error {
 			return err
 		}
 
-		err = k8s.ApplyResources(client, c.cli.LabelManager(), objects)
+		m := resourcemanager.New(client, c.cli.LabelManager())
+		m.SetApplyOptions(options.applyOptions)
+		m.SetObjects(objects)
+		err = m.Install().Do()
 		if err != nil {
 			return err
 		}
 
-		err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(objects), wait.Backoff{
-			Duration: time.Second * 5,
-			Factor:   1,
-			Jitter:   0,
-			Steps:    24,
-		}, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
+		c.appliedValues, err = getBackyardsValues(values, options.valueOverrides)
 		if err != nil {
 			return err
 		}
+		c.appliedObjects = append(c.appliedObjects, objects...)
 	} else {
 		yaml, err := objects.YAMLManifest()
 		if err != nil {
@@ -290,12 +365,32 @@ func getValues(releaseName, istioNamespace string, valueOverrideFunc func(values
 	return values, nil
 }
 
-func getBackyardsObjects(values Values, cli cli.CLI) (object.K8sObjects, error) {
+// getBackyardsValues returns the chart values with the overrides applied
+func getBackyardsValues(values Values, valueOverrides helm.ValueOverrides) ([]byte, error) {
 	rawValues, err := yaml.Marshal(values)
 	if err != nil {
 		return nil, errors.WrapIf(err, "could not marshal yaml values")
 	}
 
+	rawValues, err = valueOverrides.Apply(rawValues)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not apply value overrides")
+	}
+
+	return rawValues, nil
+}
+
+func getBackyardsObjects(values Values, valueOverrides helm.ValueOverrides, cli cli.CLI) (object.K8sObjects, error) {
+	rawValues, err := getBackyardsValues(values, valueOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderBackyardsObjects(rawValues, cli)
+}
+
+// renderBackyardsObjects renders the Backyards chart with the final values, e.g. the values recorded in a release
+func renderBackyardsObjects(rawValues []byte, cli cli.CLI) (object.K8sObjects, error) {
 	objects, err := helm.Render(backyards.Chart, string(rawValues), helm.ReleaseOptions{
 		Name:      "backyards",
 		IsInstall: true,
@@ -323,7 +418,7 @@ func (c *installCommand) setTracingAddress(values Values) error {
 	payload := []patchStringValue{{
 		Op:    "replace",
 		Path:  "/spec/tracing/zipkin/address",
-		Value: fmt.Sprintf("%s.%s:%d", values.Tracing.Service.Name, c.cli.GetPersistentConfig().Namespace(), values.Tracing.Service.ExternalPort),
+		Value: c.tracingAddress(values),
 	}}
 	payloadBytes, _ := json.Marshal(payload)
 
@@ -335,6 +430,46 @@ func (c *installCommand) setTracingAddress(values Values) error {
 	return nil
 }
 
+// planTracingAddress shows the change of the tracing address setTracingAddress would make in the Istio CR
+func (c *installCommand) planTracingAddress(values Values) (k8s.PlanItem, error) {
+	cl, err := c.cli.GetK8sClient()
+	if err != nil {
+		return k8s.PlanItem{}, errors.WrapIf(err, "could not get k8s client")
+	}
+
+	istioCR, err := istio.FetchIstioCR(cl)
+	// the Istio CR is only created by the Istio install planned along with Backyards
+	if err == istio.ErrIstioCRNotFound || k8sapimeta.IsNoMatchError(errors.Cause(err)) {
+		return k8s.PlanItem{
+			Name:      fmt.Sprintf("istio.%s", v1beta1.SchemeGroupVersion.Group),
+			Action:    k8s.PlanUpdate,
+			Ownership: fmt.Sprintf("managed by the Istio operator, tracing address set to %s once the Istio CR is created", c.tracingAddress(values)),
+		}, nil
+	}
+	if err != nil {
+		return k8s.PlanItem{}, err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(istioCR)
+	if err != nil {
+		return k8s.PlanItem{}, errors.WrapIf(err, "could not convert Istio CR")
+	}
+	current := &unstructured.Unstructured{Object: content}
+	current.SetGroupVersionKind(v1beta1.SchemeGroupVersion.WithKind("Istio"))
+
+	desired := current.DeepCopy()
+	err = unstructured.SetNestedField(desired.Object, c.tracingAddress(values), "spec", "tracing", "zipkin", "address")
+	if err != nil {
+		return k8s.PlanItem{}, errors.WrapIf(err, "could not set tracing address")
+	}
+
+	return k8s.PlanPatch(current, desired, "managed by the Istio operator, tracing address patched by the CLI", c.cli.Color())
+}
+
+func (c *installCommand) tracingAddress(values Values) string {
+	return fmt.Sprintf("%s.%s:%d", values.Tracing.Service.Name, c.cli.GetPersistentConfig().Namespace(), values.Tracing.Service.ExternalPort)
+}
+
 func (c *installCommand) validate(options *InstallOptions) error {
 	var istioHealthy bool
 	var combinedErr error
@@ -494,6 +629,9 @@ func (c *installCommand) runSubcommands(options *InstallOptions) error {
 		if options.dumpResources {
 			scmdOptions.DumpResources = true
 		}
+		scmdOptions.Plan = options.plan
+		scmdOptions.ImageRegistry = options.imageRegistry
+		scmdOptions.ApplyOptions = options.applyOptions
 		scmd = istio.NewInstallCommand(c.cli, scmdOptions)
 		err = scmd.RunE(scmd, nil)
 		if err != nil {
@@ -506,6 +644,9 @@ func (c *installCommand) runSubcommands(options *InstallOptions) error {
 		if options.dumpResources {
 			scmdOptions.DumpResources = true
 		}
+		scmdOptions.Plan = options.plan
+		scmdOptions.ImageRegistry = options.imageRegistry
+		scmdOptions.ApplyOptions = options.applyOptions
 		scmd = certmanager.NewInstallCommand(c.cli, scmdOptions)
 		err = scmd.RunE(scmd, nil)
 		if err != nil {
@@ -518,6 +659,9 @@ func (c *installCommand) runSubcommands(options *InstallOptions) error {
 		if options.dumpResources {
 			scmdOptions.DumpResources = true
 		}
+		scmdOptions.Plan = options.plan
+		scmdOptions.ImageRegistry = options.imageRegistry
+		scmdOptions.ApplyOptions = options.applyOptions
 		scmd = canary.NewInstallCommand(c.cli, scmdOptions)
 		err = scmd.RunE(scmd, nil)
 		if err != nil {
@@ -537,6 +681,9 @@ func (c *installCommand) runDemoInstall(options *InstallOptions) error {
 		if options.dumpResources {
 			scmdOptions.DumpResources = true
 		}
+		scmdOptions.Plan = options.plan
+		scmdOptions.ImageRegistry = options.imageRegistry
+		scmdOptions.ApplyOptions = options.applyOptions
 		scmd = demoapp.NewInstallCommand(c.cli, scmdOptions)
 		err = scmd.RunE(scmd, nil)
 		if err != nil {
@@ -544,7 +691,7 @@ func (c *installCommand) runDemoInstall(options *InstallOptions) error {
 		}
 	}
 
-	if c.shouldRunDemo {
+	if c.shouldRunDemo && !options.plan {
 		scmdOptions := demoapp.NewLoadOptions()
 		scmdOptions.Nowait = true
 		scmd := demoapp.NewLoadCommand(c.cli, scmdOptions)
@@ -571,10 +718,19 @@ func (c *installCommand) runNodeExporterInstall(options *InstallOptions) error {
 		return err
 	}
 
-	m, err := nodeexporter.NewNodeExporterManager(resourcemanager.New(client, c.cli.LabelManager()), c.cli.GetPersistentConfig().Namespace())
+	rm := resourcemanager.New(client, c.cli.LabelManager())
+	rm.SetApplyOptions(options.applyOptions)
+	m, err := nodeexporter.NewNodeExporterManager(rm, c.cli.GetPersistentConfig().Namespace())
 	if err != nil {
 		return err
 	}
+	images.Relocate(m.Resources(), options.imageRegistry)
+
+	if options.plan {
+		c.appliedObjects = append(c.appliedObjects, m.Resources()...)
+		return common.PrintPlan(c.cli, "node exporter", m.Install().Resources(), nil)
+	}
+
 	if options.dumpResources {
 		yaml, err := m.Install().YAML()
 		if err != nil {
@@ -589,6 +745,7 @@ func (c *installCommand) runNodeExporterInstall(options *InstallOptions) error {
 		if err != nil {
 			return err
 		}
+		c.appliedObjects = append(c.appliedObjects, m.Resources()...)
 	}
 
 	return nil
@@ -201,7 +201,7 @@ func (c *attachCommand) createServiceAccount(k8sclient k8sclient.Client, namespa
 	}
 	objects.Sort(helm.InstallObjectOrder())
 
-	err = k8s.ApplyResources(k8sclient, c.cli.LabelManager(), objects)
+	err = k8s.ApplyResources(k8sclient, c.cli.LabelManager(), objects, k8s.ApplyOptions{})
 	if err != nil {
 		return errors.WrapIf(err, "could not apply k8s resources")
 	}
@@ -37,8 +37,10 @@ import (
 
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/istio_assets"
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/istio_operator"
+	cmdCommon "github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
+	"github.com/banzaicloud/backyards-cli/pkg/images"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
 	"github.com/banzaicloud/backyards-cli/pkg/util"
 	"github.com/banzaicloud/istio-operator/pkg/apis/istio/v1beta1"
@@ -64,10 +66,15 @@ type installCommand struct {
 
 type InstallOptions struct {
 	DumpResources bool
+	Plan          bool
 	Force         bool
+	Upgrade       bool
+	ImageRegistry string
+	ApplyOptions  k8s.ApplyOptions
 
 	istioCRFilename string
 	releaseName     string
+	valueOverrides  helm.ValueOverrides
 }
 
 func NewInstallOptions() *InstallOptions {
@@ -86,10 +93,13 @@ func NewInstallCommand(cli cli.CLI, options *InstallOptions) *cobra.Command {
 		Long: `Installs Istio utilizing Banzai Cloud's Istio-operator.
 
 The command automatically applies the resources.
-It can only dump the applicable resources with the '--dump-resources' option.
+It can only dump the applicable resources with the '--dump-resources' option,
+or show the changes to the cluster without applying them with the '--plan' option.
 
 The manual mode is a two phase process as the operator needs custom CRDs to work.
-The installer automatically detects whether the CRDs are installed or not, and behaves accordingly.`,
+The installer automatically detects whether the CRDs are installed or not, and behaves accordingly.
+
+The embedded values of the Istio operator chart can be overridden with the '--values' and '--set' options.`,
 		Example: `  # Default install.
   backyards istio install
 
@@ -99,21 +109,30 @@ The installer automatically detects whether the CRDs are installed or not, and b
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
 
+			err := cmdCommon.ValidateApplyFlags(options.ApplyOptions)
+			if err != nil {
+				return err
+			}
+
 			return c.run(cli, options)
 		},
 	}
 
 	cmd.Flags().StringVar(&options.releaseName, "release-name", "istio-operator", "Name of the release")
 	cmd.Flags().StringVarP(&options.istioCRFilename, "istio-cr-file", "f", "", "Filename of a custom Istio CR yaml")
+	cmdCommon.AddValueOverrideFlags(cmd.Flags(), &options.valueOverrides, "")
+	cmdCommon.AddImageRegistryFlag(cmd.Flags(), &options.ImageRegistry)
+	cmdCommon.AddApplyFlags(cmd.Flags(), &options.ApplyOptions)
 
 	cmd.Flags().BoolVarP(&options.DumpResources, "dump-resources", "d", options.DumpResources, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.Plan, "plan", options.Plan, "Show the changes to the cluster without applying them")
 	cmd.Flags().BoolVarP(&options.Force, "force", "", options.Force, "Force Istio upgrade (only applicable in non-interactive mode)")
 
 	return cmd
 }
 
 func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
-	objects, err := getIstioOperatorObjects(options.releaseName)
+	objects, err := getIstioOperatorObjects(options.releaseName, options.valueOverrides)
 	if err != nil {
 		return err
 	}
@@ -130,7 +149,7 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 	}
 
 	isUpgrade := existingIstioCRName != nil
-	if isUpgrade {
+	if isUpgrade && !options.Plan && !options.Upgrade {
 		isExternalIstioCR := options.istioCRFilename != ""
 		nameDiffers := istioCRObj.Name != *existingIstioCRName // TODO is `istioCRObj.Name` correct?
 
@@ -173,9 +192,14 @@ func (c *installCommand) run(cli cli.CLI, options *InstallOptions) error {
 		}
 	}
 	objs = append(objs, istioCRObj)
+	images.Relocate(objs, options.ImageRegistry)
+
+	if options.Plan {
+		return cmdCommon.PrintPlan(cli, "Istio", append(crds, objs...), nil)
+	}
 
 	if !options.DumpResources {
-		err := c.applyResources(crds, objs)
+		err := c.applyResources(crds, objs, options.ApplyOptions)
 		if err != nil {
 			return errors.WrapIf(err, "could not apply resources")
 		}
@@ -221,14 +245,14 @@ func (c *installCommand) getExistingIstioCRName() (*string, error) {
 	return nil, nil
 }
 
-func (c *installCommand) applyResources(crds, objects object.K8sObjects) error {
+func (c *installCommand) applyResources(crds, objects object.K8sObjects, applyOptions k8s.ApplyOptions) error {
 	client, err := c.cli.GetK8sClient()
 	if err != nil {
 		return err
 	}
 
 	// apply CRDs first
-	err = k8s.ApplyResources(client, c.cli.LabelManager(), crds)
+	err = k8s.ApplyResources(client, c.cli.LabelManager(), crds, applyOptions)
 	if err != nil {
 		return errors.WrapIf(err, "could not apply k8s resources")
 	}
@@ -251,16 +275,32 @@ func (c *installCommand) applyResources(crds, objects object.K8sObjects) error {
 		return err
 	}
 
-	// apply the rest of the resources
-	err = k8s.ApplyResources(client, c.cli.LabelManager(), objects)
+	operatorObjects := make(object.K8sObjects, 0)
+	istioObjects := make(object.K8sObjects, 0)
+	for _, obj := range objects {
+		if obj.Kind == "Istio" {
+			istioObjects = append(istioObjects, obj)
+		} else {
+			operatorObjects = append(operatorObjects, obj)
+		}
+	}
+
+	// apply the operator and wait for it to become ready, so the Istio CR is reconciled by the new operator version
+	err = k8s.ApplyResources(client, c.cli.LabelManager(), operatorObjects, applyOptions)
 	if err != nil {
 		return errors.WrapIf(err, "could not apply k8s resources")
 	}
 
-	err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(objects, "StatefulSet"), backoff, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
+	err = k8s.WaitForResourcesConditions(client, k8s.NamesWithGVKFromK8sObjects(operatorObjects, "StatefulSet"), backoff, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
 	if err != nil {
 		return err
 	}
+
+	err = k8s.ApplyResources(client, c.cli.LabelManager(), istioObjects, applyOptions)
+	if err != nil {
+		return errors.WrapIf(err, "could not apply k8s resources")
+	}
+
 	err = k8s.WaitForResourcesConditions(client, c.getIstioDeploymentsToWaitFor(), backoff, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
 	if err != nil {
 		return err
@@ -321,7 +361,23 @@ func (c *installCommand) getIstioDeploymentsToWaitFor() []k8s.NamespacedNameWith
 	return deployments
 }
 
-func getIstioOperatorObjects(releaseName string) (object.K8sObjects, error) {
+// GetDefaultObjects renders the Istio operator chart and the default Istio CR
+func GetDefaultObjects() (object.K8sObjects, error) {
+	objects, err := getIstioOperatorObjects("istio-operator", helm.ValueOverrides{})
+	if err != nil {
+		return nil, err
+	}
+
+	istioCRObj, err := getIstioCR("")
+	if err != nil {
+		return nil, err
+	}
+	modifyIstioCR(istioCRObj, IstioNamespace, nil)
+
+	return append(objects, istioCRObj), nil
+}
+
+func getIstioOperatorObjects(releaseName string, valueOverrides helm.ValueOverrides) (object.K8sObjects, error) {
 	var values Values
 
 	valuesYAML, err := helm.GetDefaultValues(istio_operator.Chart)
@@ -341,6 +397,11 @@ func getIstioOperatorObjects(releaseName string) (object.K8sObjects, error) {
 		return nil, errors.WrapIf(err, "could not marshal yaml values")
 	}
 
+	rawValues, err = valueOverrides.Apply(rawValues)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not apply value overrides")
+	}
+
 	objects, err := helm.Render(istio_operator.Chart, string(rawValues), helm.ReleaseOptions{
 		Name:      "istio-operator",
 		IsInstall: true,
@@ -85,7 +85,7 @@ It can only dump the removable resources with the '--dump-resources' option.`,
 }
 
 func (c *uninstallCommand) run(cli cli.CLI, options *UninstallOptions) error {
-	objects, err := getIstioOperatorObjects(options.releaseName)
+	objects, err := getIstioOperatorObjects(options.releaseName, helm.ValueOverrides{})
 	if err != nil {
 		return err
 	}
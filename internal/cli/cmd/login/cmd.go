@@ -35,10 +35,18 @@ var mutex sync.Mutex
 var inMemoryAuthInfo *auth.Credentials
 
 func NewLoginCmd(cli cli.CLI) *cobra.Command {
+	options := newOIDCOptions()
+
 	cmd := &cobra.Command{
-		Use:         "login",
-		Aliases:     []string{"l"},
-		Short:       "Log in to Backyards",
+		Use:     "login",
+		Aliases: []string{"l"},
+		Short:   "Log in to Backyards",
+		Long: heredoc.Doc(`
+			Log in to Backyards.
+
+			The received token is saved to the OS keyring, the macOS keychain or the Secret Service on Linux.
+			Without a keyring it is saved to the secrets.enc file in ~/.banzai/backyards, which only obfuscates it:
+			the key is saved next to it in the secrets.key file, anyone who can read both files can read the token.`),
 		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var err error
@@ -58,36 +66,50 @@ func NewLoginCmd(cli cli.CLI) *cobra.Command {
 					return err
 				}
 			}
-			err = Login(cli, func(body *auth.Credentials) {
+			onAuth := func(body *auth.Credentials) {
 				if cli.InteractiveTerminal() {
 					logrus.Infof("Login token: %s", body.User.WrappedToken)
-					cli.GetPersistentConfig().SetToken(body.User.Token)
 				} else {
 					fmt.Println(body.User.WrappedToken)
 				}
-			})
+			}
+			if options.issuer != "" {
+				return loginWithOIDC(cli, options, onAuth)
+			}
+			err = Login(cli, onAuth)
 			return err
 		},
 	}
 
+	options.bindFlags(cmd.Flags())
+
 	return cmd
 }
 
 func Login(cli cli.CLI, onAuth func(*auth.Credentials)) error {
+	return login(cli, "", onAuth)
+}
+
+// LoginWithToken logs in with a token of an external identity provider instead of the credentials of the kubeconfig
+func LoginWithToken(cli cli.CLI, token string, onAuth func(*auth.Credentials)) error {
+	return login(cli, token, onAuth)
+}
+
+func login(c cli.CLI, token string, onAuth func(*auth.Credentials)) error {
 	mutex.Lock()
 	defer mutex.Unlock()
-	if inMemoryAuthInfo != nil {
+	if token == "" && inMemoryAuthInfo != nil && !auth.NeedsRenewal(inMemoryAuthInfo.User.Token) {
 		if onAuth != nil {
 			onAuth(inMemoryAuthInfo)
 		}
 		return nil
 	}
-	config, err := cli.GetK8sConfig()
+	config, err := c.GetK8sConfig()
 	if err != nil {
 		return err
 	}
 
-	endpoint, err := cli.InitializedEndpoint()
+	endpoint, err := c.InitializedEndpoint()
 	if err != nil {
 		return err
 	}
@@ -99,6 +121,9 @@ func Login(cli cli.CLI, onAuth func(*auth.Credentials)) error {
 	}
 
 	authClient := auth.NewClient(config, url)
+	if token != "" {
+		authClient = auth.NewTokenClient(config, url, token)
+	}
 	authInfo, err := authClient.Login()
 	if err != nil {
 		if err != servererror.ErrAuthDisabled {
@@ -107,16 +132,22 @@ func Login(cli cli.CLI, onAuth func(*auth.Credentials)) error {
 	}
 	if authInfo != nil {
 		inMemoryAuthInfo = authInfo
-		if cli.InteractiveTerminal() {
+		if c.InteractiveTerminal() {
 			logrus.Infof("Logged in as %s", authInfo.User.Name)
 			logrus.Debugf("Token: %s", authInfo.User.Token)
 			logrus.Debugf("Wrapped token: %s", authInfo.User.WrappedToken)
 		}
-		cli.GetPersistentConfig().SetToken(authInfo.User.Token)
+		// the token of an overridden identity, e.g. an impersonated user, is only kept in memory
+		if cli.AuthOverrides().Empty() {
+			err = c.GetPersistentConfig().SetToken(authInfo.User.Token)
+			if err != nil {
+				return err
+			}
+		}
 		if onAuth != nil {
 			onAuth(authInfo)
 		}
-	} else if cli.InteractiveTerminal() {
+	} else if c.InteractiveTerminal() {
 		logrus.Debug("Backyards authentication is disabled")
 	}
 	return nil
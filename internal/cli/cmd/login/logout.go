@@ -0,0 +1,53 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package login
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+func NewLogoutCmd(cli cli.CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:         "logout",
+		Short:       "Log out of Backyards by removing the saved token",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			mutex.Lock()
+			inMemoryAuthInfo = nil
+			mutex.Unlock()
+
+			err := cli.GetPersistentConfig().SetToken("")
+			if err != nil {
+				return err
+			}
+
+			if cli.GetPersistentConfig().Token() != "" {
+				logrus.Warn("the saved token is removed, but a token is still given by the --token flag")
+			}
+
+			logrus.Info("Logged out")
+
+			return nil
+		},
+	}
+}
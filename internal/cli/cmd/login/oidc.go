@@ -0,0 +1,109 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package login
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/pkg/browser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/banzaicloud/backyards-cli/pkg/auth"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+const (
+	authCodeFlow   = "auth-code"
+	deviceCodeFlow = "device-code"
+
+	oidcLoginTimeout = 5 * time.Minute
+)
+
+type oidcOptions struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	flow         string
+	redirectPort int
+	noBrowser    bool
+}
+
+func newOIDCOptions() *oidcOptions {
+	return &oidcOptions{}
+}
+
+func (o *oidcOptions) bindFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.issuer, "oidc-issuer", "", "Log in through the OIDC issuer at this URL instead of using the kubeconfig credentials")
+	flags.StringVar(&o.clientID, "oidc-client-id", "backyards-cli", "OIDC client ID")
+	flags.StringVar(&o.clientSecret, "oidc-client-secret", "", "OIDC client secret, if the client is not public")
+	flags.StringSliceVar(&o.scopes, "oidc-scopes", []string{"openid", "email", "profile"}, "OIDC scopes to request")
+	flags.StringVar(&o.flow, "oidc-flow", authCodeFlow, fmt.Sprintf("OIDC flow to use (%s|%s)", authCodeFlow, deviceCodeFlow))
+	flags.IntVar(&o.redirectPort, "oidc-redirect-port", 8000, "Local port of the redirect URL of the auth-code flow, 0 selects a random port")
+	flags.BoolVar(&o.noBrowser, "no-browser", false, "Print the URL to log in at instead of opening it in a browser")
+}
+
+// loginWithOIDC gets an ID token from the OIDC issuer and exchanges it for Backyards credentials
+func loginWithOIDC(cli cli.CLI, options *oidcOptions, onAuth func(*auth.Credentials)) error {
+	if options.flow != authCodeFlow && options.flow != deviceCodeFlow {
+		return errors.NewWithDetails("invalid OIDC flow", "flow", options.flow)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcLoginTimeout)
+	defer cancel()
+
+	provider, err := auth.NewOIDCProvider(ctx, auth.OIDCConfig{
+		Issuer:       options.issuer,
+		ClientID:     options.clientID,
+		ClientSecret: options.clientSecret,
+		Scopes:       options.scopes,
+		RedirectPort: options.redirectPort,
+	})
+	if err != nil {
+		return err
+	}
+
+	var token string
+	switch options.flow {
+	case authCodeFlow:
+		token, err = provider.AuthCodeWithPKCE(ctx, func(authURL string) error {
+			if !options.noBrowser {
+				if err := browser.OpenURL(authURL); err == nil {
+					logrus.Infof("Continue the login in the browser, waiting for the redirect")
+					return nil
+				}
+			}
+			fmt.Fprintf(cli.GetRootCommand().ErrOrStderr(), "Open the following URL to log in:\n\n  %s\n\n", authURL)
+			return nil
+		})
+	default:
+		token, err = provider.DeviceCode(ctx, func(authorization *auth.DeviceAuthorization) {
+			if authorization.VerificationURIComplete != "" {
+				fmt.Fprintf(cli.GetRootCommand().ErrOrStderr(), "Open the following URL to log in:\n\n  %s\n\n", authorization.VerificationURIComplete)
+				return
+			}
+			fmt.Fprintf(cli.GetRootCommand().ErrOrStderr(), "Open %s and enter the code %s to log in\n\n", authorization.VerificationURI, authorization.UserCode)
+		})
+	}
+	if err != nil {
+		return errors.WrapIf(err, "OIDC login failed")
+	}
+
+	return LoginWithToken(cli, token, onAuth)
+}
@@ -0,0 +1,101 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package login
+
+import (
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/auth"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type UserOut struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups"`
+	Expiry string   `json:"expiry,omitempty"`
+}
+
+type userTableOut struct {
+	Name   string
+	Groups string
+	Expiry string
+}
+
+func NewWhoamiCmd(cli cli.CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:         "whoami",
+		Short:       "Show the user of the saved Backyards token",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			// only the saved token is decoded, logging in again would replace it
+			token := cli.GetPersistentConfig().Token()
+			if token == "" {
+				logrus.Info("not logged in")
+				return nil
+			}
+			credentials, err := auth.TokenCredentials(token)
+			if err != nil {
+				return errors.WrapIf(err, "could not decode saved token")
+			}
+
+			out := UserOut{
+				Name:   credentials.User.Name,
+				Groups: credentials.User.Groups,
+			}
+			expiry, err := credentials.Expiry()
+			if err != nil {
+				logrus.Debugf("could not get token expiry: %s", err)
+			} else if !expiry.IsZero() {
+				out.Expiry = expiry.Local().Format(time.RFC3339)
+				if time.Now().After(expiry) {
+					logrus.Warn("the saved token is expired, log in again")
+				}
+			}
+
+			var data interface{} = out
+			if cli.OutputFormat() == output.OutputFormatTable {
+				data = userTableOut{
+					Name:   out.Name,
+					Groups: strings.Join(out.Groups, ", "),
+					Expiry: out.Expiry,
+				}
+			}
+
+			err = output.Output(&output.Context{
+				Out:     cli.Out(),
+				Color:   cli.Color(),
+				Format:  cli.OutputFormat(),
+				Fields:  []string{"Name", "Groups", "Expiry"},
+				Headers: []string{"User", "Groups", "Expires"},
+			}, data)
+			if err != nil {
+				return errors.WrapIf(err, "could not produce output")
+			}
+
+			return nil
+		},
+	}
+}
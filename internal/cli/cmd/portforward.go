@@ -0,0 +1,113 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+// BackendPaths are the paths the bundled backends are served at by Backyards
+var BackendPaths = map[string]string{
+	"api":        "/api",
+	"grafana":    "/grafana",
+	"jaeger":     "/jaeger",
+	"prometheus": "/prometheus",
+}
+
+type portForwardCommand struct{}
+
+type PortForwardOptions struct {
+	backend string
+	port    int
+}
+
+func NewPortForwardOptions() *PortForwardOptions {
+	return &PortForwardOptions{}
+}
+
+func NewPortForwardCommand(cli cli.CLI, options *PortForwardOptions) *cobra.Command {
+	c := portForwardCommand{}
+
+	cmd := &cobra.Command{
+		Use:         fmt.Sprintf("port-forward %s [--port PORT]", strings.Join(backendNames(), "|")),
+		Short:       "Make a backend of Backyards reachable on a local port",
+		Args:        cobra.ExactArgs(1),
+		ValidArgs:   backendNames(),
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			options.backend = args[0]
+
+			return c.run(cli, options)
+		},
+	}
+
+	cmd.Flags().IntVar(&options.port, "port", 0, "Local port to listen on (a random port is used if 0)")
+
+	return cmd
+}
+
+func (c *portForwardCommand) run(cli cli.CLI, options *PortForwardOptions) error {
+	path, ok := BackendPaths[options.backend]
+	if !ok {
+		return errors.Errorf("unknown backend %s, valid backends: %s", options.backend, strings.Join(backendNames(), ", "))
+	}
+
+	if cli.GetPersistentConfig().BaseURL() != "" {
+		log.Infof("Backyards is reachable through its base URL, nothing to forward")
+		fmt.Fprintln(cli.Out(), cli.GetPersistentConfig().BaseURL()+path)
+		return nil
+	}
+
+	ep, err := cli.NewEndpoint(options.port)
+	if err != nil {
+		return err
+	}
+	defer ep.Close()
+
+	log.Infof("Forwarding %s, press Ctrl+C to stop", options.backend)
+	fmt.Fprintln(cli.Out(), ep.URLForPath(path))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	<-signals
+
+	return nil
+}
+
+func backendNames() []string {
+	names := make([]string, 0, len(BackendPaths))
+	for name := range BackendPaths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
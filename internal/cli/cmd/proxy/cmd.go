@@ -0,0 +1,44 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+func NewRootCmd(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Manage the background proxy to Backyards",
+		Long: `Manage the background proxy to Backyards.
+
+Every command connects to Backyards through a proxy or port-forward started for the single command.
+The background proxy keeps a connection open for the current cluster, and the later commands reuse it
+instead of starting their own, unless the '--local-port' or the '--base-url' option is given.
+A proxy serving on a unix socket is not used by the dashboard command, as browsers cannot connect to it.`,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
+	}
+
+	cmd.AddCommand(
+		NewStartCommand(cli),
+		NewStopCommand(cli),
+		NewStatusCommand(cli),
+	)
+
+	return cmd
+}
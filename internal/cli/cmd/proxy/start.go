@@ -0,0 +1,242 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/banzaicloud/backyards-cli/internal/endpoint"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+const foregroundFlag = "foreground"
+
+type startOptions struct {
+	foreground bool
+	timeout    time.Duration
+	unixSocket string
+}
+
+func NewStartCommand(cli cli.CLI) *cobra.Command {
+	options := &startOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "start [flags]",
+		Short: "Start the background proxy to Backyards",
+		Example: `  # Start the background proxy.
+  backyards proxy start
+
+  # Start the proxy on a given local port.
+  backyards proxy start --local-port 50500
+
+  # Start the proxy on a unix socket only accessible by the current user.
+  backyards proxy start --unix-socket ~/.banzai/backyards/proxy.sock`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			if options.foreground {
+				return runProxy(cli, options)
+			}
+
+			return startProxy(cli, options)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.foreground, foregroundFlag, false, "Run the proxy in the foreground")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 30*time.Second, "Time to wait for the proxy to become healthy")
+	cmd.Flags().StringVar(&options.unixSocket, "unix-socket", "", "Serve the proxy on this unix socket instead of a local port")
+
+	return cmd
+}
+
+// startProxy starts the proxy as a detached process and waits for it to become healthy
+func startProxy(c cli.CLI, options *startOptions) error {
+	state, err := c.ReadProxyState()
+	if err != nil {
+		return err
+	}
+	if state != nil && state.Healthy(nil) {
+		fmt.Fprintf(c.Out(), "The proxy is already running at %s (pid %d)\n", state.URL, state.PID)
+		return nil
+	}
+	// an unhealthy proxy still serving would be left running without a state once the new one starts
+	if state != nil {
+		err = state.Stop(nil)
+		if err != nil && !errors.Is(err, cli.ErrProxyNotServing) {
+			return errors.WrapIf(err, "could not stop the unhealthy proxy")
+		}
+		if err == nil {
+			log.Infof("stopped the unhealthy proxy at %s (pid %d)", state.URL, state.PID)
+		}
+	}
+
+	_, logFile, err := c.ProxyFiles()
+	if err != nil {
+		return err
+	}
+
+	args, err := daemonArgs(c)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.WrapIf(err, "could not get the path of the executable")
+	}
+
+	out, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not open proxy log", "file", logFile)
+	}
+	defer out.Close()
+
+	daemon := exec.Command(executable, args...)
+	daemon.Stdout = out
+	daemon.Stderr = out
+	daemon.SysProcAttr = detachedProcAttr()
+	err = daemon.Start()
+	if err != nil {
+		return errors.WrapIf(err, "could not start proxy")
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- daemon.Wait()
+	}()
+
+	deadline := time.After(options.timeout)
+	for {
+		select {
+		case err := <-exited:
+			return errors.Errorf("proxy exited: %v, check the log at %s", err, logFile)
+		case <-deadline:
+			_ = daemon.Process.Kill()
+			return errors.Errorf("proxy did not become healthy in %s, check the log at %s", options.timeout, logFile)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		state, err := c.ReadProxyState()
+		if err != nil {
+			return err
+		}
+		if state != nil && state.PID == daemon.Process.Pid && state.Healthy(nil) {
+			fmt.Fprintf(c.Out(), "The proxy is running at %s (pid %d), to stop it use:\n> backyards proxy stop\n", state.URL, state.PID)
+			return daemon.Process.Release()
+		}
+	}
+}
+
+// daemonArgs returns the arguments of the current command for running the proxy in the foreground non-interactively,
+// the kubeconfig context and the persistent config are fixed, so that those are not asked for
+func daemonArgs(c cli.CLI) ([]string, error) {
+	args := append(os.Args[1:], "--"+foregroundFlag, "--non-interactive",
+		"--persistent-config-file", c.GetPersistentConfig().GetConfigFileUsed())
+
+	if viper.GetString("kubecontext") == "" {
+		context, err := cli.CurrentKubeContext()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--context", context)
+	}
+
+	return args, nil
+}
+
+// runProxy serves the proxy until it is interrupted, its state is saved for the later commands
+func runProxy(c cli.CLI, options *startOptions) error {
+	ep, url, err := newProxyEndpoint(c, options)
+	if err != nil {
+		return err
+	}
+	defer ep.Close()
+
+	state := &cli.ProxyState{
+		PID:        os.Getpid(),
+		URL:        url,
+		Namespace:  c.GetPersistentConfig().Namespace(),
+		CLIVersion: c.GetRootCommand().Version,
+		Started:    time.Now(),
+
+		CustomCredentials: !cli.AuthOverrides().Empty(),
+	}
+	err = c.WriteProxyState(state)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("proxy is listening at %s", state.URL)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	signal.Stop(signals)
+
+	log.Info("stopping proxy")
+
+	// a newer proxy might have replaced the state in the meantime
+	current, err := c.ReadProxyState()
+	if err != nil || current == nil || current.PID != state.PID {
+		return err
+	}
+
+	return c.WriteProxyState(nil)
+}
+
+// newProxyEndpoint starts serving the proxy on a unix socket or an ephemeral local port,
+// the returned URL is saved in the state of the proxy
+func newProxyEndpoint(c cli.CLI, options *startOptions) (endpoint.Endpoint, string, error) {
+	if options.unixSocket == "" {
+		ep, err := c.NewEndpoint(0)
+		if err != nil {
+			return nil, "", err
+		}
+		return ep, ep.URLForPath(""), nil
+	}
+
+	socket, err := filepath.Abs(options.unixSocket)
+	if err != nil {
+		return nil, "", errors.WrapIf(err, "could not get the path of the unix socket")
+	}
+
+	// a socket left behind by a killed proxy prevents listening
+	if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(socket)
+		if err != nil {
+			return nil, "", errors.WrapIfWithDetails(err, "could not remove stale unix socket", "socket", socket)
+		}
+	}
+
+	ep, err := c.NewUnixSocketEndpoint(socket)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ep, endpoint.UnixSocketScheme + socket, nil
+}
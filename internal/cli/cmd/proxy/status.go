@@ -0,0 +1,92 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type StatusOut struct {
+	Status     string `json:"status"`
+	URL        string `json:"url,omitempty"`
+	PID        int    `json:"pid,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	CLIVersion string `json:"cliVersion,omitempty"`
+	Started    string `json:"started,omitempty"`
+	Log        string `json:"log"`
+}
+
+func NewStatusCommand(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the status of the background proxy to Backyards",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			state, err := cli.ReadProxyState()
+			if err != nil {
+				return err
+			}
+
+			_, logFile, err := cli.ProxyFiles()
+			if err != nil {
+				return err
+			}
+
+			out := StatusOut{
+				Status: "stopped",
+				Log:    logFile,
+			}
+			if state != nil {
+				out.Status = "unhealthy"
+				if state.Healthy(nil) {
+					out.Status = "running"
+				}
+				out.URL = state.URL
+				out.PID = state.PID
+				out.Namespace = state.Namespace
+				out.CLIVersion = state.CLIVersion
+				out.Started = state.Started.Format(time.RFC3339)
+			}
+
+			return showStatus(cli, out)
+		},
+	}
+
+	return cmd
+}
+
+func showStatus(cli cli.CLI, status StatusOut) error {
+	err := output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Status", "URL", "PID", "Namespace", "CLIVersion", "Started"},
+		Headers: []string{"Status", "URL", "PID", "Namespace", "CLI version", "Started"},
+	}, []StatusOut{status})
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+func NewStopCommand(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the background proxy to Backyards",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return stopProxy(cli)
+		},
+	}
+
+	return cmd
+}
+
+// stopProxy stops the proxy daemon and removes its state, a proxy which does not serve anymore is already gone
+func stopProxy(c cli.CLI) error {
+	state, err := c.ReadProxyState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		fmt.Fprintln(c.Out(), "The proxy is not running")
+		return nil
+	}
+
+	err = state.Stop(nil)
+	if err != nil && !errors.Is(err, cli.ErrProxyNotServing) {
+		return err
+	}
+	stopped := err == nil
+
+	err = c.WriteProxyState(nil)
+	if err != nil {
+		return err
+	}
+
+	if stopped {
+		fmt.Fprintf(c.Out(), "The proxy at %s (pid %d) is stopped\n", state.URL, state.PID)
+	} else {
+		fmt.Fprintf(c.Out(), "The proxy at %s (pid %d) is not running anymore\n", state.URL, state.PID)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+	"github.com/banzaicloud/backyards-cli/pkg/prometheus"
+)
+
+const (
+	defaultQueryTimeout = 30 * time.Second
+	// number of points of a range query if the step is not given
+	defaultRangePoints = 30
+)
+
+type queryCommand struct{}
+
+type QueryOptions struct {
+	query      string
+	time       string
+	queryRange time.Duration
+	step       time.Duration
+}
+
+type SampleOut struct {
+	Metric string            `json:"-"`
+	Labels map[string]string `json:"metric"`
+	Time   string            `json:"time"`
+	Value  model.SampleValue `json:"value"`
+}
+
+func NewQueryOptions() *QueryOptions {
+	return &QueryOptions{}
+}
+
+func NewQueryCommand(cli cli.CLI, options *QueryOptions) *cobra.Command {
+	c := queryCommand{}
+
+	cmd := &cobra.Command{
+		Use:   "query PROMQL [--range DURATION [--step DURATION]]",
+		Short: "Query the metrics of the mesh from the Prometheus of Backyards",
+		Example: `  # request rate of the services in the backyards-demo namespace
+  backyards query 'sum(rate(istio_requests_total{destination_service_namespace="backyards-demo"}[1m])) by (destination_service_name)'
+
+  # the same over the last 30 minutes
+  backyards query --range 30m 'sum(rate(istio_requests_total[1m]))'`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.OperationCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			options.query = args[0]
+
+			return c.run(cli, options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.time, "time", "", "Evaluation time of the query in RFC3339 format (defaults to now), the end of the range for range queries")
+	cmd.Flags().DurationVar(&options.queryRange, "range", 0, "Run a range query over this duration instead of an instant query")
+	cmd.Flags().DurationVar(&options.step, "step", 0, "Resolution of the range query (defaults to the range divided into 30 points)")
+
+	return cmd
+}
+
+func (c *queryCommand) run(cli cli.CLI, options *QueryOptions) error {
+	ts := time.Now()
+	if options.time != "" {
+		var err error
+		ts, err = time.Parse(time.RFC3339, options.time)
+		if err != nil {
+			return errors.WrapIf(err, "invalid time")
+		}
+	}
+
+	endpoint, err := cli.InitializedEndpoint()
+	if err != nil {
+		return err
+	}
+	defer endpoint.Close()
+
+	client, err := prometheus.NewClient(endpoint.URLForPath(BackendPaths["prometheus"]), endpoint.HTTPClient())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+
+	var samples []prometheus.Sample
+	if options.queryRange > 0 {
+		step := options.step
+		if step <= 0 {
+			step = options.queryRange / defaultRangePoints
+			if step < time.Second {
+				step = time.Second
+			}
+		}
+		samples, err = client.QueryRange(ctx, options.query, ts.Add(-options.queryRange), ts, step)
+	} else {
+		samples, err = client.Query(ctx, options.query, ts)
+	}
+	if err != nil {
+		return err
+	}
+
+	return showSamples(cli, samples)
+}
+
+func showSamples(cli cli.CLI, samples []prometheus.Sample) error {
+	outs := make([]SampleOut, 0, len(samples))
+	for _, s := range samples {
+		labels := make(map[string]string, len(s.Metric))
+		for name, value := range s.Metric {
+			labels[string(name)] = string(value)
+		}
+		outs = append(outs, SampleOut{
+			Metric: s.Metric.String(),
+			Labels: labels,
+			Time:   s.Time.Format(time.RFC3339),
+			Value:  s.Value,
+		})
+	}
+
+	err := output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Metric", "Time", "Value"},
+		Headers: []string{"Metric", "Time", "Value"},
+	}, outs)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
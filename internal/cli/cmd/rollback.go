@@ -0,0 +1,178 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
+	"github.com/banzaicloud/backyards-cli/pkg/release"
+)
+
+type rollbackCommand struct {
+	cli cli.CLI
+}
+
+type rollbackOptions struct {
+	releaseName  string
+	revision     int
+	plan         bool
+	applyOptions k8s.ApplyOptions
+}
+
+func NewRollbackCommand(cli cli.CLI) *cobra.Command {
+	c := &rollbackCommand{
+		cli: cli,
+	}
+	options := &rollbackOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rollback --to N [flags]",
+		Short: "Roll back Backyards to a recorded revision",
+		Long: `Applies the resources recorded in a revision of the release history again.
+
+The resources of the latest revision which are not part of the rolled back revision are removed.
+Rolling back to an uninstall revision restores the Backyards resources removed by the uninstall command,
+if it was run with the '--keep-history' option. Istio, cert-manager, canary and the demo application
+are not part of the release history, those are not restored.
+The rollback itself is recorded as a new revision.
+
+The command can show the changes to the cluster without applying them with the '--plan' option.`,
+		Example: `  # List the revisions.
+  backyards history
+
+  # Roll back to revision 2.
+  backyards rollback --to 2`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := common.ValidateApplyFlags(options.applyOptions)
+			if err != nil {
+				return err
+			}
+
+			return c.run(options)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.releaseName, "release-name", defaultReleaseName, "Name of the release")
+	cmd.Flags().IntVar(&options.revision, "to", 0, "Revision to roll back to")
+	cmd.Flags().BoolVar(&options.plan, "plan", options.plan, "Show the changes to the cluster without applying them")
+	common.AddApplyFlags(cmd.Flags(), &options.applyOptions)
+
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+func (c *rollbackCommand) run(options *rollbackOptions) error {
+	store, err := newReleaseStore(c.cli, options.releaseName)
+	if err != nil {
+		return err
+	}
+
+	target, err := store.Get(options.revision)
+	if errors.Is(err, release.ErrRevisionNotFound) {
+		return errors.Errorf("revision %d of release %s not found, use the 'backyards history' command to list the revisions", options.revision, options.releaseName)
+	}
+	if err != nil {
+		return err
+	}
+
+	objects, err := target.Objects()
+	if err != nil {
+		return err
+	}
+
+	releases, err := store.List()
+	if err != nil {
+		return err
+	}
+	stale := make(object.K8sObjects, 0)
+	if latest := releases[len(releases)-1]; latest.Action != release.UninstallAction {
+		latestObjects, err := latest.Objects()
+		if err != nil {
+			return err
+		}
+		stale = missingObjects(latestObjects, objects, c.cli.GetPersistentConfig().Namespace())
+	}
+
+	if options.plan {
+		return common.PrintPlan(c.cli, fmt.Sprintf("rolling back to revision %d", target.Revision), objects, stale)
+	}
+
+	message := fmt.Sprintf("Roll back release %s to revision %d (%s with CLI version %s). Are you sure to proceed?",
+		options.releaseName, target.Revision, target.Action, target.CLIVersion)
+
+	return c.cli.IfConfirmed(message, func() error {
+		return c.rollback(options, target, objects, stale)
+	})
+}
+
+func (c *rollbackCommand) rollback(options *rollbackOptions, target *release.Release, objects, stale object.K8sObjects) error {
+	client, err := c.cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	if len(stale) > 0 {
+		m := resourcemanager.New(client, c.cli.LabelManager())
+		m.SetObjects(stale)
+		err = m.Uninstall().Do()
+		if err != nil {
+			return errors.WrapIf(err, "could not remove resources not part of the revision")
+		}
+	}
+
+	m := resourcemanager.New(client, c.cli.LabelManager())
+	m.SetApplyOptions(options.applyOptions)
+	m.SetObjects(objects)
+	err = m.Install().Do()
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not apply revision", "revision", target.Revision)
+	}
+
+	return saveRelease(c.cli, options.releaseName, &release.Release{
+		Action: release.RollbackAction,
+		Source: target.Revision,
+		Values: target.Values,
+	}, objects)
+}
+
+// missingObjects returns the objects which are not part of the desired objects,
+// the namespace holding the release history is never returned
+func missingObjects(objects, desired object.K8sObjects, namespace string) object.K8sObjects {
+	desiredObjects := desired.ToMap()
+
+	result := make(object.K8sObjects, 0)
+	for _, o := range withoutNamespace(objects, namespace) {
+		if _, ok := desiredObjects[o.Hash()]; !ok {
+			result = append(result, o)
+		}
+	}
+
+	return result
+}
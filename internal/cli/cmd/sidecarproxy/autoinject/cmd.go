@@ -30,6 +30,8 @@ func NewRootCmd(cli cli.CLI) *cobra.Command {
 	cmd.AddCommand(
 		newOnCommand(cli),
 		newOffCommand(cli),
+		newStatusCommand(cli),
+		newWorkloadCommand(cli),
 	)
 
 	return cmd
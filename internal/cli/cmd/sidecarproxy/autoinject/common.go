@@ -0,0 +1,244 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoinject
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+const (
+	namespaceInjectionLabel  = "istio-injection"
+	workloadInjectAnnotation = "sidecar.istio.io/inject"
+	restartedAtAnnotation    = "kubectl.kubernetes.io/restartedAt"
+	sidecarContainerName     = "istio-proxy"
+)
+
+// workload is a pod controller whose pod template can be modified to control sidecar injection
+type workload interface {
+	metav1.Object
+	object() runtime.Object
+	template() map[string]string
+	setTemplate(map[string]string)
+	selector() *metav1.LabelSelector
+}
+
+type deployment struct {
+	*appsv1.Deployment
+}
+
+func (d deployment) object() runtime.Object {
+	return d.Deployment
+}
+
+func (d deployment) template() map[string]string {
+	return d.Spec.Template.Annotations
+}
+
+func (d deployment) setTemplate(annotations map[string]string) {
+	d.Spec.Template.Annotations = annotations
+}
+
+func (d deployment) selector() *metav1.LabelSelector {
+	return d.Spec.Selector
+}
+
+type statefulSet struct {
+	*appsv1.StatefulSet
+}
+
+func (s statefulSet) object() runtime.Object {
+	return s.StatefulSet
+}
+
+func (s statefulSet) template() map[string]string {
+	return s.Spec.Template.Annotations
+}
+
+func (s statefulSet) setTemplate(annotations map[string]string) {
+	s.Spec.Template.Annotations = annotations
+}
+
+func (s statefulSet) selector() *metav1.LabelSelector {
+	return s.Spec.Selector
+}
+
+// getWorkload looks up a Deployment or a StatefulSet with the given name
+func getWorkload(cl k8sclient.Client, name types.NamespacedName) (workload, error) {
+	var d appsv1.Deployment
+	err := cl.Get(context.Background(), name, &d)
+	if err == nil {
+		d.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+		return deployment{&d}, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, errors.WrapIfWithDetails(err, "could not get deployment", "name", name.String())
+	}
+
+	var s appsv1.StatefulSet
+	err = cl.Get(context.Background(), name, &s)
+	if err == nil {
+		s.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("StatefulSet"))
+		return statefulSet{&s}, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, errors.WrapIfWithDetails(err, "could not get statefulset", "name", name.String())
+	}
+
+	return nil, errors.NewWithDetails("no deployment or statefulset found", "name", name.String())
+}
+
+// listWorkloads returns every Deployment and StatefulSet within the given namespace
+func listWorkloads(cl k8sclient.Client, namespace string) ([]workload, error) {
+	workloads := make([]workload, 0)
+
+	var deployments appsv1.DeploymentList
+	err := cl.List(context.Background(), &deployments, client.InNamespace(namespace))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list deployments", "namespace", namespace)
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		d.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+		workloads = append(workloads, deployment{d})
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	err = cl.List(context.Background(), &statefulSets, client.InNamespace(namespace))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list statefulsets", "namespace", namespace)
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		s.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("StatefulSet"))
+		workloads = append(workloads, statefulSet{s})
+	}
+
+	return workloads, nil
+}
+
+// restartWorkloads triggers a rolling restart of the given workloads the same way `kubectl rollout restart` does
+func restartWorkloads(cl k8sclient.Client, workloads ...workload) error {
+	restartedAt := time.Now().Format(time.RFC3339)
+	for _, w := range workloads {
+		annotations := w.template()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[restartedAtAnnotation] = restartedAt
+		w.setTemplate(annotations)
+
+		err := cl.Update(context.Background(), w.object())
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not restart workload", "name", workloadName(w))
+		}
+		log.Infof("%s restarted", workloadName(w))
+	}
+
+	return nil
+}
+
+func workloadName(w workload) string {
+	return fmt.Sprintf("%s:%s/%s", strings.ToLower(w.object().GetObjectKind().GroupVersionKind().Kind), w.GetNamespace(), w.GetName())
+}
+
+// restartNamespaceWorkloads rolls the Deployments and StatefulSets in the namespace whose pods are affected
+// by the changed namespace injection setting, so that it is applied to them
+func restartNamespaceWorkloads(cli cli.CLI, namespace string, injection bool) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	workloads, err := workloadsToRestart(cl, namespace, injection)
+	if err != nil {
+		return err
+	}
+	if len(workloads) == 0 {
+		log.Infof("no workloads in namespace %s need a restart", namespace)
+		return nil
+	}
+
+	return restartWorkloads(cl, workloads...)
+}
+
+// workloadsToRestart returns the workloads having pods whose sidecar does not match the namespace injection setting,
+// the workloads overriding the setting with the inject annotation are left out
+func workloadsToRestart(cl k8sclient.Client, namespace string, injection bool) ([]workload, error) {
+	workloads, err := listWorkloads(cl, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods corev1.PodList
+	err = cl.List(context.Background(), &pods, client.InNamespace(namespace))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list pods", "namespace", namespace)
+	}
+
+	result := make([]workload, 0)
+	for _, w := range workloads {
+		if _, ok := w.template()[workloadInjectAnnotation]; ok {
+			log.Debugf("%s overrides sidecar injection, skipping restart", workloadName(w))
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(w.selector())
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not parse selector", "name", workloadName(w))
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.Spec.HostNetwork {
+				continue
+			}
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			if hasSidecar(pod) != injection {
+				result = append(result, w)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func hasSidecar(pod corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == sidecarContainerName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoinject
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testDeployment(name string, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "demo"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}},
+		},
+	}
+}
+
+func testPod(app string, sidecar bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: app + "-pod", Namespace: "demo", Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: app}}},
+	}
+	if sidecar {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: sidecarContainerName})
+	}
+
+	return pod
+}
+
+func TestWorkloadsToRestart(t *testing.T) {
+	objects := []runtime.Object{
+		testDeployment("plain", nil),
+		testPod("plain", false),
+		testDeployment("injected", nil),
+		testPod("injected", true),
+		testDeployment("opted-out", map[string]string{workloadInjectAnnotation: "false"}),
+		testPod("opted-out", false),
+		testDeployment("opted-in", map[string]string{workloadInjectAnnotation: "true"}),
+		testPod("opted-in", true),
+		testDeployment("scaled-down", nil),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "demo"},
+			Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+		},
+		testPod("db", true),
+	}
+
+	tests := []struct {
+		name      string
+		injection bool
+		expected  []string
+	}{
+		{
+			name:      "enabling injection",
+			injection: true,
+			expected:  []string{"deployment:demo/plain"},
+		},
+		{
+			name:      "disabling injection",
+			injection: false,
+			expected:  []string{"deployment:demo/injected", "statefulset:demo/db"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewFakeClientWithScheme(scheme.Scheme, objects...)
+
+			workloads, err := workloadsToRestart(cl, "demo", tt.injection)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := make([]string, 0, len(workloads))
+			for _, w := range workloads {
+				names = append(names, workloadName(w))
+			}
+			sort.Strings(names)
+
+			if len(names) != len(tt.expected) {
+				t.Fatalf("expected %v to be restarted, got %v", tt.expected, names)
+			}
+			for i := range names {
+				if names[i] != tt.expected[i] {
+					t.Errorf("expected %v to be restarted, got %v", tt.expected, names)
+				}
+			}
+		})
+	}
+}
+
+func TestRestartWorkloads(t *testing.T) {
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, testDeployment("plain", map[string]string{"keep": "me"}))
+
+	w, err := getWorkload(cl, types.NamespacedName{Namespace: "demo", Name: "plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = restartWorkloads(cl, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var d appsv1.Deployment
+	err = cl.Get(context.Background(), types.NamespacedName{Namespace: "demo", Name: "plain"}, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Annotations[restartedAtAnnotation] == "" || d.Spec.Template.Annotations["keep"] != "me" {
+		t.Errorf("the restart annotation should be added to the pod template: %v", d.Spec.Template.Annotations)
+	}
+}
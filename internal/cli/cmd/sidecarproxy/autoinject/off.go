@@ -29,6 +29,7 @@ type offCommand struct{}
 
 type offOptions struct {
 	namespaceName string
+	restart       bool
 }
 
 func newOffOptions() *offOptions {
@@ -67,6 +68,7 @@ func newOffCommand(cli cli.CLI) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.StringVar(&options.namespaceName, "namespace", "", "Namespace name")
+	flags.BoolVar(&options.restart, "restart", false, "Restart the deployments and statefulsets of the namespace affected by the change so that it takes effect")
 
 	return cmd
 }
@@ -91,5 +93,9 @@ func (c *offCommand) run(cli cli.CLI, options *offOptions) error {
 
 	log.Infof("auto sidecar injection successfully removed from namespace %s", options.namespaceName)
 
+	if options.restart {
+		return restartNamespaceWorkloads(cli, options.namespaceName, false)
+	}
+
 	return nil
 }
@@ -29,6 +29,7 @@ type onCommand struct{}
 
 type onOptions struct {
 	namespaceName string
+	restart       bool
 }
 
 func newOnOptions() *onOptions {
@@ -67,6 +68,7 @@ func newOnCommand(cli cli.CLI) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.StringVar(&options.namespaceName, "namespace", "", "Namespace name")
+	flags.BoolVar(&options.restart, "restart", false, "Restart the deployments and statefulsets of the namespace affected by the change so that it takes effect")
 
 	return cmd
 }
@@ -91,5 +93,9 @@ func (c *onCommand) run(cli cli.CLI, options *onOptions) error {
 
 	log.Infof("auto sidecar injection successfully set to namespace %s", options.namespaceName)
 
+	if options.restart {
+		return restartNamespaceWorkloads(cli, options.namespaceName, true)
+	}
+
 	return nil
 }
@@ -0,0 +1,254 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoinject
+
+import (
+	"context"
+	"fmt"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+const (
+	podStatusMissing = "missing sidecar"
+	podStatusStale   = "stale proxy"
+)
+
+type statusCommand struct{}
+
+type statusOptions struct {
+	namespaceName string
+}
+
+type NamespaceStatus struct {
+	Name      string `json:"name"`
+	Injection string `json:"injection"`
+}
+
+type PodStatus struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	ProxyImage    string `json:"proxyImage,omitempty"`
+	ExpectedImage string `json:"expectedImage,omitempty"`
+}
+
+type StatusOut struct {
+	Namespaces []NamespaceStatus `json:"namespaces"`
+	Pods       []PodStatus       `json:"pods"`
+}
+
+func newStatusOptions() *statusOptions {
+	return &statusOptions{}
+}
+
+func newStatusCommand(cli cli.CLI) *cobra.Command {
+	c := &statusCommand{}
+	options := newStatusOptions()
+
+	cmd := &cobra.Command{
+		Use:   "status [[--namespace=]name]",
+		Short: "Show sidecar injection status of namespaces and pods",
+		Long: `Show sidecar injection status of namespaces and pods.
+
+Lists the namespaces with their injection label, and the pods that should have a sidecar
+but do not, or that run a proxy image different from the one set in the Istio CR.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				options.namespaceName = args[0]
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.namespaceName, "namespace", "", "Namespace name (all namespaces if empty)")
+
+	return cmd
+}
+
+func (c *statusCommand) run(cli cli.CLI, options *statusOptions) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	var expectedImage string
+	istioCR, err := istio.FetchIstioCR(cl)
+	if err != nil {
+		log.Warnf("proxy versions are not checked: %s", err)
+	} else {
+		expectedImage = istioCR.Spec.Proxy.Image
+	}
+
+	namespaces, err := getNamespaces(cl, options.namespaceName)
+	if err != nil {
+		return err
+	}
+
+	out := StatusOut{
+		Namespaces: make([]NamespaceStatus, 0),
+		Pods:       make([]PodStatus, 0),
+	}
+	for _, ns := range namespaces {
+		injection := ns.Labels[namespaceInjectionLabel]
+		out.Namespaces = append(out.Namespaces, NamespaceStatus{
+			Name:      ns.Name,
+			Injection: injection,
+		})
+
+		pods, err := getPodStatuses(cl, ns.Name, injection == "enabled", expectedImage)
+		if err != nil {
+			return err
+		}
+		out.Pods = append(out.Pods, pods...)
+	}
+
+	return c.output(cli, out)
+}
+
+func (c *statusCommand) output(cli cli.CLI, out StatusOut) error {
+	if cli.OutputFormat() != output.OutputFormatTable {
+		return output.Output(&output.Context{
+			Out:    cli.Out(),
+			Color:  cli.Color(),
+			Format: cli.OutputFormat(),
+		}, out)
+	}
+
+	if cli.Interactive() {
+		fmt.Fprintf(cli.Out(), "Sidecar injection settings of namespaces\n\n")
+	}
+
+	err := output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Name", "Injection"},
+		Headers: []string{"Namespace", "Injection"},
+	}, out.Namespaces)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	if len(out.Pods) == 0 {
+		fmt.Fprintf(cli.Out(), "\nall pods are running with up-to-date sidecars where expected\n\n")
+		return nil
+	}
+
+	if cli.Interactive() {
+		fmt.Fprintf(cli.Out(), "\nPods missing sidecars or running stale proxies\n\n")
+	}
+
+	err = output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Namespace", "Name", "Status", "ProxyImage", "ExpectedImage"},
+		Headers: []string{"Namespace", "Pod", "Status", "Proxy image", "Expected image"},
+	}, out.Pods)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
+
+func getNamespaces(cl k8sclient.Client, name string) ([]corev1.Namespace, error) {
+	if name != "" {
+		var ns corev1.Namespace
+		err := cl.Get(context.Background(), types.NamespacedName{Name: name}, &ns)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not get namespace", "name", name)
+		}
+		return []corev1.Namespace{ns}, nil
+	}
+
+	var namespaces corev1.NamespaceList
+	err := cl.List(context.Background(), &namespaces)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not list namespaces")
+	}
+
+	return namespaces.Items, nil
+}
+
+func getPodStatuses(cl k8sclient.Client, namespace string, namespaceInjection bool, expectedImage string) ([]PodStatus, error) {
+	var pods corev1.PodList
+	err := cl.List(context.Background(), &pods, client.InNamespace(namespace))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list pods", "namespace", namespace)
+	}
+
+	statuses := make([]PodStatus, 0)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.Spec.HostNetwork {
+			continue
+		}
+
+		var proxyImage string
+		for _, container := range pod.Spec.Containers {
+			if container.Name == sidecarContainerName {
+				proxyImage = container.Image
+				break
+			}
+		}
+
+		status := PodStatus{
+			Namespace:     pod.Namespace,
+			Name:          pod.Name,
+			ProxyImage:    proxyImage,
+			ExpectedImage: expectedImage,
+		}
+
+		switch {
+		case proxyImage == "" && injectionExpected(pod, namespaceInjection):
+			status.Status = podStatusMissing
+		case proxyImage != "" && expectedImage != "" && proxyImage != expectedImage:
+			status.Status = podStatusStale
+		default:
+			continue
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// injectionExpected tells whether the sidecar injector should have added a proxy to the pod
+func injectionExpected(pod corev1.Pod, namespaceInjection bool) bool {
+	switch pod.Annotations[workloadInjectAnnotation] {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return namespaceInjection
+	}
+}
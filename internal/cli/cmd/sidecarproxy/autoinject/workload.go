@@ -0,0 +1,155 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoinject
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+type overrideMode string
+
+const (
+	overrideOn    overrideMode = "on"
+	overrideOff   overrideMode = "off"
+	overrideUnset overrideMode = "unset"
+)
+
+type workloadCommand struct{}
+
+type workloadOptions struct {
+	workloadID   string
+	workloadName types.NamespacedName
+	mode         overrideMode
+}
+
+func newWorkloadOptions(mode overrideMode) *workloadOptions {
+	return &workloadOptions{
+		mode: mode,
+	}
+}
+
+func newWorkloadCommand(cli cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "workload",
+		Aliases: []string{"wl"},
+		Short:   "Override sidecar injection for a single workload",
+		Long: `Override sidecar injection for a single workload.
+
+The override is set through the sidecar.istio.io/inject annotation on the pod template of the
+deployment or statefulset, so the workload is rolled automatically when the setting changes.`,
+	}
+
+	cmd.AddCommand(
+		newWorkloadOverrideCommand(cli, overrideOn, "Enable sidecar injection for the given workload regardless of the namespace setting"),
+		newWorkloadOverrideCommand(cli, overrideOff, "Disable sidecar injection for the given workload regardless of the namespace setting"),
+		newWorkloadOverrideCommand(cli, overrideUnset, "Remove the sidecar injection override of the given workload"),
+	)
+
+	return cmd
+}
+
+func newWorkloadOverrideCommand(cli cli.CLI, mode overrideMode, short string) *cobra.Command {
+	c := &workloadCommand{}
+	options := newWorkloadOptions(mode)
+
+	cmd := &cobra.Command{
+		Use:           string(mode) + " [[--workload=]namespace/name]",
+		Short:         short,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.workloadID = args[0]
+			}
+
+			if options.workloadID == "" {
+				return errors.New("workload must be specified")
+			}
+
+			options.workloadName, err = util.ParseK8sResourceID(options.workloadID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse workload ID")
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.workloadID, "workload", "", "Workload name in namespace/name format")
+
+	return cmd
+}
+
+func (c *workloadCommand) run(cli cli.CLI, options *workloadOptions) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	w, err := getWorkload(cl, options.workloadName)
+	if err != nil {
+		return err
+	}
+
+	annotations := w.template()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	current, exists := annotations[workloadInjectAnnotation]
+	var changed bool
+	switch options.mode {
+	case overrideOn:
+		annotations[workloadInjectAnnotation] = "true"
+		changed = current != "true"
+	case overrideOff:
+		annotations[workloadInjectAnnotation] = "false"
+		changed = current != "false"
+	case overrideUnset:
+		delete(annotations, workloadInjectAnnotation)
+		changed = exists
+	}
+
+	if !changed {
+		log.Infof("sidecar injection override of %s is unchanged", workloadName(w))
+		return nil
+	}
+
+	w.setTemplate(annotations)
+	err = cl.Update(context.Background(), w.object())
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not update workload", "name", workloadName(w))
+	}
+
+	switch options.mode {
+	case overrideUnset:
+		log.Infof("sidecar injection override successfully removed from %s", workloadName(w))
+	default:
+		log.Infof("sidecar injection override successfully set to %s for %s", options.mode, workloadName(w))
+	}
+
+	return nil
+}
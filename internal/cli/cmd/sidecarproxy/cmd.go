@@ -17,7 +17,11 @@ package sidecarproxy
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/config"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/egress"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/ingress"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/logging"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/stats"
 
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/autoinject"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
@@ -35,6 +39,10 @@ func NewRootCmd(cli cli.CLI) *cobra.Command {
 	cmd.AddCommand(
 		autoinject.NewRootCmd(cli),
 		egress.NewRootCmd(cli),
+		ingress.NewRootCmd(cli),
+		config.NewConfigCommand(cli),
+		logging.NewLogCommand(cli),
+		stats.NewStatsCommand(cli),
 	)
 
 	return cmd
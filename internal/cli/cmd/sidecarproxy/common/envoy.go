@@ -0,0 +1,74 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/envoy"
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/portforward"
+)
+
+const sidecarContainerName = "istio-proxy"
+
+// GetEnvoyAdminClient opens a port-forward to the admin interface of the sidecar proxy running in the given pod.
+// The returned port-forward must be stopped by the caller.
+func GetEnvoyAdminClient(cli cli.CLI, podName types.NamespacedName) (*envoy.AdminClient, *portforward.Portforward, error) {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return nil, nil, errors.WrapIf(err, "could not get k8s client")
+	}
+
+	var pod corev1.Pod
+	err = cl.Get(context.Background(), podName, &pod)
+	if err != nil {
+		return nil, nil, errors.WrapIfWithDetails(err, "could not get pod", "name", podName.String())
+	}
+
+	hasSidecar := false
+	for _, container := range pod.Spec.Containers {
+		if container.Name == sidecarContainerName {
+			hasSidecar = true
+			break
+		}
+	}
+	if !hasSidecar {
+		return nil, nil, errors.NewWithDetails("pod has no sidecar proxy", "name", podName.String())
+	}
+
+	config, err := cli.GetK8sConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Debugf("Creating port forward to envoy admin of pod %s", podName)
+	pf, err := portforward.NewForPod(config, podName.Namespace, podName.Name, 0, envoy.AdminPort)
+	if err != nil {
+		return nil, nil, errors.WrapIf(err, "could not create port forward")
+	}
+
+	err = pf.Run()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return envoy.NewAdminClient(pf.GetURL("")), pf, nil
+}
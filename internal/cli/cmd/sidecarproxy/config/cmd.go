@@ -0,0 +1,144 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+const (
+	sectionListeners = "listeners"
+	sectionRoutes    = "routes"
+	sectionClusters  = "clusters"
+	sectionEndpoints = "endpoints"
+	sectionSecrets   = "secrets"
+)
+
+var sections = []string{sectionListeners, sectionRoutes, sectionClusters, sectionEndpoints, sectionSecrets}
+
+type configCommand struct{}
+
+type configOptions struct {
+	podID   string
+	podName types.NamespacedName
+	section string
+	name    string
+}
+
+func newConfigOptions() *configOptions {
+	return &configOptions{}
+}
+
+func NewConfigCommand(cli cli.CLI) *cobra.Command {
+	c := &configCommand{}
+	options := newConfigOptions()
+
+	cmd := &cobra.Command{
+		Use:   "config [--pod=]namespace/pod listeners|routes|clusters|endpoints|secrets",
+		Short: "Show the configuration of a sidecar proxy",
+		Long: `Show the configuration of a sidecar proxy.
+
+The configuration is retrieved from the Envoy admin interface of the pod through a port-forward.`,
+		Example: `  # list the listeners of a pod
+  backyards sp config backyards-demo/catalog-v1-5864c4b7d7-j5cmf listeners
+
+  # show the routes of a route config as json
+  backyards sp config backyards-demo/catalog-v1-5864c4b7d7-j5cmf routes --name 8080 -o json`,
+		Args:          cobra.RangeArgs(1, 2),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			switch len(args) {
+			case 1:
+				options.section = args[0]
+			case 2:
+				options.podID = args[0]
+				options.section = args[1]
+			}
+
+			if options.podID == "" {
+				return errors.New("pod must be specified")
+			}
+
+			options.podName, err = util.ParseK8sResourceID(options.podID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse pod ID")
+			}
+
+			if !isValidSection(options.section) {
+				return errors.Errorf("invalid config section '%s', use one of %v", options.section, sections)
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.podID, "pod", "", "Pod name in namespace/name format")
+	flags.StringVar(&options.name, "name", "", "Only show items with this name (listener, route config, cluster or secret name)")
+
+	return cmd
+}
+
+func isValidSection(section string) bool {
+	for _, s := range sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *configCommand) run(cli cli.CLI, options *configOptions) error {
+	client, pf, err := common.GetEnvoyAdminClient(cli, options.podName)
+	if err != nil {
+		return errors.WrapIf(err, "could not connect to the sidecar proxy")
+	}
+	defer pf.Stop()
+
+	if options.section == sectionEndpoints {
+		clusters, err := client.ClusterStatuses()
+		if err != nil {
+			return err
+		}
+
+		return outputEndpoints(cli, options, clusters)
+	}
+
+	dump, err := client.ConfigDump()
+	if err != nil {
+		return err
+	}
+
+	switch options.section {
+	case sectionListeners:
+		return outputListeners(cli, options, dump.Listeners)
+	case sectionRoutes:
+		return outputRoutes(cli, options, dump.Routes)
+	case sectionClusters:
+		return outputClusters(cli, options, dump.Clusters)
+	case sectionSecrets:
+		return outputSecrets(cli, options, dump.Secrets)
+	}
+
+	return nil
+}
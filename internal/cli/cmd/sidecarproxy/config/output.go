@@ -0,0 +1,249 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/envoy"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+// lines is rendered as one item per line in tables
+type lines []string
+
+func (l lines) String() string {
+	return strings.Join(l, "\n")
+}
+
+type ListenerOut struct {
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	Port         int    `json:"port"`
+	Type         string `json:"type"`
+	Destinations lines  `json:"destinations,omitempty"`
+	Status       string `json:"status"`
+}
+
+type RouteOut struct {
+	RouteConfig string `json:"routeConfig"`
+	VirtualHost string `json:"virtualHost"`
+	Domains     lines  `json:"domains,omitempty"`
+	Match       string `json:"match"`
+	Destination string `json:"destination"`
+}
+
+type ClusterOut struct {
+	Name      string `json:"name"`
+	FQDN      string `json:"fqdn"`
+	Port      string `json:"port,omitempty"`
+	Subset    string `json:"subset,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Status    string `json:"status"`
+}
+
+type EndpointOut struct {
+	Endpoint string `json:"endpoint"`
+	Health   string `json:"health"`
+	Cluster  string `json:"cluster"`
+}
+
+type SecretOut struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Status       string `json:"status"`
+	Version      string `json:"version,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	NotAfter     string `json:"notAfter,omitempty"`
+}
+
+func outputListeners(cli cli.CLI, options *configOptions, listeners []envoy.Listener) error {
+	outs := make([]ListenerOut, 0)
+	for _, l := range listeners {
+		if options.name != "" && l.Name != options.name {
+			continue
+		}
+		outs = append(outs, ListenerOut{
+			Name:         l.Name,
+			Address:      l.Address.String(),
+			Port:         l.Address.Port(),
+			Type:         l.Type(),
+			Destinations: l.Destinations(),
+			Status:       l.Status,
+		})
+	}
+
+	return show(cli, options, outs,
+		[]string{"Name", "Address", "Port", "Type", "Destinations", "Status"},
+		[]string{"Name", "Address", "Port", "Type", "Destinations", "Status"})
+}
+
+func outputRoutes(cli cli.CLI, options *configOptions, routeConfigs []envoy.RouteConfig) error {
+	outs := make([]RouteOut, 0)
+	for _, rc := range routeConfigs {
+		if options.name != "" && rc.Name != options.name {
+			continue
+		}
+		for _, vh := range rc.VirtualHosts {
+			for _, r := range vh.Routes {
+				outs = append(outs, RouteOut{
+					RouteConfig: rc.Name,
+					VirtualHost: vh.Name,
+					Domains:     vh.Domains,
+					Match:       r.Match.String(),
+					Destination: r.Destination(),
+				})
+			}
+		}
+	}
+
+	return show(cli, options, outs,
+		[]string{"RouteConfig", "VirtualHost", "Domains", "Match", "Destination"},
+		[]string{"Route config", "Virtual host", "Domains", "Match", "Destination"})
+}
+
+func outputClusters(cli cli.CLI, options *configOptions, clusters []envoy.Cluster) error {
+	outs := make([]ClusterOut, 0)
+	for _, c := range clusters {
+		if options.name != "" && c.Name != options.name {
+			continue
+		}
+		direction, port, subset, fqdn := c.ParsedName()
+		outs = append(outs, ClusterOut{
+			Name:      c.Name,
+			FQDN:      fqdn,
+			Port:      port,
+			Subset:    subset,
+			Direction: direction,
+			Type:      c.Type,
+			Status:    c.Status,
+		})
+	}
+
+	return show(cli, options, outs,
+		[]string{"FQDN", "Port", "Subset", "Direction", "Type", "Status"},
+		[]string{"Service FQDN", "Port", "Subset", "Direction", "Type", "Status"})
+}
+
+func outputEndpoints(cli cli.CLI, options *configOptions, clusters []envoy.ClusterStatus) error {
+	outs := make([]EndpointOut, 0)
+	for _, c := range clusters {
+		if options.name != "" && c.Name != options.name {
+			continue
+		}
+		for _, h := range c.HostStatuses {
+			endpoint := h.Address.String()
+			if h.Address.Port() != 0 {
+				endpoint = fmt.Sprintf("%s:%d", endpoint, h.Address.Port())
+			}
+			outs = append(outs, EndpointOut{
+				Endpoint: endpoint,
+				Health:   h.Health(),
+				Cluster:  c.Name,
+			})
+		}
+	}
+
+	return show(cli, options, outs,
+		[]string{"Endpoint", "Health", "Cluster"},
+		[]string{"Endpoint", "Health", "Cluster"})
+}
+
+func outputSecrets(cli cli.CLI, options *configOptions, secrets []envoy.Secret) error {
+	outs := make([]SecretOut, 0)
+	for _, s := range secrets {
+		if options.name != "" && s.Name != options.name {
+			continue
+		}
+		o := SecretOut{
+			Name:    s.Name,
+			Status:  s.Status,
+			Version: s.VersionInfo,
+		}
+		var source *envoy.DataSource
+		switch {
+		case s.TLSCertificate != nil:
+			o.Type = "Cert Chain"
+			source = &s.TLSCertificate.CertificateChain
+		case s.ValidationContext != nil:
+			o.Type = "CA"
+			source = &s.ValidationContext.TrustedCA
+		}
+		if source != nil {
+			if cert := parseCertificate(*source); cert != nil {
+				o.SerialNumber = cert.SerialNumber.Text(16)
+				o.NotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+			}
+		}
+		outs = append(outs, o)
+	}
+
+	return show(cli, options, outs,
+		[]string{"Name", "Type", "Status", "SerialNumber", "NotAfter"},
+		[]string{"Name", "Type", "Status", "Serial number", "Not after"})
+}
+
+// parseCertificate returns the first certificate of an inline PEM data source
+func parseCertificate(source envoy.DataSource) *x509.Certificate {
+	data := source.InlineBytes
+	if len(data) == 0 {
+		data = []byte(source.InlineString)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+
+	return cert
+}
+
+func show(cli cli.CLI, options *configOptions, data interface{}, fields, headers []string) error {
+	if cli.OutputFormat() == output.OutputFormatTable && cli.Interactive() {
+		fmt.Fprintf(cli.Out(), "%s of %s\n\n", strings.Title(options.section), options.podName)
+	}
+
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  fields,
+		Headers: headers,
+	}
+
+	err := output.Output(ctx, data)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	if cli.Interactive() {
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -32,6 +32,8 @@ func NewRootCmd(cli cli.CLI) *cobra.Command {
 		newSetCommand(cli),
 		newDeleteCommand(cli),
 		NewRecommendCommand(cli),
+		newHistoryCommand(cli),
+		newRollbackCommand(cli),
 	)
 
 	return cmd
@@ -108,6 +108,11 @@ func (d *deleteCommand) run(cli cli.CLI, options *deleteOptions) error {
 	}
 	defer client.Close()
 
+	previous, err := getSidecars(client, options.namespaceID, options.workloadID)
+	if err != nil {
+		return errors.WrapIf(err, "could not retrieve sidecars through graphql")
+	}
+
 	req := graphql.DisableSidecarEgressInput{
 		Selector: graphql.SidecarEgressSelector{
 			Namespace: options.namespaceID,
@@ -156,5 +161,15 @@ func (d *deleteCommand) run(cli cli.CLI, options *deleteOptions) error {
 
 	log.Infof("sidecar egress for %s/%s deleted successfully\n\n", options.namespaceID, options.workloadID)
 
-	return Output(cli, options.namespaceID, options.workloadID, sidecars, false, false)
+	err = Output(cli, options.namespaceID, options.workloadID, sidecars, false, false)
+	if err != nil {
+		return err
+	}
+
+	err = recordHistory(cli, options.namespaceID, options.workloadID, previous, sidecars)
+	if err != nil {
+		log.Warnf("could not save egress history: %s", err)
+	}
+
+	return nil
 }
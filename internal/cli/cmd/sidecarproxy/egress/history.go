@@ -0,0 +1,336 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/diff"
+	"github.com/banzaicloud/backyards-cli/pkg/graphql"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+// maximum number of entries kept in the history file
+const historyLimit = 50
+
+// HistoryEntry records the egress listeners of a namespace or a workload before and after applying a change
+type HistoryEntry struct {
+	ID         int               `json:"id"`
+	Time       time.Time         `json:"time"`
+	Namespace  string            `json:"namespace"`
+	Workload   string            `json:"workload,omitempty"`
+	Previous   []graphql.Sidecar `json:"previous"`
+	Applied    []graphql.Sidecar `json:"applied"`
+	RolledBack *time.Time        `json:"rolledBack,omitempty"`
+}
+
+type history struct {
+	file    string
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// historyFile places the history next to the persistent config, so that it is kept per cluster
+func historyFile(cli cli.CLI) string {
+	config := cli.GetPersistentConfig().GetConfigFileUsed()
+
+	return strings.TrimSuffix(config, filepath.Ext(config)) + ".egress-history.json"
+}
+
+func loadHistory(cli cli.CLI) (*history, error) {
+	h := &history{
+		file: historyFile(cli),
+	}
+
+	content, err := ioutil.ReadFile(h.file)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read egress history", "file", h.file)
+	}
+
+	err = json.Unmarshal(content, h)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not parse egress history", "file", h.file)
+	}
+
+	return h, nil
+}
+
+func (h *history) save() error {
+	if len(h.Entries) > historyLimit {
+		h.Entries = h.Entries[len(h.Entries)-historyLimit:]
+	}
+
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return errors.WrapIf(err, "could not marshal egress history")
+	}
+
+	err = os.MkdirAll(filepath.Dir(h.file), 0700)
+	if err != nil {
+		return errors.WrapIf(err, "could not create directory for egress history")
+	}
+
+	err = ioutil.WriteFile(h.file, content, 0600)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write egress history", "file", h.file)
+	}
+
+	return nil
+}
+
+func (h *history) add(namespace, workload string, previous, applied []graphql.Sidecar) HistoryEntry {
+	id := 1
+	if len(h.Entries) > 0 {
+		id = h.Entries[len(h.Entries)-1].ID + 1
+	}
+
+	entry := HistoryEntry{
+		ID:        id,
+		Time:      time.Now(),
+		Namespace: namespace,
+		Workload:  workload,
+		Previous:  targetSidecars(previous, workload),
+		Applied:   targetSidecars(applied, workload),
+	}
+	h.Entries = append(h.Entries, entry)
+
+	return entry
+}
+
+// find returns the entry with the given ID, or the latest not yet rolled back entry of the target if id is 0
+func (h *history) find(namespace, workload string, id int) (*HistoryEntry, error) {
+	for i := len(h.Entries) - 1; i >= 0; i-- {
+		e := &h.Entries[i]
+		if id != 0 {
+			if e.ID == id {
+				return e, nil
+			}
+			continue
+		}
+		if e.Namespace == namespace && e.Workload == workload && e.RolledBack == nil {
+			return e, nil
+		}
+	}
+
+	if id != 0 {
+		return nil, errors.Errorf("no egress history entry found with ID %d", id)
+	}
+
+	return nil, errors.Errorf("no egress change to roll back for %s", target(namespace, workload))
+}
+
+// targetSidecars keeps only the sidecars that are managed on the level of the target,
+// namespace wide sidecars for a namespace and workload specific ones for a workload
+func targetSidecars(sidecars []graphql.Sidecar, workload string) []graphql.Sidecar {
+	result := make([]graphql.Sidecar, 0)
+	for _, sc := range sidecars {
+		if (sc.Spec.WorkloadSelector == nil) == (workload == "") {
+			result = append(result, sc)
+		}
+	}
+
+	return result
+}
+
+func target(namespace, workload string) string {
+	if workload == "" {
+		return "namespace " + namespace
+	}
+
+	return namespace + "/" + workload
+}
+
+// egressDiff renders the unified diff of the egress listeners of two sidecar sets
+func egressDiff(from, to []graphql.Sidecar, fromName, toName string, color bool) (string, error) {
+	a, err := egressText(from)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := egressText(to)
+	if err != nil {
+		return "", err
+	}
+
+	return diff.Unified(fromName, toName, diff.Lines(a), diff.Lines(b), 3, color), nil
+}
+
+// egressText renders the egress listeners as YAML with the hosts sorted to get a stable diff
+func egressText(sidecars []graphql.Sidecar) (string, error) {
+	listeners := make([]graphql.IstioEgressListener, 0)
+	for _, sc := range sidecars {
+		for _, e := range sc.Spec.Egress {
+			l := *e
+			l.Hosts = append([]string{}, e.Hosts...)
+			sort.Strings(l.Hosts)
+			listeners = append(listeners, l)
+		}
+	}
+	if len(listeners) == 0 {
+		return "", nil
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{
+		"egress": listeners,
+	})
+	if err != nil {
+		return "", errors.WrapIf(err, "could not marshal egress listeners")
+	}
+
+	return string(content), nil
+}
+
+// recordHistory saves an applied egress change and tells how to roll it back
+func recordHistory(cli cli.CLI, namespace, workload string, previous, applied []graphql.Sidecar) error {
+	h, err := loadHistory(cli)
+	if err != nil {
+		return err
+	}
+
+	entry := h.add(namespace, workload, previous, applied)
+	err = h.save()
+	if err != nil {
+		return err
+	}
+
+	var targetArgs string
+	if workload == "" {
+		targetArgs = "--namespace " + namespace
+	} else {
+		targetArgs = fmt.Sprintf("--namespace %s --workload %s", namespace, workload)
+	}
+	// the hint goes to stderr, so that it does not break json and yaml output
+	log.Infof("The change was saved to the egress history with ID %d, to restore the previous egress rules use: "+
+		"backyards sp egress rollback %s", entry.ID, targetArgs)
+
+	return nil
+}
+
+type HistoryOut struct {
+	ID         int    `json:"id"`
+	Time       string `json:"time"`
+	Target     string `json:"target"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+	RolledBack string `json:"rolledBack,omitempty"`
+}
+
+func newHistoryCommand(cli cli.CLI) *cobra.Command {
+	var namespaceID, workloadID string
+
+	cmd := &cobra.Command{
+		Use:           "history [[--namespace] namespace [--workload name]]",
+		Short:         "List the applied egress changes that can be rolled back",
+		Args:          cobra.MaximumNArgs(2),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				namespaceID = args[0]
+			}
+			if len(args) > 1 {
+				workloadID = args[1]
+			}
+
+			h, err := loadHistory(cli)
+			if err != nil {
+				return err
+			}
+
+			outs := make([]HistoryOut, 0)
+			for _, e := range h.Entries {
+				if namespaceID != "" && e.Namespace != namespaceID {
+					continue
+				}
+				if workloadID != "" && e.Workload != workloadID {
+					continue
+				}
+				o := HistoryOut{
+					ID:     e.ID,
+					Time:   e.Time.Format(time.RFC3339),
+					Target: target(e.Namespace, e.Workload),
+				}
+				o.Added, o.Removed = hostChanges(e.Previous, e.Applied)
+				if e.RolledBack != nil {
+					o.RolledBack = e.RolledBack.Format(time.RFC3339)
+				}
+				outs = append(outs, o)
+			}
+
+			err = output.Output(&output.Context{
+				Out:     cli.Out(),
+				Color:   cli.Color(),
+				Format:  cli.OutputFormat(),
+				Fields:  []string{"ID", "Time", "Target", "Added", "Removed", "RolledBack"},
+				Headers: []string{"ID", "Time", "Target", "Added hosts", "Removed hosts", "Rolled back"},
+			}, outs)
+			if err != nil {
+				return errors.WrapIf(err, "could not produce output")
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&namespaceID, "namespace", "", "Namespace name")
+	flags.StringVar(&workloadID, "workload", "", "Workload name")
+
+	return cmd
+}
+
+// hostChanges counts the egress hosts added and removed by a change
+func hostChanges(previous, applied []graphql.Sidecar) (int, int) {
+	hosts := func(sidecars []graphql.Sidecar) map[string]bool {
+		m := make(map[string]bool)
+		for _, sc := range sidecars {
+			for _, e := range sc.Spec.Egress {
+				for _, h := range e.Hosts {
+					m[h] = true
+				}
+			}
+		}
+		return m
+	}
+
+	before, after := hosts(previous), hosts(applied)
+	added, removed := 0, 0
+	for h := range after {
+		if !before[h] {
+			added++
+		}
+	}
+	for h := range before {
+		if !after[h] {
+			removed++
+		}
+	}
+
+	return added, removed
+}
@@ -0,0 +1,105 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+
+	"github.com/banzaicloud/backyards-cli/pkg/graphql"
+)
+
+func testSidecar(workloadLabels map[string]string, ports ...int) graphql.Sidecar {
+	sc := graphql.Sidecar{Name: "default", Namespace: "demo"}
+	if workloadLabels != nil {
+		sc.Spec.WorkloadSelector = &graphql.WorkloadSelector{Labels: workloadLabels}
+	}
+	for _, p := range ports {
+		sc.Spec.Egress = append(sc.Spec.Egress, &graphql.IstioEgressListener{
+			Port:  &v1alpha3.Port{Number: p, Protocol: "HTTP", Name: "http"},
+			Hosts: []string{"./*"},
+		})
+	}
+
+	return sc
+}
+
+func TestTargetSidecars(t *testing.T) {
+	namespaceWide := testSidecar(nil, 80)
+	workload := testSidecar(map[string]string{"app": "catalog"}, 8080)
+	sidecars := []graphql.Sidecar{namespaceWide, workload}
+
+	if result := targetSidecars(sidecars, ""); len(result) != 1 || result[0].Spec.WorkloadSelector != nil {
+		t.Errorf("expected only the namespace wide sidecar for a namespace, got %+v", result)
+	}
+	if result := targetSidecars(sidecars, "catalog"); len(result) != 1 || result[0].Spec.WorkloadSelector == nil {
+		t.Errorf("expected only the workload sidecar for a workload, got %+v", result)
+	}
+	if result := targetSidecars(nil, "catalog"); result == nil || len(result) != 0 {
+		t.Errorf("expected an empty list, got %+v", result)
+	}
+}
+
+func TestHistoryAddAndFind(t *testing.T) {
+	h := &history{}
+
+	first := h.add("demo", "", nil, []graphql.Sidecar{testSidecar(nil, 80)})
+	second := h.add("demo", "catalog", nil, []graphql.Sidecar{testSidecar(nil, 80), testSidecar(map[string]string{"app": "catalog"}, 8080)})
+	third := h.add("demo", "", []graphql.Sidecar{testSidecar(nil, 80)}, []graphql.Sidecar{testSidecar(nil, 80, 443)})
+
+	if first.ID != 1 || second.ID != 2 || third.ID != 3 {
+		t.Fatalf("unexpected IDs: %d, %d, %d", first.ID, second.ID, third.ID)
+	}
+	if len(second.Applied) != 1 || second.Applied[0].Spec.WorkloadSelector == nil {
+		t.Errorf("only the workload sidecars should be recorded for a workload: %+v", second.Applied)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		workload  string
+		id        int
+		expected  int
+		err       bool
+	}{
+		{"latest of namespace", "demo", "", 0, 3, false},
+		{"latest of workload", "demo", "catalog", 0, 2, false},
+		{"by ID", "", "", 1, 1, false},
+		{"unknown ID", "", "", 4, 0, true},
+		{"unknown workload", "demo", "orders", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := h.find(tt.namespace, tt.workload, tt.id)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && entry.ID != tt.expected {
+				t.Errorf("expected entry %d, got %d", tt.expected, entry.ID)
+			}
+		})
+	}
+
+	now := time.Now()
+	h.Entries[2].RolledBack = &now
+	entry, err := h.find("demo", "", 0)
+	if err != nil || entry.ID != 1 {
+		t.Errorf("a rolled back entry should be skipped, got %+v, %v", entry, err)
+	}
+}
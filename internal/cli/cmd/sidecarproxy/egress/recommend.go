@@ -27,6 +27,7 @@ import (
 	cmdCommon "github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
 )
 
 type recommendCommand struct{}
@@ -117,11 +118,29 @@ func (c *recommendCommand) run(cli cli.CLI, options *RecommendOptions) error {
 		return err
 	}
 
+	current, err := getSidecars(client, options.namespaceID, options.workloadID)
+	if err != nil {
+		return errors.WrapIf(err, "could not retrieve sidecars through graphql")
+	}
+	current = targetSidecars(current, options.workloadID)
+
 	err = Output(cli, options.namespaceID, options.workloadID, sidecars, true, options.apply)
 	if err != nil {
 		return err
 	}
 
+	if len(sidecars) > 0 && cli.OutputFormat() == output.OutputFormatTable {
+		d, err := egressDiff(current, sidecars, "current", "recommended", cli.Color())
+		if err != nil {
+			return err
+		}
+		if d == "" {
+			fmt.Fprintf(cli.Out(), "The current egress rules of %s already match the recommendations\n\n", target(options.namespaceID, options.workloadID))
+		} else {
+			fmt.Fprintf(cli.Out(), "Changes to the egress rules of %s\n\n%s\n", target(options.namespaceID, options.workloadID), d)
+		}
+	}
+
 	if options.apply {
 		if cli.Interactive() {
 			var err error
@@ -157,6 +176,11 @@ func (c *recommendCommand) run(cli cli.CLI, options *RecommendOptions) error {
 
 		fmt.Fprintf(cli.Out(), "\nRecommendations were successfully applied\n")
 
+		err = recordHistory(cli, options.namespaceID, options.workloadID, current, sidecars)
+		if err != nil {
+			log.Warnf("could not save egress history: %s", err)
+		}
+
 		return Output(cli, options.namespaceID, options.workloadID, sidecars, false, false)
 	}
 
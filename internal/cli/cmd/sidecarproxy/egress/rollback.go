@@ -0,0 +1,210 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+
+	cmdCommon "github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/graphql"
+)
+
+type rollbackCommand struct{}
+
+type rollbackOptions struct {
+	workloadID  string
+	namespaceID string
+	id          int
+}
+
+func newRollbackCommand(cli cli.CLI) *cobra.Command {
+	c := &rollbackCommand{}
+	options := &rollbackOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rollback [--namespace] namespace [--workload name] [--id N]",
+		Short: "Restore the egress rules of a workload or a namespace from the egress history",
+		Long: `Restore the egress rules of a workload or a namespace from the egress history.
+
+Without the --id flag the latest change of the given namespace or workload is rolled back.
+The recorded changes can be listed with the history command.`,
+		Args:          cobra.MaximumNArgs(2),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if options.namespaceID == "" {
+					options.namespaceID = args[0]
+				} else if options.workloadID == "" {
+					options.workloadID = args[0]
+				}
+			}
+			if len(args) == 2 {
+				options.namespaceID = args[0]
+				options.workloadID = args[1]
+			}
+
+			if options.namespaceID == "" && options.id == 0 {
+				return errors.New("namespace or history ID must be specified")
+			}
+
+			if options.workloadID != "" {
+				_, err := util.ParseK8sResourceID(options.namespaceID + "/" + options.workloadID)
+				if err != nil {
+					return errors.WrapIf(err, "could not parse workload ID")
+				}
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.namespaceID, "namespace", "", "Namespace name")
+	flags.StringVar(&options.workloadID, "workload", "", "Workload name")
+	flags.IntVar(&options.id, "id", 0, "ID of the egress history entry to roll back")
+
+	return cmd
+}
+
+func (c *rollbackCommand) run(cli cli.CLI, options *rollbackOptions) error {
+	h, err := loadHistory(cli)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.find(options.namespaceID, options.workloadID, options.id)
+	if err != nil {
+		return err
+	}
+
+	client, err := cmdCommon.GetGraphQLClient(cli)
+	if err != nil {
+		return errors.WrapIf(err, "could not get initialized graphql client")
+	}
+	defer client.Close()
+
+	current, err := getSidecars(client, entry.Namespace, entry.Workload)
+	if err != nil {
+		return errors.WrapIf(err, "could not retrieve sidecars through graphql")
+	}
+
+	d, err := egressDiff(targetSidecars(current, entry.Workload), entry.Previous, "current", fmt.Sprintf("history #%d", entry.ID), cli.Color())
+	if err != nil {
+		return err
+	}
+	if d != "" {
+		fmt.Fprintf(cli.Out(), "Changes to the egress rules of %s\n\n%s\n", target(entry.Namespace, entry.Workload), d)
+	}
+
+	message := fmt.Sprintf("Restore the egress rules of %s from %s. Are you sure to proceed?", target(entry.Namespace, entry.Workload), entry.Time.Format(time.RFC3339))
+
+	return cli.IfConfirmed(message, func() error {
+		err := rollback(client, entry)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		entry.RolledBack = &now
+		err = h.save()
+		if err != nil {
+			log.Warnf("could not save egress history: %s", err)
+		}
+
+		log.Infof("sidecar egress for %s restored successfully\n\n", target(entry.Namespace, entry.Workload))
+
+		sidecars, err := getSidecars(client, entry.Namespace, entry.Workload)
+		if err != nil {
+			return errors.WrapIf(err, "could not retrieve sidecars through graphql")
+		}
+
+		return Output(cli, entry.Namespace, entry.Workload, sidecars, false, false)
+	})
+}
+
+// rollback re-applies the egress listeners recorded before the change, then removes the ones only added by it,
+// so that a failure on the way leaves the listeners of both states behind instead of neither
+func rollback(client graphql.Client, entry *HistoryEntry) error {
+	previous := make(map[string]bool)
+	for _, sc := range entry.Previous {
+		var labelWhitelist []string
+		if sc.Spec.WorkloadSelector != nil {
+			for l := range sc.Spec.WorkloadSelector.Labels {
+				labelWhitelist = append(labelWhitelist, l)
+			}
+		}
+		for _, e := range sc.Spec.Egress {
+			previous[egressListenerKey(sc, e)] = true
+			_, err := applyEgress(client, entry.Namespace, entry.Workload, e.Bind, e.Hosts, e.Port, labelWhitelist)
+			if err != nil {
+				return errors.WrapIf(err, "could not restore sidecar egress rules")
+			}
+		}
+	}
+
+	for _, sc := range entry.Applied {
+		for _, e := range sc.Spec.Egress {
+			if previous[egressListenerKey(sc, e)] {
+				continue
+			}
+			req := graphql.DisableSidecarEgressInput{
+				Selector: graphql.SidecarEgressSelector{
+					Namespace: entry.Namespace,
+					Port:      e.Port,
+				},
+			}
+			if sc.Spec.WorkloadSelector != nil {
+				workloadLabels := sc.Spec.WorkloadSelector.Labels
+				req.Selector.WorkloadLabels = &workloadLabels
+			}
+			if e.Bind != "" {
+				bind := e.Bind
+				req.Selector.Bind = &bind
+			}
+
+			response, err := client.DisableSidecarEgress(req)
+			if err != nil {
+				return errors.WrapIf(err, "could not delete sidecar egress")
+			}
+			if !response {
+				return errors.New("unknown internal error: could not delete sidecar egress")
+			}
+		}
+	}
+
+	return nil
+}
+
+// egressListenerKey identifies an egress listener by the selector it is applied and disabled with
+func egressListenerKey(sc graphql.Sidecar, e *graphql.IstioEgressListener) string {
+	var selector string
+	if sc.Spec.WorkloadSelector != nil {
+		selector = labels.SelectorFromSet(sc.Spec.WorkloadSelector.Labels).String()
+	}
+	var port int
+	if e.Port != nil {
+		port = e.Port.Number
+	}
+
+	return fmt.Sprintf("%s/%d/%s", selector, port, e.Bind)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/banzaicloud/backyards-cli/pkg/graphql"
+)
+
+// recordingClient records the egress changes in the order they were requested
+type recordingClient struct {
+	graphql.Client
+	calls  []string
+	failOn string
+}
+
+func (c *recordingClient) ApplySidecarEgress(input graphql.ApplySidecarEgressInput) (graphql.ApplySidecarEgressResponse, error) {
+	call := fmt.Sprintf("apply %d", input.Selector.Port.Number)
+	if call == c.failOn {
+		return false, fmt.Errorf("%s failed", call)
+	}
+	c.calls = append(c.calls, call)
+	return true, nil
+}
+
+func (c *recordingClient) DisableSidecarEgress(input graphql.DisableSidecarEgressInput) (graphql.DisableSidecarEgressResponse, error) {
+	call := fmt.Sprintf("disable %d", input.Selector.Port.Number)
+	if call == c.failOn {
+		return false, fmt.Errorf("%s failed", call)
+	}
+	c.calls = append(c.calls, call)
+	return true, nil
+}
+
+func TestRollback(t *testing.T) {
+	entry := &HistoryEntry{
+		Namespace: "demo",
+		Previous:  []graphql.Sidecar{testSidecar(nil, 80, 8080)},
+		Applied:   []graphql.Sidecar{testSidecar(nil, 80, 443)},
+	}
+
+	client := &recordingClient{}
+	err := rollback(client, entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{"apply 80", "apply 8080", "disable 443"}
+	if fmt.Sprint(client.calls) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, client.calls)
+	}
+
+	// the previous listeners are restored before anything is disabled
+	client = &recordingClient{failOn: "apply 8080"}
+	err = rollback(client, entry)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if fmt.Sprint(client.calls) != fmt.Sprint([]string{"apply 80"}) {
+		t.Errorf("no listener should be disabled after a failed restore, got %v", client.calls)
+	}
+}
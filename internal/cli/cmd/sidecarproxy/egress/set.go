@@ -119,6 +119,11 @@ func (c *setCommand) run(cli cli.CLI, options *setOptions) error {
 	}
 	defer client.Close()
 
+	previous, err := getSidecars(client, options.namespaceID, options.workloadID)
+	if err != nil {
+		return errors.WrapIf(err, "could not retrieve sidecars through graphql")
+	}
+
 	response, err := applyEgress(client, options.namespaceID, options.workloadID, options.parsedBind, options.hosts, options.parsedPort, options.labelWhitelist)
 	if err != nil {
 		return errors.WrapIf(err, "could not apply sidecar egress rules")
@@ -145,7 +150,17 @@ func (c *setCommand) run(cli cli.CLI, options *setOptions) error {
 
 	log.Infof("sidecar egress for %s/%s set successfully\n\n", options.namespaceID, options.workloadID)
 
-	return Output(cli, options.namespaceID, options.workloadID, sidecars, false, false)
+	err = Output(cli, options.namespaceID, options.workloadID, sidecars, false, false)
+	if err != nil {
+		return err
+	}
+
+	err = recordHistory(cli, options.namespaceID, options.workloadID, previous, sidecars)
+	if err != nil {
+		log.Warnf("could not save egress history: %s", err)
+	}
+
+	return nil
 }
 
 func applyEgress(client graphql.Client, namespace, name, bind string, hosts []string, port *v1alpha3.Port, labelWhitelist []string) (bool, error) {
@@ -0,0 +1,167 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+const (
+	sidecarContainerName = "istio-proxy"
+	unixSocketPrefix     = "unix://"
+
+	// managedByLabel marks the Sidecars created by the CLI, so that they are backed up
+	// together with the sidecar resources created through Backyards
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "backyards"
+)
+
+var defaultEndpointHosts = []string{"127.0.0.1", "0.0.0.0"}
+
+// getPodTemplate returns the pod template of the Deployment or StatefulSet with the given name
+func getPodTemplate(cl k8sclient.Client, name types.NamespacedName) (*corev1.PodTemplateSpec, error) {
+	var d appsv1.Deployment
+	err := cl.Get(context.Background(), name, &d)
+	if err == nil {
+		return &d.Spec.Template, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, errors.WrapIfWithDetails(err, "could not get deployment", "name", name.String())
+	}
+
+	var s appsv1.StatefulSet
+	err = cl.Get(context.Background(), name, &s)
+	if err == nil {
+		return &s.Spec.Template, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, errors.WrapIfWithDetails(err, "could not get statefulset", "name", name.String())
+	}
+
+	return nil, errors.NewWithDetails("no deployment or statefulset found", "name", name.String())
+}
+
+// containerPorts collects the ports exposed by the application containers of a pod template
+func containerPorts(template *corev1.PodTemplateSpec) []int {
+	ports := make([]int, 0)
+	for _, c := range template.Spec.Containers {
+		if c.Name == sidecarContainerName {
+			continue
+		}
+		for _, p := range c.Ports {
+			ports = append(ports, int(p.ContainerPort))
+		}
+	}
+	sort.Ints(ports)
+
+	return ports
+}
+
+// listSidecars returns the Sidecar resources of the namespace, or only the ones
+// selecting pods with the given labels if podLabels is not nil
+func listSidecars(cl k8sclient.Client, namespace string, podLabels map[string]string) ([]v1alpha3.Sidecar, error) {
+	var sidecarList v1alpha3.SidecarList
+	err := cl.List(context.Background(), &sidecarList, client.InNamespace(namespace))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list sidecars", "namespace", namespace)
+	}
+
+	if podLabels == nil {
+		return sidecarList.Items, nil
+	}
+
+	sidecars := make([]v1alpha3.Sidecar, 0)
+	for _, sc := range sidecarList.Items {
+		if selectsPod(sc, podLabels) {
+			sidecars = append(sidecars, sc)
+		}
+	}
+
+	return sidecars, nil
+}
+
+// selectsPod tells whether a Sidecar has a workload selector matching the given pod labels;
+// namespace wide Sidecars are not considered, since ingress listeners belong to a workload
+func selectsPod(sc v1alpha3.Sidecar, podLabels map[string]string) bool {
+	if sc.Spec.WorkloadSelector == nil || len(sc.Spec.WorkloadSelector.Labels) == 0 {
+		return false
+	}
+
+	return labels.SelectorFromSet(sc.Spec.WorkloadSelector.Labels).Matches(labels.Set(podLabels))
+}
+
+// validateDefaultEndpoint checks that the default endpoint is in 127.0.0.1:PORT, 0.0.0.0:PORT or
+// unix:///path/to/socket format, and that the port is exposed by one of the workload containers
+func validateDefaultEndpoint(endpoint string, ports []int) error {
+	if strings.HasPrefix(endpoint, unixSocketPrefix) {
+		if strings.TrimPrefix(endpoint, unixSocketPrefix) == "" {
+			return errors.New("invalid default endpoint, socket path is missing")
+		}
+		return nil
+	}
+
+	parts := strings.Split(endpoint, ":")
+	if len(parts) != 2 {
+		return errors.Errorf("invalid default endpoint '%s', please use %s:PORT or %s/path/to/socket", endpoint, defaultEndpointHosts[0], unixSocketPrefix)
+	}
+
+	validHost := false
+	for _, h := range defaultEndpointHosts {
+		if parts[0] == h {
+			validHost = true
+			break
+		}
+	}
+	if !validHost {
+		return errors.Errorf("invalid default endpoint host '%s', must be one of %s", parts[0], strings.Join(defaultEndpointHosts, ", "))
+	}
+
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return errors.New("invalid default endpoint, port is not an integer")
+	}
+
+	for _, p := range ports {
+		if p == port {
+			return nil
+		}
+	}
+
+	if len(ports) == 0 {
+		return errors.Errorf("port %d of the default endpoint is not exposed, the workload has no container ports", port)
+	}
+
+	exposed := make([]string, len(ports))
+	for i, p := range ports {
+		exposed[i] = strconv.Itoa(p)
+	}
+
+	return errors.Errorf("port %d of the default endpoint is not exposed by the workload, container ports: %s", port, strings.Join(exposed, ", "))
+}
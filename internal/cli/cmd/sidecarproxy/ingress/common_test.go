@@ -0,0 +1,48 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"testing"
+)
+
+func TestValidateDefaultEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		ports    []int
+		valid    bool
+	}{
+		{"localhost", "127.0.0.1:8080", []int{80, 8080}, true},
+		{"any address", "0.0.0.0:8080", []int{8080}, true},
+		{"unix socket", "unix:///var/run/app.sock", nil, true},
+		{"unix socket without path", "unix://", nil, false},
+		{"other host", "10.0.0.1:8080", []int{8080}, false},
+		{"missing port", "127.0.0.1", []int{8080}, false},
+		{"invalid port", "127.0.0.1:http", []int{8080}, false},
+		{"port not exposed", "127.0.0.1:9090", []int{8080}, false},
+		{"no container ports", "127.0.0.1:8080", nil, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDefaultEndpoint(tt.endpoint, tt.ports)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid: %t, got error: %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+type deleteCommand struct{}
+
+type deleteOptions struct {
+	workloadID   string
+	workloadName types.NamespacedName
+
+	bind string
+
+	parsedPort *v1alpha3.Port
+}
+
+func newDeleteCommand(cli cli.CLI) *cobra.Command {
+	d := &deleteCommand{}
+	options := &deleteOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "delete [[--workload=]namespace/name] [--bind PROTOCOL://[IP]:port]",
+		Short: "Delete sidecar ingress listeners of a workload",
+		Long: `Delete sidecar ingress listeners of a workload.
+
+Only the listener on the port of the --bind flag is deleted if it is set, otherwise every ingress listener
+of the workload is removed. Sidecar resources left without any configuration are deleted.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.workloadID = args[0]
+			}
+
+			if options.workloadID == "" {
+				return errors.New("workload must be specified")
+			}
+
+			options.workloadName, err = util.ParseK8sResourceID(options.workloadID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse workload ID")
+			}
+
+			_, options.parsedPort, err = common.ParseSidecarEgressBind(options.bind)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse bind option")
+			}
+			// a bind without a port would match every listener
+			if options.bind != "" && options.parsedPort == nil {
+				return errors.New("bind must be specified in PROTOCOL://[IP]:port format, unix sockets are not allowed for ingress listeners")
+			}
+
+			return d.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.workloadID, "workload", "", "Workload name in namespace/name format")
+	flags.StringVarP(&options.bind, "bind", "b", "", "Ingress listener bind PROTOCOL://[IP]:port")
+
+	return cmd
+}
+
+func (d *deleteCommand) run(cli cli.CLI, options *deleteOptions) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	template, err := getPodTemplate(cl, options.workloadName)
+	if err != nil {
+		return err
+	}
+
+	sidecars, err := listSidecars(cl, options.workloadName.Namespace, template.Labels)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]v1alpha3.Sidecar, 0)
+	deleted := 0
+	for i := range sidecars {
+		sidecar := &sidecars[i]
+
+		ingress := make([]*v1alpha3.IstioIngressListener, 0)
+		for _, l := range sidecar.Spec.Ingress {
+			if options.parsedPort != nil && (l.Port == nil || l.Port.Number != options.parsedPort.Number) {
+				ingress = append(ingress, l)
+			}
+		}
+		if len(ingress) == len(sidecar.Spec.Ingress) {
+			remaining = append(remaining, *sidecar)
+			continue
+		}
+		deleted += len(sidecar.Spec.Ingress) - len(ingress)
+		sidecar.Spec.Ingress = ingress
+
+		if len(sidecar.Spec.Ingress) == 0 && len(sidecar.Spec.Egress) == 0 && sidecar.Spec.OutboundTrafficPolicy == nil {
+			err = cl.Delete(context.Background(), sidecar)
+			if err != nil {
+				return errors.WrapIfWithDetails(err, "could not delete sidecar", "name", sidecar.Name)
+			}
+			continue
+		}
+
+		err = cl.Update(context.Background(), sidecar)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not update sidecar", "name", sidecar.Name)
+		}
+		remaining = append(remaining, *sidecar)
+	}
+
+	if deleted == 0 {
+		log.Infof("no matching sidecar ingress found for %s", options.workloadName)
+		return nil
+	}
+
+	log.Infof("sidecar ingress for %s deleted successfully\n\n", options.workloadName)
+
+	return Output(cli, options.workloadName.Namespace, options.workloadName.Name, remaining)
+}
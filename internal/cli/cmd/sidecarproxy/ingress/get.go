@@ -0,0 +1,98 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+type getCommand struct{}
+
+type getOptions struct {
+	id           string
+	workloadName types.NamespacedName
+}
+
+func newGetCommand(cli cli.CLI) *cobra.Command {
+	c := &getCommand{}
+	options := &getOptions{}
+
+	cmd := &cobra.Command{
+		Use:           "get [--namespace=]namespace|namespace/workload",
+		Short:         "Get sidecar ingress listeners for a namespace or a workload",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.id = args[0]
+			}
+
+			if options.id == "" {
+				return errors.New("namespace must be specified")
+			}
+
+			if strings.Contains(options.id, "/") {
+				options.workloadName, err = util.ParseK8sResourceID(options.id)
+				if err != nil {
+					return errors.WrapIf(err, "could not parse workload ID")
+				}
+			} else {
+				options.workloadName.Namespace = options.id
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.id, "namespace", "", "Namespace name, or workload name in namespace/name format")
+
+	return cmd
+}
+
+func (c *getCommand) run(cli cli.CLI, options *getOptions) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	var podLabels map[string]string
+	if options.workloadName.Name != "" {
+		template, err := getPodTemplate(cl, options.workloadName)
+		if err != nil {
+			return err
+		}
+		podLabels = template.Labels
+		if podLabels == nil {
+			podLabels = make(map[string]string)
+		}
+	}
+
+	sidecars, err := listSidecars(cl, options.workloadName.Namespace, podLabels)
+	if err != nil {
+		return err
+	}
+
+	return Output(cli, options.workloadName.Namespace, options.workloadName.Name, sidecars)
+}
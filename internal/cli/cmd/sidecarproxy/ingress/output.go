@@ -0,0 +1,108 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"bytes"
+	"fmt"
+
+	"emperror.dev/errors"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type Out struct {
+	Sidecar         string      `json:"sidecar,omitempty"`
+	Selector        string      `json:"selector,omitempty"`
+	Port            common.Port `json:"port,omitempty"`
+	Bind            string      `json:"bind,omitempty"`
+	DefaultEndpoint string      `json:"defaultEndpoint,omitempty"`
+	CaptureMode     string      `json:"captureMode,omitempty"`
+}
+
+func Output(cli cli.CLI, namespace, workload string, sidecars []v1alpha3.Sidecar) error {
+	outs := make([]Out, 0)
+	for _, sc := range sidecars {
+		var selector string
+		if sc.Spec.WorkloadSelector != nil {
+			b := new(bytes.Buffer)
+			for key, value := range sc.Spec.WorkloadSelector.Labels {
+				fmt.Fprintf(b, "%s=\"%s\"\n", key, value)
+			}
+			selector = b.String()
+		}
+		for _, i := range sc.Spec.Ingress {
+			o := Out{
+				Sidecar:         sc.Name,
+				Selector:        selector,
+				Bind:            i.Bind,
+				DefaultEndpoint: i.DefaultEndpoint,
+				CaptureMode:     string(i.CaptureMode),
+			}
+			if i.Port != nil {
+				o.Port = common.Port(*i.Port)
+			}
+			outs = append(outs, o)
+		}
+	}
+
+	var target string
+	if workload == "" {
+		target = "namespace " + namespace
+	} else {
+		target = namespace + "/" + workload
+	}
+
+	if len(outs) == 0 {
+		fmt.Fprintf(cli.Out(), "no ingress rule found for %s\n\n", target)
+		return nil
+	}
+
+	if cli.OutputFormat() == output.OutputFormatTable && cli.Interactive() {
+		fmt.Fprintf(cli.Out(), "Sidecar ingress rules for %s\n\n", target)
+	}
+
+	err := show(cli, outs)
+	if err != nil {
+		return err
+	}
+
+	if cli.Interactive() {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func show(cli output.FormatContext, data interface{}) error {
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Sidecar", "Selector", "Port", "Bind", "DefaultEndpoint", "CaptureMode"},
+		Headers: []string{"Sidecar", "Selector", "Port", "Bind", "Default Endpoint", "Capture Mode"},
+	}
+
+	err := output.Output(ctx, data)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+)
+
+type setCommand struct{}
+
+type setOptions struct {
+	workloadID   string
+	workloadName types.NamespacedName
+
+	bind            string
+	defaultEndpoint string
+	captureMode     string
+	labelWhitelist  []string
+
+	parsedBind string
+	parsedPort *v1alpha3.Port
+}
+
+func newSetCommand(cli cli.CLI) *cobra.Command {
+	c := &setCommand{}
+	options := &setOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "set [[--workload=]namespace/name] --bind PROTOCOL://[IP]:port [--default-endpoint 127.0.0.1:port|unix:///path]",
+		Short: "Set sidecar ingress listener for a workload",
+		Long: `Set sidecar ingress listener for a workload.
+
+The listener is added to the Sidecar resource selecting the workload, or a new Sidecar is created
+if there is none. An existing listener on the same port is replaced. The port of the default endpoint
+must be exposed by one of the containers of the workload.`,
+		Example: `  # accept HTTP traffic on port 9080 and forward it to the application on port 8080
+  backyards sp ingress set backyards-demo/catalog-v1 --bind HTTP://0.0.0.0:9080 --default-endpoint 127.0.0.1:8080`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.workloadID = args[0]
+			}
+
+			if options.workloadID == "" {
+				return errors.New("workload must be specified")
+			}
+
+			options.workloadName, err = util.ParseK8sResourceID(options.workloadID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse workload ID")
+			}
+
+			options.parsedBind, options.parsedPort, err = common.ParseSidecarEgressBind(options.bind)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse bind option")
+			}
+
+			err = c.validateOptions(options)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.workloadID, "workload", "", "Workload name in namespace/name format")
+	flags.StringVarP(&options.bind, "bind", "b", "", "Ingress listener bind PROTOCOL://[IP]:port")
+	flags.StringVar(&options.defaultEndpoint, "default-endpoint", "", "Endpoint the traffic is forwarded to, 127.0.0.1:port or unix:///path/to/socket (default 127.0.0.1:<bind port>)")
+	flags.StringVar(&options.captureMode, "capture-mode", "", fmt.Sprintf("Traffic capture mode (%s|%s|%s)", v1alpha3.CaptureModeDefault, v1alpha3.CaptureModeIPTables, v1alpha3.CaptureModeNone))
+	flags.StringArrayVarP(&options.labelWhitelist, "labelWhitelist", "l", options.labelWhitelist, "Labels to include in the workload selector of a newly created sidecar")
+
+	return cmd
+}
+
+func (c *setCommand) validateOptions(options *setOptions) error {
+	if options.parsedPort == nil {
+		return errors.New("bind must be specified in PROTOCOL://[IP]:port format, unix sockets are not allowed for ingress listeners")
+	}
+
+	if options.defaultEndpoint == "" {
+		options.defaultEndpoint = fmt.Sprintf("%s:%d", defaultEndpointHosts[0], options.parsedPort.Number)
+	}
+
+	switch v1alpha3.CaptureMode(strings.ToUpper(options.captureMode)) {
+	case "", v1alpha3.CaptureModeDefault, v1alpha3.CaptureModeIPTables, v1alpha3.CaptureModeNone:
+		options.captureMode = strings.ToUpper(options.captureMode)
+	default:
+		return errors.Errorf("invalid capture mode '%s'", options.captureMode)
+	}
+
+	return nil
+}
+
+func (c *setCommand) run(cli cli.CLI, options *setOptions) error {
+	cl, err := cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	template, err := getPodTemplate(cl, options.workloadName)
+	if err != nil {
+		return err
+	}
+
+	err = validateDefaultEndpoint(options.defaultEndpoint, containerPorts(template))
+	if err != nil {
+		return err
+	}
+
+	sidecars, err := listSidecars(cl, options.workloadName.Namespace, template.Labels)
+	if err != nil {
+		return err
+	}
+	if len(sidecars) > 1 {
+		return errors.NewWithDetails("workload is selected by multiple sidecars, cannot decide which one to modify", "name", options.workloadName.String())
+	}
+
+	listener := &v1alpha3.IstioIngressListener{
+		Port:            options.parsedPort,
+		Bind:            options.parsedBind,
+		CaptureMode:     v1alpha3.CaptureMode(options.captureMode),
+		DefaultEndpoint: options.defaultEndpoint,
+	}
+
+	if len(sidecars) == 0 {
+		selector, err := workloadSelector(template.Labels, options.labelWhitelist)
+		if err != nil {
+			return err
+		}
+		sidecar := &v1alpha3.Sidecar{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      options.workloadName.Name,
+				Namespace: options.workloadName.Namespace,
+				Labels:    map[string]string{managedByLabel: managedByValue},
+			},
+			Spec: v1alpha3.SidecarSpec{
+				WorkloadSelector: &v1alpha3.WorkloadSelector{
+					Labels: selector,
+				},
+				Ingress: []*v1alpha3.IstioIngressListener{listener},
+			},
+		}
+		err = cl.Create(context.Background(), sidecar)
+		if err != nil {
+			return errors.WrapIf(err, "could not create sidecar")
+		}
+		sidecars = append(sidecars, *sidecar)
+	} else {
+		sidecar := &sidecars[0]
+		replaced := false
+		for i, l := range sidecar.Spec.Ingress {
+			if l.Port != nil && l.Port.Number == listener.Port.Number {
+				sidecar.Spec.Ingress[i] = listener
+				replaced = true
+			}
+		}
+		if !replaced {
+			sidecar.Spec.Ingress = append(sidecar.Spec.Ingress, listener)
+		}
+		err = cl.Update(context.Background(), sidecar)
+		if err != nil {
+			return errors.WrapIf(err, "could not update sidecar")
+		}
+	}
+
+	log.Infof("sidecar ingress for %s set successfully\n\n", options.workloadName)
+
+	return Output(cli, options.workloadName.Namespace, options.workloadName.Name, sidecars)
+}
+
+func workloadSelector(podLabels map[string]string, labelWhitelist []string) (map[string]string, error) {
+	if len(podLabels) == 0 {
+		return nil, errors.New("workload has no pod labels to select it with")
+	}
+
+	if len(labelWhitelist) == 0 {
+		return podLabels, nil
+	}
+
+	selector := make(map[string]string)
+	for _, l := range labelWhitelist {
+		if v, ok := podLabels[l]; ok {
+			selector[l] = v
+		}
+	}
+	if len(selector) == 0 {
+		return nil, errors.New("workload has no matching label from label whitelist")
+	}
+
+	return selector, nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkloadSelector(t *testing.T) {
+	podLabels := map[string]string{"app": "catalog", "version": "v1", "pod-template-hash": "abc"}
+
+	tests := []struct {
+		name      string
+		podLabels map[string]string
+		whitelist []string
+		selector  map[string]string
+		err       bool
+	}{
+		{"all labels", podLabels, nil, podLabels, false},
+		{"whitelisted labels", podLabels, []string{"app", "version", "missing"}, map[string]string{"app": "catalog", "version": "v1"}, false},
+		{"no whitelisted label", podLabels, []string{"missing"}, nil, true},
+		{"no pod labels", nil, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := workloadSelector(tt.podLabels, tt.whitelist)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(selector, tt.selector) {
+				t.Errorf("expected selector %v, got %v", tt.selector, selector)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/envoy"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+const defaultLogLevel = "info"
+
+type logCommand struct{}
+
+type logOptions struct {
+	podID    string
+	podName  types.NamespacedName
+	level    string
+	duration time.Duration
+	reset    bool
+
+	// levels to set per logger, the "level" key sets every logger
+	levels map[string]string
+}
+
+type Out struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+}
+
+func newLogOptions() *logOptions {
+	return &logOptions{}
+}
+
+func NewLogCommand(cli cli.CLI) *cobra.Command {
+	c := &logCommand{}
+	options := newLogOptions()
+
+	cmd := &cobra.Command{
+		Use:   "log [--pod=]namespace/pod [--level=level|logger:level,...] [--for=duration]",
+		Short: "Show or change the log levels of a sidecar proxy",
+		Long: `Show or change the log levels of a sidecar proxy.
+
+Without the --level flag the current log levels are listed.
+When the --for flag is set, the command waits for the given duration and then restores the previous levels.`,
+		Example: `  # raise the level of the http and router loggers for 5 minutes
+  backyards sp log backyards-demo/catalog-v1-5864c4b7d7-j5cmf --level http:debug,router:trace --for 5m
+
+  # set every logger to debug
+  backyards sp log backyards-demo/catalog-v1-5864c4b7d7-j5cmf --level debug
+
+  # reset every logger to the default level
+  backyards sp log backyards-demo/catalog-v1-5864c4b7d7-j5cmf --reset`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.podID = args[0]
+			}
+
+			if options.podID == "" {
+				return errors.New("pod must be specified")
+			}
+
+			options.podName, err = util.ParseK8sResourceID(options.podID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse pod ID")
+			}
+
+			if options.reset && options.level != "" {
+				return errors.New("--reset and --level cannot be used together")
+			}
+			if options.reset {
+				options.level = defaultLogLevel
+			}
+
+			if options.duration > 0 && options.level == "" {
+				return errors.New("--for can only be used together with --level")
+			}
+
+			options.levels, err = parseLevels(options.level)
+			if err != nil {
+				return err
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.podID, "pod", "", "Pod name in namespace/name format")
+	flags.StringVar(&options.level, "level", "", fmt.Sprintf("Log level for every logger, or a comma separated list of logger:level pairs (levels: %s)", strings.Join(envoy.ValidLogLevels, "|")))
+	flags.DurationVar(&options.duration, "for", 0, "Restore the previous log levels after this duration")
+	flags.BoolVar(&options.reset, "reset", false, fmt.Sprintf("Reset every logger to the %s level", defaultLogLevel))
+
+	return cmd
+}
+
+func parseLevels(level string) (map[string]string, error) {
+	levels := make(map[string]string)
+	if level == "" {
+		return levels, nil
+	}
+
+	if !strings.Contains(level, ":") {
+		if !envoy.IsValidLogLevel(level) {
+			return nil, errors.Errorf("invalid log level '%s'", level)
+		}
+		levels["level"] = level
+		return levels, nil
+	}
+
+	for _, pair := range strings.Split(level, ",") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid logger level '%s', format must be <logger>:<level>", pair)
+		}
+		if !envoy.IsValidLogLevel(parts[1]) {
+			return nil, errors.Errorf("invalid log level '%s' for logger %s", parts[1], parts[0])
+		}
+		levels[parts[0]] = parts[1]
+	}
+
+	return levels, nil
+}
+
+func (c *logCommand) run(cli cli.CLI, options *logOptions) (err error) {
+	client, pf, err := common.GetEnvoyAdminClient(cli, options.podName)
+	if err != nil {
+		return errors.WrapIf(err, "could not connect to the sidecar proxy")
+	}
+	defer pf.Stop()
+
+	previous, err := client.LogLevels()
+	if err != nil {
+		return err
+	}
+
+	if len(options.levels) == 0 {
+		return show(cli, previous, nil)
+	}
+
+	if options.duration > 0 {
+		// the levels are restored even if setting them fails partway, or the command fails or is interrupted later
+		defer func() {
+			restoreErr := restore(client, previous, options.levels)
+			if restoreErr != nil {
+				err = errors.Combine(err, restoreErr)
+				return
+			}
+			log.Infof("log levels of %s restored", options.podName)
+		}()
+	}
+
+	current, err := client.SetLogLevels(options.levels)
+	if err != nil {
+		return err
+	}
+
+	err = show(cli, current, options.levels)
+	if err != nil {
+		return err
+	}
+
+	if options.duration == 0 {
+		return nil
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	log.Infof("log levels of %s will be restored in %s (press Ctrl+C to restore them now)", options.podName, options.duration)
+	select {
+	case <-time.After(options.duration):
+	case <-signals:
+	}
+
+	return nil
+}
+
+// restore sets the previous level of the changed loggers
+func restore(client *envoy.AdminClient, previous map[string]string, changed map[string]string) error {
+	levels := make(map[string]string)
+	for logger, level := range previous {
+		if _, ok := changed[logger]; ok || changed["level"] != "" {
+			levels[logger] = level
+		}
+	}
+
+	_, err := client.SetLogLevels(levels)
+	if err != nil {
+		return errors.WrapIf(err, "could not restore previous log levels")
+	}
+
+	return nil
+}
+
+// show prints the levels of every logger, or only the changed ones if a filter is given
+func show(cli cli.CLI, levels map[string]string, filter map[string]string) error {
+	outs := make([]Out, 0)
+	for logger, level := range levels {
+		if len(filter) > 0 && filter["level"] == "" {
+			if _, ok := filter[logger]; !ok {
+				continue
+			}
+		}
+		outs = append(outs, Out{
+			Logger: logger,
+			Level:  level,
+		})
+	}
+	sort.Slice(outs, func(i, j int) bool {
+		return outs[i].Logger < outs[j].Logger
+	})
+
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Logger", "Level"},
+		Headers: []string{"Logger", "Level"},
+	}
+
+	err := output.Output(ctx, outs)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
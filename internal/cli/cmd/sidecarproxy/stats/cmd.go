@@ -0,0 +1,211 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/envoy"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+)
+
+type statsCommand struct{}
+
+type statsOptions struct {
+	podID       string
+	podName     types.NamespacedName
+	filter      string
+	interval    time.Duration
+	count       int
+	changedOnly bool
+}
+
+type StatOut struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+	Delta int64  `json:"delta"`
+}
+
+type SnapshotOut struct {
+	Time  time.Time `json:"time"`
+	Stats []StatOut `json:"stats"`
+}
+
+func newStatsOptions() *statsOptions {
+	return &statsOptions{
+		count: 1,
+	}
+}
+
+func NewStatsCommand(cli cli.CLI) *cobra.Command {
+	c := &statsCommand{}
+	options := newStatsOptions()
+
+	cmd := &cobra.Command{
+		Use:   "stats [--pod=]namespace/pod [--filter=regex]",
+		Short: "Show the stats of a sidecar proxy",
+		Long: `Show the stats of a sidecar proxy.
+
+With the --interval flag the stats are polled repeatedly, and the change of every counter
+since the previous poll is shown in the delta column.`,
+		Example: `  # show the upstream request counters once
+  backyards sp stats backyards-demo/catalog-v1-5864c4b7d7-j5cmf --filter upstream_rq
+
+  # poll the counters every 5 seconds until interrupted, only showing the changed ones
+  backyards sp stats backyards-demo/catalog-v1-5864c4b7d7-j5cmf --filter upstream_rq --interval 5s --count 0 --changed`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+
+			if len(args) > 0 {
+				options.podID = args[0]
+			}
+
+			if options.podID == "" {
+				return errors.New("pod must be specified")
+			}
+
+			options.podName, err = util.ParseK8sResourceID(options.podID)
+			if err != nil {
+				return errors.WrapIf(err, "could not parse pod ID")
+			}
+
+			if options.count < 0 {
+				return errors.New("count must not be negative")
+			}
+
+			if options.interval == 0 && options.count != 1 {
+				return errors.New("--interval must be set to poll stats multiple times")
+			}
+
+			return c.run(cli, options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.podID, "pod", "", "Pod name in namespace/name format")
+	flags.StringVar(&options.filter, "filter", "", "Only show stats whose name matches this regular expression")
+	flags.DurationVar(&options.interval, "interval", 0, "Poll the stats with this interval")
+	flags.IntVar(&options.count, "count", options.count, "Number of polls (0 means until interrupted)")
+	flags.BoolVar(&options.changedOnly, "changed", false, "Only show stats that changed since the previous poll")
+
+	return cmd
+}
+
+func (c *statsCommand) run(cli cli.CLI, options *statsOptions) error {
+	client, pf, err := common.GetEnvoyAdminClient(cli, options.podName)
+	if err != nil {
+		return errors.WrapIf(err, "could not connect to the sidecar proxy")
+	}
+	defer pf.Stop()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	var previous map[string]int64
+	for i := 0; options.count == 0 || i < options.count; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(options.interval):
+			case <-signals:
+				return nil
+			}
+		}
+
+		stats, err := client.Stats(options.filter)
+		if err != nil {
+			return err
+		}
+
+		err = show(cli, options, diff(stats, previous, options.changedOnly))
+		if err != nil {
+			return err
+		}
+
+		previous = make(map[string]int64, len(stats))
+		for _, s := range stats {
+			previous[s.Name] = s.Value
+		}
+	}
+
+	return nil
+}
+
+// diff calculates the change of the stats since the previous poll
+func diff(stats []envoy.Stat, previous map[string]int64, changedOnly bool) []StatOut {
+	outs := make([]StatOut, 0)
+	for _, s := range stats {
+		var delta int64
+		if previous != nil {
+			delta = s.Value - previous[s.Name]
+			if changedOnly && delta == 0 {
+				continue
+			}
+		}
+		outs = append(outs, StatOut{
+			Name:  s.Name,
+			Value: s.Value,
+			Delta: delta,
+		})
+	}
+
+	return outs
+}
+
+func show(cli cli.CLI, options *statsOptions, stats []StatOut) error {
+	if cli.OutputFormat() != output.OutputFormatTable {
+		return output.Output(&output.Context{
+			Out:    cli.Out(),
+			Color:  cli.Color(),
+			Format: cli.OutputFormat(),
+		}, SnapshotOut{
+			Time:  time.Now(),
+			Stats: stats,
+		})
+	}
+
+	if options.interval > 0 {
+		fmt.Fprintf(cli.Out(), "Stats of %s at %s\n\n", options.podName, time.Now().Format(time.RFC3339))
+	}
+
+	err := output.Output(&output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Name", "Value", "Delta"},
+		Headers: []string{"Name", "Value", "Delta"},
+	}, stats)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	if options.interval > 0 {
+		fmt.Fprintln(cli.Out())
+	}
+
+	return nil
+}
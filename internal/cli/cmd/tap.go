@@ -19,9 +19,11 @@ import (
 	"net/http"
 	"strings"
 	"text/template"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/mitchellh/mapstructure"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	cmdCommon "github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
@@ -35,6 +37,10 @@ import (
 const (
 	resourceTypeWorkload = "WORKLOAD"
 	resourceTypePod      = "POD"
+
+	maxResubscribeAttempts = 10
+	minResubscribeDelay    = time.Second
+	maxResubscribeDelay    = 30 * time.Second
 )
 
 type tapCommand struct {
@@ -188,9 +194,7 @@ func (c *tapCommand) run(cli cli.CLI, options *TapOptions) error {
 	errCh := make(chan error)
 	ctx, cancelContext := context.WithCancel(context.Background())
 	defer cancelContext()
-	go func() {
-		client.SubscribeToAccessLogs(ctx, input, ch, errCh)
-	}()
+	go c.subscribe(ctx, client, input, ch, errCh)
 
 	type Data map[string]interface{}
 
@@ -232,6 +236,51 @@ func (c *tapCommand) run(cli cli.CLI, options *TapOptions) error {
 	}
 }
 
+// subscribe subscribes to the access logs again when the subscription is interrupted, e.g. on the rollout of Backyards
+func (c *tapCommand) subscribe(ctx context.Context, client graphql.Client, input *graphql.GetAccessLogsInput, ch chan interface{}, errCh chan error) {
+	first := true
+	failures := 0
+	delay := minResubscribeDelay
+	for {
+		started := time.Now()
+		subErrCh := make(chan error, 1)
+		client.SubscribeToAccessLogs(ctx, input, ch, subErrCh)
+		err := <-subErrCh
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// the subscription is refused, e.g. because of invalid input
+		if first && time.Since(started) < minResubscribeDelay*5 {
+			errCh <- err
+			return
+		}
+		first = false
+
+		// a long running subscription was interrupted, not refused
+		if time.Since(started) > maxResubscribeDelay {
+			failures = 0
+			delay = minResubscribeDelay
+		}
+		failures++
+		if failures > maxResubscribeAttempts {
+			errCh <- err
+			return
+		}
+
+		log.Warnf("tap subscription interrupted, resubscribing in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
+
 func (c *tapCommand) parseResource(res string, parsed *res) error {
 	parts := strings.Split(res, "/")
 	if len(parts) < 2 {
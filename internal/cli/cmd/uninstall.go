@@ -21,29 +21,43 @@ import (
 	"emperror.dev/errors"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/MakeNowJust/heredoc"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	backupcmd "github.com/banzaicloud/backyards-cli/internal/cli/cmd/backup"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/canary"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/certmanager"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/demoapp"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	"github.com/banzaicloud/backyards-cli/pkg/backup"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
 	"github.com/banzaicloud/backyards-cli/pkg/helm"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s/resourcemanager"
 	"github.com/banzaicloud/backyards-cli/pkg/nodeexporter"
+	"github.com/banzaicloud/backyards-cli/pkg/release"
 )
 
 type uninstallCommand struct {
 	cli cli.CLI
+
+	// values and objects removed by the command, recorded in the release history
+	removedValues  []byte
+	removedObjects object.K8sObjects
 }
 
 type UninstallOptions struct {
 	releaseName    string
 	istioNamespace string
 	dumpResources  bool
+	keepHistory    bool
+	backupDir      string
+	skipBackup     bool
+	valueOverrides helm.ValueOverrides
 
 	uninstallEverything bool
 }
@@ -62,12 +76,27 @@ func NewUninstallCommand(cli cli.CLI) *cobra.Command {
 		Long: `Uninstall Backyards
 
 The command automatically removes the resources.
-It can only dump the removable resources with the '--dump-resources' option.`,
+It can only dump the removable resources with the '--dump-resources' option.
+
+The resources are rendered with the chart values recorded in the latest release, unless
+values files ('-f') or '--set key=value' options are given.
+
+The Backyards namespace is removed together with the release history stored in it.
+With the '--keep-history' option the namespace is kept and the removed resources are recorded
+in the release history, so that the removal can be rolled back with the rollback command.
+Only the Backyards and node exporter resources are recorded, the Istio, cert-manager, canary and
+demo application resources removed by the '--uninstall-everything' option cannot be restored by a rollback.
+
+The Istio custom resource and the routing, mTLS and sidecar resources created through Backyards are backed up
+before the removal, the backup can be restored with the 'backyards backup restore' command.`,
 		Example: `  # Default uninstall
   backyards uninstall
 
   # Uninstall Backyards from a non-default namespace
-  backyards uninstall -n backyards-system`,
+  backyards uninstall -n backyards-system
+
+  # Uninstall Backyards but keep the release history to be able to roll back the removal
+  backyards uninstall --keep-history`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
@@ -93,6 +122,16 @@ It can only dump the removable resources with the '--dump-resources' option.`,
 					}
 					options.uninstallEverything = response == AnswerAll
 				}
+
+				var b *backup.Backup
+				if !options.dumpResources && !options.skipBackup {
+					var err error
+					b, err = backupcmd.Create(cli, options.backupDir)
+					if err != nil {
+						return errors.WrapIf(err, "could not back up resources, use the '--skip-backup' option to uninstall without a backup")
+					}
+				}
+
 				err := c.run(options)
 				if err != nil {
 					return err
@@ -103,8 +142,27 @@ It can only dump the removable resources with the '--dump-resources' option.`,
 					return err
 				}
 
-				cli.GetPersistentConfig().SetToken("")
-				return c.runSubcommands(options)
+				err = c.recordRelease(options)
+				if err != nil {
+					return err
+				}
+
+				err = cli.GetPersistentConfig().SetToken("")
+				if err != nil {
+					return err
+				}
+
+				err = c.runSubcommands(options)
+				if err != nil {
+					return err
+				}
+
+				if b != nil {
+					fmt.Fprintf(cli.Out(), "The resources managed through Backyards were backed up, to restore them use:\n> %s\n\n",
+						backupcmd.RestoreCommand(b, options.backupDir))
+				}
+
+				return nil
 			})
 		},
 	}
@@ -112,6 +170,10 @@ It can only dump the removable resources with the '--dump-resources' option.`,
 	cmd.Flags().StringVar(&options.releaseName, "release-name", "backyards", "Name of the release")
 	cmd.Flags().StringVar(&options.istioNamespace, "istio-namespace", "istio-system", "Namespace of Istio sidecar injector")
 	cmd.Flags().BoolVarP(&options.dumpResources, "dump-resources", "d", false, "Dump resources to stdout instead of applying them")
+	cmd.Flags().BoolVar(&options.keepHistory, "keep-history", false, "Keep the Backyards namespace with the release history to be able to roll back the removal")
+	cmd.Flags().BoolVar(&options.skipBackup, "skip-backup", false, "Do not back up the resources managed through Backyards before the removal")
+	backupcmd.AddDirFlag(cmd.Flags(), &options.backupDir)
+	common.AddValueOverrideFlags(cmd.Flags(), &options.valueOverrides, "f")
 
 	cmd.Flags().BoolVarP(&options.uninstallEverything, "uninstall-everything", "a", false, "Uninstall all components at once")
 
@@ -124,13 +186,22 @@ func (c *uninstallCommand) run(options *UninstallOptions) error {
 		return err
 	}
 
-	objects, err := getBackyardsObjects(values, c.cli)
+	rawValues, err := c.getValues(options, values)
+	if err != nil {
+		return err
+	}
+
+	objects, err := renderBackyardsObjects(rawValues, c.cli)
 	if err != nil {
 		return err
 	}
 
 	objects.Sort(helm.UninstallObjectOrder())
 
+	if options.keepHistory {
+		objects = withoutNamespace(objects, c.cli.GetPersistentConfig().Namespace())
+	}
+
 	if !options.dumpResources {
 		client, err := c.cli.GetK8sClient()
 		if err != nil {
@@ -146,6 +217,10 @@ func (c *uninstallCommand) run(options *UninstallOptions) error {
 		if err != nil {
 			return errors.WrapIf(err, "could not delete k8s resources")
 		}
+
+		c.removedValues = rawValues
+		c.removedObjects = append(c.removedObjects, objects...)
+
 		return nil
 	}
 
@@ -158,6 +233,32 @@ func (c *uninstallCommand) run(options *UninstallOptions) error {
 	return nil
 }
 
+// getValues returns the chart values Backyards was installed with, to remove the resources enabled by value overrides as well.
+// The overrides given as options are used if there are any, otherwise the values recorded in the latest release.
+func (c *uninstallCommand) getValues(options *UninstallOptions, values Values) ([]byte, error) {
+	if !options.valueOverrides.Empty() || options.dumpResources {
+		return getBackyardsValues(values, options.valueOverrides)
+	}
+
+	store, err := newReleaseStore(c.cli, options.releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) > 0 {
+		if latest := releases[len(releases)-1]; latest.Action != release.UninstallAction && latest.Values != "" {
+			log.Debugf("using the values of revision %d of release %s", latest.Revision, options.releaseName)
+			return []byte(latest.Values), nil
+		}
+	}
+
+	return getBackyardsValues(values, options.valueOverrides)
+}
+
 func (c *uninstallCommand) runSubcommands(options *UninstallOptions) error {
 	var err error
 	var scmd *cobra.Command
@@ -249,6 +350,7 @@ func (c *uninstallCommand) runNodeExporterUninstall(options *UninstallOptions) e
 		if err != nil {
 			return err
 		}
+		c.removedObjects = append(c.removedObjects, m.Resources()...)
 	}
 
 	return nil
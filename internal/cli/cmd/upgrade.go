@@ -0,0 +1,489 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"istio.io/operator/pkg/object"
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sapimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/canary"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/certmanager"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/common"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/util"
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/cli"
+	"github.com/banzaicloud/backyards-cli/pkg/output"
+	"github.com/banzaicloud/backyards-cli/pkg/release"
+	"github.com/banzaicloud/backyards-cli/pkg/upgrade"
+)
+
+const (
+	upgradeActionUpgrade = "upgrade"
+	upgradeActionReapply = "reapply"
+	upgradeActionSkip    = "skip"
+
+	// at most one minor version can be skipped, both for Backyards and for Istio
+	maxMinorVersionSkew = 1
+)
+
+type upgradeCommand struct {
+	cli cli.CLI
+}
+
+type UpgradeOptions struct {
+	*InstallOptions
+
+	force bool
+}
+
+type UpgradeStepOut struct {
+	Component string `json:"component"`
+	Installed string `json:"installed,omitempty"`
+	Target    string `json:"target"`
+	Action    string `json:"action"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+type upgradeStep struct {
+	UpgradeStepOut
+
+	blocked bool
+	run     func() error
+}
+
+func NewUpgradeOptions(installOptions *InstallOptions) *UpgradeOptions {
+	return &UpgradeOptions{
+		InstallOptions: installOptions,
+	}
+}
+
+func NewUpgradeCommand(cli cli.CLI, options *UpgradeOptions) *cobra.Command {
+	c := &upgradeCommand{
+		cli: cli,
+	}
+
+	cmd := &cobra.Command{
+		Use:         "upgrade [flags]",
+		Args:        cobra.NoArgs,
+		Short:       "Upgrade Backyards and its components",
+		Annotations: map[string]string{util.CommandGroupAnnotationKey: util.InstallCommand},
+		Long: `Upgrades Backyards and its installed components to the versions embedded into this CLI.
+
+The installed versions are read from the version label the CLI sets on every managed resource,
+the version of the Istio CR and the version reported by the Backyards API.
+Downgrades, major version changes and skipping more than one minor version are refused,
+unless the '--force' option is given, the changes of a refused upgrade can still be shown with the '--plan' option.
+
+The components are upgraded in order: cert-manager, Istio operator, Istio, Backyards, canary operator.
+Every step waits for its workloads to become ready before the next one starts, a failing step stops the upgrade.
+Components which are not installed are skipped.
+
+The authentication mode and the audit sink setting of the installed Backyards are kept,
+unless the corresponding options are given explicitly.
+
+The command can show the changes to the cluster without applying them with the '--plan' option.`,
+		Example: `  # Show the upgrade steps and the changes to the cluster.
+  backyards upgrade --plan
+
+  # Upgrade every installed component.
+  backyards upgrade`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := common.ValidateApplyFlags(options.applyOptions)
+			if err != nil {
+				return err
+			}
+
+			return c.run(cmd, options)
+		},
+	}
+
+	addBackyardsFlags(cmd.Flags(), options.InstallOptions)
+
+	cmd.Flags().BoolVar(&options.plan, "plan", options.plan, "Show the upgrade steps and the changes to the cluster without applying them")
+	cmd.Flags().BoolVar(&options.force, "force", options.force, "Upgrade even if the upgrade path is not supported")
+
+	return cmd
+}
+
+func (c *upgradeCommand) run(cmd *cobra.Command, options *UpgradeOptions) error {
+	cl, err := c.cli.GetK8sClient()
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s client")
+	}
+
+	err = c.keepInstalledSettings(cl, cmd, options)
+	if err != nil {
+		return err
+	}
+
+	steps, err := c.getSteps(cl, options)
+	if err != nil {
+		return err
+	}
+
+	blocked := false
+	outs := make([]UpgradeStepOut, 0, len(steps))
+	for _, step := range steps {
+		outs = append(outs, step.UpgradeStepOut)
+		blocked = blocked || step.blocked
+	}
+
+	if c.cli.OutputFormat() == output.OutputFormatTable && c.cli.Interactive() {
+		fmt.Fprintf(c.cli.Out(), "Upgrade steps\n\n")
+	}
+	err = showUpgradeSteps(c.cli, outs)
+	if err != nil {
+		return err
+	}
+	if c.cli.Interactive() {
+		fmt.Fprintln(c.cli.Out())
+	}
+
+	if options.plan {
+		if blocked && !options.force {
+			log.Warn("the upgrade path is not supported, the upgrade would only run with the '--force' option")
+		}
+		return c.runSteps(steps)
+	}
+
+	if blocked && !options.force {
+		return errors.New("the upgrade path is not supported, use the '--force' option to upgrade anyway")
+	}
+
+	if !c.cli.InteractiveTerminal() {
+		return c.runSteps(steps)
+	}
+
+	return c.cli.IfConfirmed("Do you want to proceed with the upgrade?", func() error {
+		return c.runSteps(steps)
+	})
+}
+
+func (c *upgradeCommand) runSteps(steps []upgradeStep) error {
+	for _, step := range steps {
+		if step.run == nil {
+			continue
+		}
+
+		err := step.run()
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "upgrade step failed", "component", step.Component)
+		}
+	}
+
+	return nil
+}
+
+func (c *upgradeCommand) getSteps(cl client.Client, options *UpgradeOptions) ([]upgradeStep, error) {
+	target := internalk8s.SanitizedVersion(c.cli.GetRootCommand().Version)
+	steps := make([]upgradeStep, 0)
+
+	// cert-manager
+	certManagerObjects, err := certmanager.GetDefaultObjects()
+	if err != nil {
+		return nil, err
+	}
+	step, err := newComponentUpgradeStep(cl, "cert-manager", certManagerObjects, target)
+	if err != nil {
+		return nil, err
+	}
+	if step.Action != upgradeActionSkip {
+		step.run = func() error {
+			scmdOptions := certmanager.NewInstallOptions()
+			scmdOptions.Plan = options.plan
+			scmdOptions.ImageRegistry = options.imageRegistry
+			scmdOptions.ApplyOptions = options.applyOptions
+			scmd := certmanager.NewInstallCommand(c.cli, scmdOptions)
+			return scmd.RunE(scmd, nil)
+		}
+	}
+	steps = append(steps, step)
+
+	// Istio operator and Istio, the Istio CR is applied only after the operator became ready
+	istioObjects, err := istio.GetDefaultObjects()
+	if err != nil {
+		return nil, err
+	}
+	operatorStep, err := newComponentUpgradeStep(cl, "Istio operator", istioObjects, target)
+	if err != nil {
+		return nil, err
+	}
+	istioStep, err := newIstioUpgradeStep(cl, istioObjects)
+	if err != nil {
+		return nil, err
+	}
+	if operatorStep.Action != upgradeActionSkip {
+		operatorStep.run = func() error {
+			scmdOptions := istio.NewInstallOptions()
+			scmdOptions.Plan = options.plan
+			scmdOptions.Upgrade = true
+			scmdOptions.ImageRegistry = options.imageRegistry
+			scmdOptions.ApplyOptions = options.applyOptions
+			scmd := istio.NewInstallCommand(c.cli, scmdOptions)
+			return scmd.RunE(scmd, nil)
+		}
+		if istioStep.Action == upgradeActionSkip {
+			istioStep.Action = "install"
+		}
+	} else if istioStep.Action != upgradeActionSkip {
+		istioStep.Action = upgradeActionSkip
+		istioStep.Notes = "Istio is not managed by Backyards"
+		istioStep.blocked = false
+	}
+	steps = append(steps, operatorStep, istioStep)
+
+	// Backyards
+	step, err = c.newBackyardsUpgradeStep(cl, options, target)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, step)
+
+	// canary operator
+	canaryObjects, err := canary.GetDefaultObjects()
+	if err != nil {
+		return nil, err
+	}
+	step, err = newComponentUpgradeStep(cl, "canary operator", canaryObjects, target)
+	if err != nil {
+		return nil, err
+	}
+	if step.Action != upgradeActionSkip {
+		step.run = func() error {
+			scmdOptions := canary.NewInstallOptions()
+			scmdOptions.Plan = options.plan
+			scmdOptions.ImageRegistry = options.imageRegistry
+			scmdOptions.ApplyOptions = options.applyOptions
+			scmd := canary.NewInstallCommand(c.cli, scmdOptions)
+			return scmd.RunE(scmd, nil)
+		}
+	}
+	steps = append(steps, step)
+
+	return steps, nil
+}
+
+func (c *upgradeCommand) newBackyardsUpgradeStep(cl client.Client, options *UpgradeOptions, target string) (upgradeStep, error) {
+	values, err := getValues(options.releaseName, options.istioNamespace, nil)
+	if err != nil {
+		return upgradeStep{}, err
+	}
+
+	objects, err := getBackyardsObjects(values, options.valueOverrides, c.cli)
+	if err != nil {
+		return upgradeStep{}, err
+	}
+
+	step, err := newComponentUpgradeStep(cl, "Backyards", objects, target)
+	if err != nil {
+		return step, err
+	}
+	if step.Action == upgradeActionSkip {
+		return step, errors.New("Backyards is not installed, use the 'backyards install' command to install it")
+	}
+
+	apiVersion := getAPIVersion(c.cli, versionEndpoint)
+	if apiVersion != defaultVersionString && internalk8s.SanitizedVersion(apiVersion) != step.Installed {
+		step.Notes = appendNote(step.Notes, fmt.Sprintf("API reports version %s", apiVersion))
+	}
+
+	ic := &installCommand{
+		cli: c.cli,
+	}
+	step.run = func() error {
+		err := ic.run(options.InstallOptions)
+		if err != nil {
+			return err
+		}
+
+		err = ic.runNodeExporterInstall(options.InstallOptions)
+		if err != nil {
+			return err
+		}
+
+		err = ic.removeStaleObjects(options.InstallOptions)
+		if err != nil {
+			return err
+		}
+
+		return ic.recordRelease(options.InstallOptions, release.UpgradeAction)
+	}
+
+	return step, nil
+}
+
+// keepInstalledSettings sets the authentication mode and the audit sink setting from the installed Backyards deployment,
+// unless those are explicitly given as flags
+func (c *upgradeCommand) keepInstalledSettings(cl client.Client, cmd *cobra.Command, options *UpgradeOptions) error {
+	var deployment appsv1.Deployment
+	err := cl.Get(context.Background(), types.NamespacedName{
+		Namespace: c.cli.GetPersistentConfig().Namespace(),
+		Name:      options.releaseName,
+	}, &deployment)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.WrapIf(err, "could not get Backyards deployment")
+	}
+
+	env := make(map[string]string)
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		for _, e := range container.Env {
+			env[e.Name] = e.Value
+		}
+	}
+
+	if !cmd.Flags().Changed("anonymous-auth") {
+		options.anonymousAuth = env["AUTH_MODE"] == string(anonymous)
+	}
+	if !cmd.Flags().Changed("enable-auditsink") {
+		_, options.enableAuditSink = env["AUDITSINK_MODE"]
+	}
+
+	return nil
+}
+
+// newComponentUpgradeStep reads the installed version of a component from the version label of its first workload
+func newComponentUpgradeStep(cl client.Client, component string, objects object.K8sObjects, target string) (upgradeStep, error) {
+	step := upgradeStep{
+		UpgradeStepOut: UpgradeStepOut{
+			Component: component,
+			Target:    target,
+			Action:    upgradeActionSkip,
+			Notes:     "not installed",
+		},
+	}
+
+	for _, obj := range objects {
+		if obj.Kind != "Deployment" && obj.Kind != "StatefulSet" {
+			continue
+		}
+
+		var actual unstructured.Unstructured
+		actual.SetGroupVersionKind(obj.GroupVersionKind())
+		err := cl.Get(context.Background(), types.NamespacedName{
+			Namespace: obj.Namespace,
+			Name:      obj.Name,
+		}, &actual)
+		if k8serrors.IsNotFound(err) {
+			return step, nil
+		}
+		if err != nil {
+			return step, errors.WrapIfWithDetails(err, "could not get resource", "component", component, "name", obj.Name)
+		}
+
+		version, ok := actual.GetLabels()[internalk8s.CLIVersionLabel]
+		if !ok {
+			step.Notes = "not managed by Backyards"
+			return step, nil
+		}
+
+		step.Installed = version
+		step.Notes = ""
+		setUpgradeAction(&step)
+
+		return step, nil
+	}
+
+	return step, nil
+}
+
+func newIstioUpgradeStep(cl client.Client, istioObjects object.K8sObjects) (upgradeStep, error) {
+	step := upgradeStep{
+		UpgradeStepOut: UpgradeStepOut{
+			Component: "Istio",
+			Action:    upgradeActionSkip,
+			Notes:     "not installed",
+		},
+	}
+
+	for _, obj := range istioObjects {
+		if obj.Kind == "Istio" {
+			step.Target, _, _ = unstructured.NestedString(obj.UnstructuredObject().Object, "spec", "version")
+		}
+	}
+
+	istioCR, err := istio.FetchIstioCR(cl)
+	if err != nil {
+		if err == istio.ErrIstioCRNotFound || k8sapimeta.IsNoMatchError(errors.Cause(err)) {
+			return step, nil
+		}
+		return step, errors.WrapIf(err, "could not get Istio CR")
+	}
+
+	step.Installed = string(istioCR.Spec.Version)
+	step.Notes = ""
+	setUpgradeAction(&step)
+
+	return step, nil
+}
+
+func setUpgradeAction(step *upgradeStep) {
+	step.Action = upgradeActionUpgrade
+	if step.Installed == step.Target {
+		step.Action = upgradeActionReapply
+	}
+
+	err := upgrade.CheckPath(step.Installed, step.Target, maxMinorVersionSkew)
+	switch {
+	case err == nil:
+	case errors.Is(err, upgrade.ErrUnknownVersion):
+		step.Notes = appendNote(step.Notes, "version skew not checked, unknown version")
+	default:
+		step.Notes = appendNote(step.Notes, err.Error())
+		step.blocked = true
+	}
+}
+
+func appendNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+
+	return notes + ", " + note
+}
+
+func showUpgradeSteps(cli cli.CLI, data interface{}) error {
+	ctx := &output.Context{
+		Out:     cli.Out(),
+		Color:   cli.Color(),
+		Format:  cli.OutputFormat(),
+		Fields:  []string{"Component", "Installed", "Target", "Action", "Notes"},
+		Headers: []string{"Component", "Installed", "Target", "Action", "Notes"},
+	}
+
+	err := output.Output(ctx, data)
+	if err != nil {
+		return errors.WrapIf(err, "could not produce output")
+	}
+
+	return nil
+}
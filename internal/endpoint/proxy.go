@@ -21,8 +21,8 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
 	"strings"
-	"time"
 
 	"emperror.dev/errors"
 	"github.com/sirupsen/logrus"
@@ -43,6 +43,8 @@ type proxyEndpoint struct {
 	baseURL string
 	service K8sService
 	ca      []byte
+	// socket is set if the proxy serves on a unix socket instead of a local port
+	socket string
 
 	srv *http.Server
 }
@@ -84,6 +86,42 @@ func NewProxyEndpoint(localPort int, cfg *rest.Config, service K8sService) (Endp
 	return ep, nil
 }
 
+// NewUnixSocketProxyEndpoint serves the proxy on a unix socket, which is only accessible by the current user
+func NewUnixSocketProxyEndpoint(socket string, cfg *rest.Config, service K8sService) (Endpoint, error) {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not listen on unix socket", "socket", socket)
+	}
+
+	err = os.Chmod(socket, 0600)
+	if err != nil {
+		listener.Close()
+		return nil, errors.WrapIfWithDetails(err, "could not restrict unix socket permissions", "socket", socket)
+	}
+
+	ep := &proxyEndpoint{
+		service: service,
+		baseURL: "http://" + unixSocketHost,
+		socket:  socket,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", ep.proxyToCluster(cfg))
+
+	ep.srv = &http.Server{
+		Handler: mux,
+	}
+
+	go func() {
+		err := ep.srv.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("failed to serve proxy on unix socket: %s", err)
+		}
+	}()
+
+	return ep, nil
+}
+
 func (e *proxyEndpoint) URLForPath(path string) string {
 	return fmt.Sprintf("%s%s", e.baseURL, path)
 }
@@ -93,6 +131,9 @@ func (e *proxyEndpoint) CA() []byte {
 }
 
 func (e *proxyEndpoint) HTTPClient() *http.Client {
+	if e.socket != "" {
+		return unixSocketClient(e.socket)
+	}
 	return withCa(e.ca)
 }
 
@@ -133,7 +174,8 @@ func NewK8sAPIProxy(cfg *rest.Config, proxyPath string) (http.Handler, error) {
 		PathPrepend:  proxyPath,
 		RoundTripper: transport,
 	}
-	proxy.FlushInterval = 200 * time.Millisecond
+	// flush every write, so that server-sent events and the rewritten responses are streamed
+	proxy.FlushInterval = -1
 
 	return http.Handler(proxy), nil
 }
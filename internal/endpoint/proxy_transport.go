@@ -24,8 +24,15 @@ import (
 	"strings"
 
 	"emperror.dev/errors"
+	"github.com/andybalholm/brotli"
 )
 
+// size of the chunks read from the response body while rewriting it
+const rewriteChunkSize = 32 * 1024
+
+// ReplaceTransport removes the path of the Kubernetes service proxy from redirects and HTML responses,
+// so that the UI works behind it. Protocol upgrades like websockets are passed through, and the HTML
+// responses are rewritten while streamed, without holding them in memory.
 type ReplaceTransport struct {
 	PathPrepend string
 
@@ -49,11 +56,20 @@ func (t *ReplaceTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		return resp, nil
 	}
 
+	// the body of an upgraded connection is the connection itself, the reverse proxy copies it both ways
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+
 	if redirect := resp.Header.Get("Location"); redirect != "" {
 		resp.Header.Set("Location", strings.Replace(redirect, t.PathPrepend, "", -1))
 		return resp, nil
 	}
 
+	if !hasBody(req, resp) {
+		return resp, nil
+	}
+
 	contentType := resp.Header.Get("Content-Type")
 	contentType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
 	if contentType != "text/html" {
@@ -63,55 +79,144 @@ func (t *ReplaceTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	return t.rewriteResponse(resp)
 }
 
-func (t *ReplaceTransport) rewriteHTML(reader io.Reader, writer io.Writer) error {
-	var err error
-
-	b, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return err
-	}
-
-	b = bytes.ReplaceAll(b, []byte(t.PathPrepend), nil)
-
-	_, err = writer.Write(b)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (t *ReplaceTransport) rewriteResponse(resp *http.Response) (*http.Response, error) {
 	origBody := resp.Body
-	defer origBody.Close()
 
-	newContent := &bytes.Buffer{}
-	var reader io.Reader = origBody
-	var writer io.Writer = newContent
-	encoding := resp.Header.Get("Content-Encoding")
-	switch encoding {
+	var reader io.Reader
+	var newWriter func(io.Writer) io.WriteCloser
+	switch resp.Header.Get("Content-Encoding") {
 	case "gzip":
-		var err error
-		reader, err = gzip.NewReader(reader)
+		gzr, err := gzip.NewReader(origBody)
 		if err != nil {
+			origBody.Close()
 			return nil, errors.WrapIf(err, "could not make gzip reader")
 		}
-		gzw := gzip.NewWriter(writer)
-		defer gzw.Close()
-		writer = gzw
+		reader = gzr
+		newWriter = func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		}
+	case "br":
+		reader = brotli.NewReader(origBody)
+		newWriter = func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriter(w)
+		}
 	case "":
+		reader = origBody
 	default:
 		return resp, nil
 	}
 
-	err := t.rewriteHTML(reader, writer)
-	if err != nil {
-		return resp, err
+	reader = newReplaceReader(reader, []byte(t.PathPrepend), nil)
+
+	if newWriter == nil {
+		resp.Body = &readCloser{Reader: reader, Closer: origBody}
+	} else {
+		// the rewritten content is compressed again as it is read by the reverse proxy
+		pr, pw := io.Pipe()
+		go func() {
+			defer origBody.Close()
+
+			writer := newWriter(pw)
+			_, err := io.Copy(writer, reader)
+			if cerr := writer.Close(); err == nil {
+				err = cerr
+			}
+			pw.CloseWithError(err)
+		}()
+		resp.Body = pr
 	}
 
-	resp.Body = ioutil.NopCloser(newContent)
 	resp.Header.Del("Content-Length")
-	resp.ContentLength = int64(newContent.Len())
+	resp.ContentLength = -1
+
+	return resp, nil
+}
+
+// hasBody returns whether the response may have a body to rewrite
+func hasBody(req *http.Request, resp *http.Response) bool {
+	if req.Method == http.MethodHead || resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+
+	return resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// replaceReader replaces the occurrences of old with new in the content of the underlying reader,
+// only the bytes that may be the beginning of a match spanning two reads are held back
+type replaceReader struct {
+	src      io.Reader
+	old, new []byte
+
+	chunk   []byte
+	pending []byte
+	out     bytes.Buffer
+	eof     bool
+}
+
+func newReplaceReader(src io.Reader, old, new []byte) io.Reader {
+	if len(old) == 0 {
+		return src
+	}
+
+	return &replaceReader{
+		src:   src,
+		old:   old,
+		new:   new,
+		chunk: make([]byte, rewriteChunkSize),
+	}
+}
+
+// Read implements the io.Reader interface
+func (r *replaceReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		err := r.fill()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return r.out.Read(p)
+}
+
+// fill reads the next chunk and moves the content which can not be part of a later match to the output
+func (r *replaceReader) fill() error {
+	n, err := r.src.Read(r.chunk)
+	r.pending = append(r.pending, r.chunk[:n]...)
+	if err == io.EOF {
+		r.eof = true
+	} else if err != nil {
+		return err
+	}
 
-	return resp, err
+	// a match starting before safe is complete in the pending content
+	safe := len(r.pending) - len(r.old) + 1
+	if r.eof {
+		safe = len(r.pending)
+	}
+
+	start := 0
+	for start < safe {
+		i := bytes.Index(r.pending[start:], r.old)
+		if i < 0 || start+i >= safe {
+			break
+		}
+		r.out.Write(r.pending[start : start+i])
+		r.out.Write(r.new)
+		start += i + len(r.old)
+	}
+	if start < safe {
+		r.out.Write(r.pending[start:safe])
+		start = safe
+	}
+	r.pending = append(r.pending[:0], r.pending[start:]...)
+
+	return nil
 }
@@ -0,0 +1,238 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/rest"
+)
+
+var testService = K8sService{Name: "backyards-ingressgateway", Namespace: "backyards-system", Port: 80}
+
+// newTestProxy serves the handler as the Kubernetes API server over HTTP/2 and returns a proxy to the test service
+func newTestProxy(t *testing.T, handler http.Handler) (*httptest.Server, func()) {
+	apiServer := httptest.NewUnstartedServer(handler)
+	apiServer.EnableHTTP2 = true
+	apiServer.StartTLS()
+
+	ep := &proxyEndpoint{service: testService}
+	proxy := httptest.NewServer(ep.proxyToCluster(&rest.Config{
+		Host:            apiServer.URL,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+	}))
+
+	return proxy, func() {
+		proxy.Close()
+		apiServer.Close()
+	}
+}
+
+// page returns an HTML page referring to assets behind the service proxy, large enough to be read in several chunks
+func page(prefix string) string {
+	var b strings.Builder
+	b.WriteString("<html><head>")
+	for i := 0; i < 2000; i++ {
+		b.WriteString(`<script src="` + prefix + `/static/app.js"></script>`)
+	}
+	b.WriteString("</head></html>")
+	return b.String()
+}
+
+func TestReplaceTransportWebsocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	proxy, closeProxy := newTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != testService.Path()+"/api/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(messageType, message)
+		}
+	}))
+	defer closeProxy()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(proxy.URL, "http")+"/api/graphql", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer conn.Close()
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"connection_init"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(message) != `{"type":"connection_init"}` {
+		t.Errorf("unexpected message: %s", message)
+	}
+}
+
+func TestReplaceTransportEncodings(t *testing.T) {
+	tests := map[string]struct {
+		encode func(io.Writer) io.WriteCloser
+		decode func(io.Reader) (io.Reader, error)
+	}{
+		"": {},
+		"gzip": {
+			encode: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+			decode: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		"br": {
+			encode: func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+			decode: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		},
+	}
+
+	for encoding, test := range tests {
+		test := test
+		t.Run("encoding "+encoding, func(t *testing.T) {
+			proxy, closeProxy := newTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				var body io.WriteCloser = nopWriteCloser{w}
+				if test.encode != nil {
+					w.Header().Set("Content-Encoding", encoding)
+					body = test.encode(w)
+				}
+				_, _ = io.WriteString(body, page(testService.Path()))
+				_ = body.Close()
+			}))
+			defer closeProxy()
+
+			req, err := http.NewRequest(http.MethodGet, proxy.URL+"/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the client decompresses the body only if it asked for the encoding implicitly
+			req.Header.Set("Accept-Encoding", "gzip, br")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.Header.Get("Content-Encoding") != encoding {
+				t.Errorf("unexpected encoding: %s", resp.Header.Get("Content-Encoding"))
+			}
+			var reader io.Reader = resp.Body
+			if test.decode != nil {
+				reader, err = test.decode(resp.Body)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			content, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(content) != page("") {
+				t.Errorf("the service proxy path is not removed from the content")
+			}
+		})
+	}
+}
+
+func TestReplaceTransportStreaming(t *testing.T) {
+	release := make(chan struct{})
+	proxy, closeProxy := newTestProxy(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		switch r.URL.Path {
+		case testService.Path() + "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: first\n\n")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, `<html><script src="`+testService.Path()+`/app.js"></script>`+strings.Repeat(" ", 100))
+		}
+		flusher.Flush()
+		<-release
+	}))
+	defer closeProxy()
+	defer close(release)
+
+	tests := map[string]string{
+		"/events":    "data: first",
+		"/dashboard": `<html><script src="/app.js"></script>`,
+	}
+	for path, expected := range tests {
+		// the beginning of the response is received while the server is still writing it
+		received := make(chan string, 1)
+		go func(path string, length int) {
+			resp, err := http.Get(proxy.URL + path)
+			if err != nil {
+				received <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			line := make([]byte, length)
+			_, _ = io.ReadFull(bufio.NewReader(resp.Body), line)
+			received <- string(line)
+		}(path, len(expected))
+
+		select {
+		case line := <-received:
+			if line != expected {
+				t.Errorf("unexpected content of %s: %s", path, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("the response of %s is not streamed", path)
+		}
+	}
+}
+
+func TestReplaceReader(t *testing.T) {
+	content := "/proxy/a /prox/proxy/b /proxy"
+	// reading byte by byte splits every match between reads
+	reader := newReplaceReader(iotest.OneByteReader(strings.NewReader(content)), []byte("/proxy"), nil)
+
+	result, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(result, []byte("/a /prox/b ")) {
+		t.Errorf("unexpected result: %q", result)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
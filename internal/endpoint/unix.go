@@ -0,0 +1,67 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+const (
+	// UnixSocketScheme prefixes the path of a unix socket given as an URL
+	UnixSocketScheme = "unix://"
+
+	// unixSocketHost is a placeholder host for the requests sent over a unix socket
+	unixSocketHost = "backyards"
+)
+
+type unixSocketEndpoint struct {
+	socket string
+}
+
+// NewUnixSocketEndpoint returns an endpoint connecting to a local proxy serving on a unix socket
+func NewUnixSocketEndpoint(socket string) Endpoint {
+	return &unixSocketEndpoint{
+		socket: socket,
+	}
+}
+
+func (e *unixSocketEndpoint) URLForPath(path string) string {
+	return fmt.Sprintf("http://%s%s", unixSocketHost, path)
+}
+
+func (e *unixSocketEndpoint) CA() []byte {
+	return nil
+}
+
+func (e *unixSocketEndpoint) HTTPClient() *http.Client {
+	return unixSocketClient(e.socket)
+}
+
+func (e *unixSocketEndpoint) Close() {
+}
+
+func unixSocketClient(socket string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
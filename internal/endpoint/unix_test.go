@@ -0,0 +1,70 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestUnixSocketProxyEndpoint(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer apiServer.Close()
+
+	dir, err := ioutil.TempDir("", "endpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "proxy.sock")
+	ep, err := NewUnixSocketProxyEndpoint(socket, &rest.Config{Host: apiServer.URL}, testService)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %s", err)
+	}
+	defer ep.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("the socket should only be accessible by the current user, got %s", info.Mode().Perm())
+	}
+
+	for _, client := range []Endpoint{ep, NewUnixSocketEndpoint(socket)} {
+		resp, err := client.HTTPClient().Get(client.URLForPath("/api/version"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := testService.Path() + "/api/version"; string(body) != expected {
+			t.Errorf("expected the request to be proxied to %s, got %s", expected, body)
+		}
+	}
+}
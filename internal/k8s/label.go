@@ -51,11 +51,12 @@ const (
 func NewLabelManager(interactive bool, version string) k8s.LabelManager {
 	return &labelManager{
 		interactive: interactive,
-		version:     sanitizedVersion(version),
+		version:     SanitizedVersion(version),
 	}
 }
 
-func sanitizedVersion(version string) string {
+// SanitizedVersion returns the version in a form usable as a label value
+func SanitizedVersion(version string) string {
 	re := regexp.MustCompile(`[^-A-Za-z0-9_.]`)
 	sanitizedVersion := re.ReplaceAllString(version, "_")
 	re = regexp.MustCompile(`^([^A-Za-z0-9])(.*)`)
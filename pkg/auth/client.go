@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"emperror.dev/errors"
+	"github.com/square/go-jose/v3/jwt"
 	"k8s.io/client-go/rest"
 
 	"github.com/banzaicloud/backyards-cli/pkg/servererror"
@@ -30,6 +31,9 @@ import (
 
 const defaultLoginTimeout = time.Second * 10
 
+// RenewalLeeway is how long before their expiry tokens are renewed, so that they do not expire while in use
+const RenewalLeeway = time.Minute
+
 type Client interface {
 	Login() (*Credentials, error)
 }
@@ -37,6 +41,8 @@ type Client interface {
 type client struct {
 	config *rest.Config
 	url    string
+	// token is sent instead of the credentials of the kubeconfig if set, e.g. an ID token of an external identity provider
+	token string
 }
 
 type AuthenticationMode string
@@ -55,6 +61,13 @@ type RequestBody struct {
 		// base64 encoded client cert
 		Cert string `json:"cert"`
 	} `json:"cert,omitempty"`
+	// Impersonate is the identity to act as, the given credentials must be authorized to impersonate it
+	Impersonate *Impersonation `json:"impersonate,omitempty"`
+}
+
+type Impersonation struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
 }
 
 type Credentials struct {
@@ -78,6 +91,15 @@ func NewClient(config *rest.Config, url string) Client {
 	}
 }
 
+// NewTokenClient returns a client which logs in with the given token, the config is only used to set up the transport
+func NewTokenClient(config *rest.Config, url string, token string) Client {
+	return &client{
+		config: config,
+		url:    url,
+		token:  token,
+	}
+}
+
 func (c *client) Login() (*Credentials, error) {
 	response, err := c.sendRequest(c.url)
 	if err != nil {
@@ -121,8 +143,20 @@ func (c *client) Login() (*Credentials, error) {
 
 func (c *client) requestBody() (*RequestBody, error) {
 	rb := &RequestBody{}
+	if c.config.Impersonate.UserName != "" || len(c.config.Impersonate.Groups) > 0 {
+		rb.Impersonate = &Impersonation{
+			User:   c.config.Impersonate.UserName,
+			Groups: c.config.Impersonate.Groups,
+		}
+	}
+	// a bearer token overridden with --kube-token is the only credential of the config, the client certificate of the
+	// kubeconfig is removed by client.GetConfigWithOverrides
 	// nolint ifElseChain
-	if c.config.TLSClientConfig.CertFile != "" && c.config.TLSClientConfig.KeyFile != "" {
+	if c.token != "" {
+		rb.Mode = TokenAuth
+		rb.Token = c.token
+		return rb, nil
+	} else if c.config.TLSClientConfig.CertFile != "" && c.config.TLSClientConfig.KeyFile != "" {
 		cert, err := ioutil.ReadFile(c.config.TLSClientConfig.CertFile)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to load client cert from %s", c.config.TLSClientConfig.CertFile)
@@ -159,7 +193,18 @@ func (c *client) requestBody() (*RequestBody, error) {
 }
 
 func (c *client) sendRequest(url string) (*http.Response, error) {
-	transport, err := rest.TransportFor(c.config)
+	config := c.config
+	if c.token != "" {
+		// the credentials of the kubeconfig are not sent along, exec plugins may not even work
+		config = &rest.Config{
+			TLSClientConfig: rest.TLSClientConfig{
+				Insecure: c.config.Insecure,
+				CAFile:   c.config.CAFile,
+				CAData:   c.config.CAData,
+			},
+		}
+	}
+	transport, err := rest.TransportFor(config)
 	if err != nil {
 		return nil, err
 	}
@@ -178,3 +223,65 @@ func (c *client) sendRequest(url string) (*http.Response, error) {
 	}
 	return httpClient.Post(url, "application/json", b)
 }
+
+// Expiry returns the expiry of the ID token
+func (c *Credentials) Expiry() (time.Time, error) {
+	return TokenExpiry(c.User.Token)
+}
+
+// TokenExpiry returns the expiry of a JWT token, the signature is not verified since it is checked by the server
+func TokenExpiry(token string) (time.Time, error) {
+	webToken, err := jwt.ParseSigned(token)
+	if err != nil {
+		return time.Time{}, errors.WrapIf(err, "failed to parse signed token")
+	}
+
+	claims := &jwt.Claims{}
+	err = webToken.UnsafeClaimsWithoutVerification(claims)
+	if err != nil {
+		return time.Time{}, errors.WrapIf(err, "failed to extract claims from token")
+	}
+	if claims.Expiry == nil {
+		return time.Time{}, nil
+	}
+
+	return claims.Expiry.Time(), nil
+}
+
+// TokenCredentials returns the credentials of the user the ID token was issued to, the signature is not verified
+func TokenCredentials(token string) (*Credentials, error) {
+	webToken, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to parse signed token")
+	}
+
+	claims := &struct {
+		jwt.Claims
+		Name   string   `json:"name"`
+		Groups []string `json:"groups"`
+	}{}
+	err = webToken.UnsafeClaimsWithoutVerification(claims)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to extract claims from token")
+	}
+
+	credentials := &Credentials{}
+	credentials.User.Name = claims.Name
+	if credentials.User.Name == "" {
+		credentials.User.Name = claims.Subject
+	}
+	credentials.User.Groups = claims.Groups
+	credentials.User.Token = token
+
+	return credentials, nil
+}
+
+// NeedsRenewal returns whether the token is expired or expires within the renewal leeway
+func NeedsRenewal(token string) bool {
+	expiry, err := TokenExpiry(token)
+	if err != nil {
+		return true
+	}
+
+	return !expiry.IsZero() && time.Now().Add(RenewalLeeway).After(expiry)
+}
@@ -0,0 +1,208 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/square/go-jose/v3"
+	"github.com/square/go-jose/v3/jwt"
+	"k8s.io/client-go/rest"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+func signedToken(t *testing.T, expiry time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{Subject: "admin", Expiry: jwt.NewNumericDate(expiry)}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestNeedsRenewal(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		renew bool
+	}{
+		{"valid", signedToken(t, time.Now().Add(time.Hour)), false},
+		{"about to expire", signedToken(t, time.Now().Add(RenewalLeeway/2)), true},
+		{"expired", signedToken(t, time.Now().Add(-time.Hour)), true},
+		{"invalid", "not-a-token", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if NeedsRenewal(test.token) != test.renew {
+				t.Errorf("expected renewal: %t", test.renew)
+			}
+		})
+	}
+}
+
+func TestTokenCredentials(t *testing.T) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err := jwt.Signed(signer).Claims(map[string]interface{}{
+		"sub":    "CgVhZG1pbg",
+		"name":   "admin",
+		"groups": []string{"system:masters"},
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		user   string
+		groups []string
+		err    bool
+	}{
+		{"name claim", named, "admin", []string{"system:masters"}, false},
+		{"subject", signedToken(t, time.Now().Add(time.Hour)), "admin", nil, false},
+		{"invalid", "not-a-token", "", nil, true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			credentials, err := TokenCredentials(test.token)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if credentials.User.Name != test.user || !reflect.DeepEqual(credentials.User.Groups, test.groups) {
+				t.Errorf("unexpected user: %s %v", credentials.User.Name, credentials.User.Groups)
+			}
+			if credentials.User.Token != test.token {
+				t.Error("token is not kept")
+			}
+		})
+	}
+}
+
+func TestTokenClientLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body RequestBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil || body.Mode != TokenAuth || body.Token != "id-token" || r.Header.Get("Authorization") != "" {
+			http.Error(w, `{"title":"invalid credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		credentials := Credentials{}
+		credentials.User.Name = "jane"
+		_ = json.NewEncoder(w).Encode(credentials)
+	}))
+	defer server.Close()
+
+	// the credentials of the kubeconfig are not sent when logging in with a token
+	config := &rest.Config{BearerToken: "kubeconfig-token"}
+
+	credentials, err := NewTokenClient(config, server.URL+"/api/login", "id-token").Login()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if credentials.User.Name != "jane" {
+		t.Errorf("unexpected user: %s", credentials.User.Name)
+	}
+}
+
+func TestLoginWithImpersonation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body RequestBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil || body.Mode != TokenAuth || body.Impersonate == nil {
+			http.Error(w, `{"title":"invalid credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		credentials := Credentials{}
+		credentials.User.Name = body.Impersonate.User
+		credentials.User.Groups = body.Impersonate.Groups
+		_ = json.NewEncoder(w).Encode(credentials)
+	}))
+	defer server.Close()
+
+	config := &rest.Config{
+		BearerToken: "admin-token",
+		Impersonate: rest.ImpersonationConfig{UserName: "tenant", Groups: []string{"tenants"}},
+	}
+
+	credentials, err := NewClient(config, server.URL+"/api/login").Login()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if credentials.User.Name != "tenant" || len(credentials.User.Groups) != 1 || credentials.User.Groups[0] != "tenants" {
+		t.Errorf("unexpected user: %+v", credentials.User)
+	}
+}
+
+func TestRequestBodyWithOverriddenToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfig := filepath.Join(dir, "config")
+	err = ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- cluster: {server: "https://127.0.0.1:6443"}
+  name: cluster
+contexts:
+- context: {cluster: cluster, user: admin}
+  name: admin
+current-context: admin
+users:
+- name: admin
+  user: {client-certificate-data: Y2VydA==, client-key-data: a2V5}
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := k8sclient.GetConfigWithOverrides(kubeconfig, "", k8sclient.AuthOverrides{Token: "override-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := NewClient(config, "").(*client).requestBody()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if body.Mode != TokenAuth || body.Token != "override-token" {
+		t.Errorf("the overridden token is not used: %+v", body)
+	}
+}
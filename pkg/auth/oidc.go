@@ -0,0 +1,334 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	deviceCodeGrantType       = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultDevicePollInterval = 5 * time.Second
+	callbackPath              = "/callback"
+)
+
+// OIDCConfig describes the OIDC client used to get an ID token from an external identity provider
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RedirectPort is the local port of the redirect URL of the authorization code flow, 0 selects a random port
+	RedirectPort int
+	HTTPClient   *http.Client
+}
+
+// OIDCProvider gets ID tokens through the authorization code flow with PKCE or through the device code flow
+type OIDCProvider struct {
+	config   OIDCConfig
+	metadata providerMetadata
+}
+
+type providerMetadata struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// DeviceAuthorization is shown to the user to authorize the CLI on another device
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// NewOIDCProvider discovers the endpoints of the issuer
+func NewOIDCProvider(ctx context.Context, config OIDCConfig) (*OIDCProvider, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: defaultLoginTimeout}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid"}
+	}
+
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequest(http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, errors.WrapIf(err, "failed to create discovery request")
+	}
+
+	response, err := config.HTTPClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "failed to discover OIDC issuer", "issuer", config.Issuer)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.NewWithDetails("failed to discover OIDC issuer", "issuer", config.Issuer, "status", response.Status)
+	}
+
+	provider := &OIDCProvider{
+		config: config,
+	}
+	err = json.NewDecoder(response.Body).Decode(&provider.metadata)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "invalid OIDC discovery document", "issuer", config.Issuer)
+	}
+	if provider.metadata.TokenEndpoint == "" {
+		return nil, errors.NewWithDetails("OIDC issuer has no token endpoint", "issuer", config.Issuer)
+	}
+
+	return provider, nil
+}
+
+// AuthCodeWithPKCE runs the authorization code flow with PKCE: it serves the redirect URL locally,
+// passes the authorization URL to open, and exchanges the received code for an ID token
+func (p *OIDCProvider) AuthCodeWithPKCE(ctx context.Context, open func(authURL string) error) (string, error) {
+	if p.metadata.AuthorizationEndpoint == "" {
+		return "", errors.NewWithDetails("OIDC issuer has no authorization endpoint", "issuer", p.config.Issuer)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", p.config.RedirectPort))
+	if err != nil {
+		return "", errors.WrapIf(err, "failed to listen for the OIDC redirect")
+	}
+	defer listener.Close()
+
+	redirectURL := fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := url.Parse(p.metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.WrapIf(err, "invalid authorization endpoint")
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != callbackPath {
+				http.NotFound(w, r)
+				return
+			}
+			query := r.URL.Query()
+			switch {
+			case query.Get("state") != state:
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			case query.Get("error") != "":
+				http.Error(w, "Login failed, you can close this window.", http.StatusUnauthorized)
+				errs <- errors.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+			default:
+				fmt.Fprintln(w, "Logged in, you can close this window.")
+				codes <- query.Get("code")
+			}
+		}),
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	err = open(authURL.String())
+	if err != nil {
+		return "", err
+	}
+
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return "", err
+	case <-ctx.Done():
+		return "", errors.WrapIf(ctx.Err(), "timed out waiting for the OIDC redirect")
+	}
+
+	return p.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+// DeviceCode runs the device code flow: it passes the user code and the verification URL to prompt,
+// and polls the token endpoint until the user authorizes the CLI
+func (p *OIDCProvider) DeviceCode(ctx context.Context, prompt func(*DeviceAuthorization)) (string, error) {
+	if p.metadata.DeviceAuthorizationEndpoint == "" {
+		return "", errors.NewWithDetails("OIDC issuer does not support the device code flow", "issuer", p.config.Issuer)
+	}
+
+	var authorization DeviceAuthorization
+	err := p.post(ctx, p.metadata.DeviceAuthorizationEndpoint, url.Values{
+		"scope": {strings.Join(p.config.Scopes, " ")},
+	}, &authorization)
+	if err != nil {
+		return "", errors.WrapIf(err, "device authorization failed")
+	}
+
+	prompt(&authorization)
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	if authorization.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(authorization.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return "", errors.WrapIf(ctx.Err(), "timed out waiting for the device authorization")
+		}
+
+		token, err := p.token(ctx, url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"device_code": {authorization.DeviceCode},
+		})
+		var oauthErr *oauthError
+		if errors.As(err, &oauthErr) {
+			switch oauthErr.code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += defaultDevicePollInterval
+				continue
+			}
+		}
+
+		return token, err
+	}
+}
+
+type oauthError struct {
+	code        string
+	description string
+}
+
+func (e *oauthError) Error() string {
+	if e.description != "" {
+		return e.code + ": " + e.description
+	}
+	return e.code
+}
+
+// token requests an ID token from the token endpoint
+func (p *OIDCProvider) token(ctx context.Context, values url.Values) (string, error) {
+	var response tokenResponse
+	err := p.post(ctx, p.metadata.TokenEndpoint, values, &response)
+	if err != nil {
+		return "", err
+	}
+	if response.IDToken == "" {
+		return "", errors.New("no ID token in the token response, check the openid scope")
+	}
+
+	return response.IDToken, nil
+}
+
+func (p *OIDCProvider) post(ctx context.Context, endpoint string, values url.Values, result interface{}) error {
+	values.Set("client_id", p.config.ClientID)
+	if p.config.ClientSecret != "" {
+		values.Set("client_secret", p.config.ClientSecret)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return errors.WrapIf(err, "failed to create request")
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.config.HTTPClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "request failed", "url", endpoint)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.WrapIf(err, "failed to read response body")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var errorResponse tokenResponse
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error != "" {
+			return errors.WithStack(&oauthError{code: errorResponse.Error, description: errorResponse.ErrorDescription})
+		}
+		return errors.NewWithDetails("request failed", "url", endpoint, "status", response.Status, "response", string(body))
+	}
+
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "invalid response", "response", string(body))
+	}
+
+	return nil
+}
+
+// randomString returns a random URL safe string usable as state and PKCE code verifier
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.WrapIf(err, "failed to generate random string")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
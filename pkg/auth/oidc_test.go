@@ -0,0 +1,162 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIssuer implements the discovery, authorization, device authorization and token endpoints of an OIDC issuer
+type mockIssuer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	challenges   map[string]string
+	pendingPolls int
+}
+
+func newMockIssuer() *mockIssuer {
+	issuer := &mockIssuer{
+		challenges:   make(map[string]string),
+		pendingPolls: 1,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(providerMetadata{
+			Issuer:                      issuer.URL,
+			AuthorizationEndpoint:       issuer.URL + "/authorize",
+			TokenEndpoint:               issuer.URL + "/token",
+			DeviceAuthorizationEndpoint: issuer.URL + "/device",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "PKCE is required", http.StatusBadRequest)
+			return
+		}
+		issuer.mu.Lock()
+		issuer.challenges["auth-code"] = query.Get("code_challenge")
+		issuer.mu.Unlock()
+
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"auth-code"}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(DeviceAuthorization{
+			DeviceCode:      "device-code",
+			UserCode:        "ABCD-EFGH",
+			VerificationURI: issuer.URL + "/activate",
+			ExpiresIn:       30,
+			Interval:        1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if issuer.challenges[r.Form.Get("code")] != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: "auth-code-id-token"})
+		case deviceCodeGrantType:
+			if issuer.pendingPolls > 0 {
+				issuer.pendingPolls--
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(tokenResponse{Error: "authorization_pending"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(tokenResponse{IDToken: "device-id-token"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(tokenResponse{Error: "unsupported_grant_type"})
+		}
+	})
+	issuer.Server = httptest.NewServer(mux)
+
+	return issuer
+}
+
+func TestOIDCAuthCodeWithPKCE(t *testing.T) {
+	issuer := newMockIssuer()
+	defer issuer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := NewOIDCProvider(ctx, OIDCConfig{Issuer: issuer.URL, ClientID: "backyards-cli"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the browser is simulated by following the redirects of the authorization endpoint
+	token, err := provider.AuthCodeWithPKCE(ctx, func(authURL string) error {
+		go func() {
+			resp, err := http.Get(authURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if token != "auth-code-id-token" {
+		t.Errorf("unexpected token: %s", token)
+	}
+}
+
+func TestOIDCDeviceCode(t *testing.T) {
+	issuer := newMockIssuer()
+	defer issuer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	provider, err := NewOIDCProvider(ctx, OIDCConfig{Issuer: issuer.URL, ClientID: "backyards-cli"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var userCode string
+	token, err := provider.DeviceCode(ctx, func(authorization *DeviceAuthorization) {
+		userCode = authorization.UserCode
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if userCode != "ABCD-EFGH" || token != "device-id-token" {
+		t.Errorf("unexpected user code %s or token %s", userCode, token)
+	}
+}
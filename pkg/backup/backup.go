@@ -0,0 +1,261 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	log "github.com/sirupsen/logrus"
+	"istio.io/operator/pkg/object"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+)
+
+const (
+	fileExtension = ".yaml"
+	timeFormat    = "20060102-150405.000"
+)
+
+var ErrBackupNotFound = errors.New("backup not found")
+
+// ManagedKinds are the kinds of the objects backed up if they carry an ownership label
+var ManagedKinds = []schema.GroupVersionKind{
+	{Group: "networking.istio.io", Version: "v1alpha3", Kind: "VirtualService"},
+	{Group: "networking.istio.io", Version: "v1alpha3", Kind: "DestinationRule"},
+	{Group: "networking.istio.io", Version: "v1alpha3", Kind: "Sidecar"},
+	{Group: "networking.istio.io", Version: "v1alpha3", Kind: "ServiceEntry"},
+	{Group: "authentication.istio.io", Version: "v1alpha1", Kind: "Policy"},
+	{Group: "security.istio.io", Version: "v1beta1", Kind: "PeerAuthentication"},
+}
+
+// IstioKind is the kind of the Istio CR which is always backed up
+var IstioKind = schema.GroupVersionKind{Group: "istio.banzaicloud.io", Version: "v1beta1", Kind: "Istio"}
+
+// OwnershipLabels are the labels marking the objects created through Backyards
+var OwnershipLabels = map[string]string{
+	// set by the CLI to the version of the CLI which created the object
+	internalk8s.CLIVersionLabel: "",
+	// set by Backyards to the objects created through its API
+	"app.kubernetes.io/managed-by": "backyards",
+}
+
+// Owned returns whether the object carries any of the ownership labels
+func Owned(obj *unstructured.Unstructured) bool {
+	labels := obj.GetLabels()
+	for key, value := range OwnershipLabels {
+		if actual, ok := labels[key]; ok && (value == "" || value == actual) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Collect returns the Istio CRs and the owned objects of the managed kinds from the cluster.
+// Kinds which are not served by the cluster are skipped.
+func Collect(cl client.Client) (object.K8sObjects, error) {
+	objects := make(object.K8sObjects, 0)
+	for _, gvk := range append([]schema.GroupVersionKind{IstioKind}, ManagedKinds...) {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+		err := cl.List(context.Background(), list)
+		if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+			log.Debugf("%s is not served by the cluster, skipping", gvk)
+			continue
+		}
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not list objects", "kind", gvk.String())
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if gvk != IstioKind && !Owned(obj) {
+				continue
+			}
+			objects = append(objects, object.NewK8sObject(cleanup(obj), nil, nil))
+		}
+	}
+
+	return objects, nil
+}
+
+// cleanup removes the server managed fields, so that the object can be applied to a cluster again
+func cleanup(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetSelfLink("")
+	obj.SetGeneration(0)
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, patch.LastAppliedConfig)
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+// Backup is a set of objects saved to a file
+type Backup struct {
+	Name    string
+	Path    string
+	Time    time.Time
+	Objects int
+}
+
+// Store keeps the backups as YAML manifests in a directory
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Save writes the objects to a new backup named after the current time
+func (s *Store) Save(objects object.K8sObjects, header string) (*Backup, error) {
+	manifest, err := objects.YAMLManifest()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not render backup manifest")
+	}
+
+	err = os.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not create backup directory", "dir", s.dir)
+	}
+
+	content := manifest
+	if header != "" {
+		content = "# " + strings.Replace(header, "\n", "\n# ", -1) + "\n" + manifest
+	}
+
+	// an existing backup is never overwritten, a backup saved within the same millisecond gets a suffix
+	now := time.Now()
+	base := "backup-" + now.UTC().Format(timeFormat)
+	name := base
+	path := filepath.Join(s.dir, name+fileExtension)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	for i := 1; os.IsExist(err); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+		path = filepath.Join(s.dir, name+fileExtension)
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not create backup", "path", path)
+	}
+
+	_, err = file.WriteString(content)
+	err = errors.Combine(err, file.Close())
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not write backup", "path", path)
+	}
+
+	return &Backup{
+		Name:    name,
+		Path:    path,
+		Time:    now,
+		Objects: len(objects),
+	}, nil
+}
+
+// List returns the backups ordered by creation time
+func (s *Store) List() ([]Backup, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read backup directory", "dir", s.dir)
+	}
+
+	backups := make([]Backup, 0)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != fileExtension {
+			continue
+		}
+
+		path := filepath.Join(s.dir, file.Name())
+		objects, err := readObjects(path)
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, Backup{
+			Name:    strings.TrimSuffix(file.Name(), fileExtension),
+			Path:    path,
+			Time:    file.ModTime(),
+			Objects: len(objects),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// Objects returns the objects of a backup given by its name or by the path of its file
+func (s *Store) Objects(name string) (object.K8sObjects, error) {
+	path := name
+	if _, err := os.Stat(path); err != nil {
+		path = filepath.Join(s.dir, name+fileExtension)
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, errors.WithDetails(ErrBackupNotFound, "name", name)
+	}
+
+	return readObjects(path)
+}
+
+func readObjects(path string) (object.K8sObjects, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read backup", "path", path)
+	}
+
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(string(content))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not parse backup", "path", path)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,173 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/banzaicloud/istio-client-go/pkg/networking/v1alpha3"
+	istiov1beta1 "github.com/banzaicloud/istio-operator/pkg/apis/istio/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+// schemeClient lists unstructured objects through their typed lists, since the fake client can not decode those,
+// and reports the kinds unknown to the scheme as not served, like the discovery based REST mapper does
+type schemeClient struct {
+	client.Client
+}
+
+func (c schemeClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOptionFunc) error {
+	gvk := list.GetObjectKind().GroupVersionKind()
+	typed, err := k8sclient.GetScheme().New(gvk)
+	if err != nil {
+		return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+	}
+
+	err = c.Client.List(ctx, typed, opts...)
+	if err != nil {
+		return err
+	}
+
+	items, err := meta.ExtractList(typed)
+	if err != nil {
+		return err
+	}
+
+	result := list.(*unstructured.UnstructuredList)
+	for _, item := range items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return err
+		}
+		obj := unstructured.Unstructured{Object: content}
+		obj.SetAPIVersion(gvk.GroupVersion().String())
+		obj.SetKind(strings.TrimSuffix(gvk.Kind, "List"))
+		result.Items = append(result.Items, obj)
+	}
+
+	return nil
+}
+
+func TestCollectAndRestore(t *testing.T) {
+	owned := map[string]string{internalk8s.CLIVersionLabel: "1.0.0"}
+	cl := schemeClient{fake.NewFakeClientWithScheme(k8sclient.GetScheme(),
+		&istiov1beta1.Istio{
+			ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "istio-system", ResourceVersion: "12", UID: "istio"},
+			Spec:       istiov1beta1.IstioSpec{Version: "1.4.0"},
+		},
+		&v1alpha3.VirtualService{
+			ObjectMeta: metav1.ObjectMeta{Name: "owned", Namespace: "demo", Labels: owned, ResourceVersion: "3"},
+		},
+		&v1alpha3.VirtualService{
+			ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: "demo"},
+		},
+		&v1alpha3.DestinationRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "demo", Labels: map[string]string{"app.kubernetes.io/managed-by": "backyards"}},
+		},
+	)}
+
+	objects, err := Collect(cl)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	names := make([]string, 0, len(objects))
+	for _, o := range objects {
+		names = append(names, o.Kind+"/"+o.Name)
+		if o.UnstructuredObject().GetResourceVersion() != "" || o.UnstructuredObject().GetUID() != "" {
+			t.Errorf("server managed fields should be removed from %s", o.Name)
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "DestinationRule/api,Istio/mesh,VirtualService/owned" {
+		t.Errorf("unexpected objects: %s", names)
+	}
+
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(dir)
+	backup, err := store.Save(objects, "test backup")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	backups, err := store.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(backups) != 1 || backups[0].Name != backup.Name || backups[0].Objects != 3 {
+		t.Errorf("unexpected backups: %+v", backups)
+	}
+
+	restored, err := store.Objects(backup.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(restored) != 3 {
+		t.Errorf("expected 3 objects, got %d", len(restored))
+	}
+
+	_, err = store.Objects("missing")
+	if err == nil {
+		t.Error("missing backups should not be found")
+	}
+}
+
+func TestSaveKeepsExistingBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(dir)
+	names := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		backup, err := store.Save(nil, fmt.Sprintf("backup %d", i))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if names[backup.Name] {
+			t.Fatalf("backup %s is overwritten", backup.Name)
+		}
+		names[backup.Name] = true
+	}
+
+	backups, err := store.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(backups) != 5 {
+		t.Errorf("expected 5 backups, got %+v", backups)
+	}
+}
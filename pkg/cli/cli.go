@@ -27,7 +27,6 @@ import (
 
 	"emperror.dev/errors"
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/square/go-jose/v3/jwt"
 	"k8s.io/client-go/util/homedir"
 
 	"github.com/hashicorp/go-hclog"
@@ -40,10 +39,23 @@ import (
 
 	"github.com/banzaicloud/backyards-cli/internal/endpoint"
 	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+	"github.com/banzaicloud/backyards-cli/pkg/auth"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s"
 	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
 	"github.com/banzaicloud/backyards-cli/pkg/k8s/portforward"
 	"github.com/banzaicloud/backyards-cli/pkg/output"
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
+)
+
+// service name of the secrets in the OS keyring
+const secretService = "backyards-cli"
+
+// viper keys of the request-scoped Kubernetes credentials given as root flags
+const (
+	ImpersonateKey          = "impersonate"
+	ImpersonateGroupsKey    = "impersonateGroups"
+	KubeTokenKey            = "kubeToken"
+	CertificateAuthorityKey = "certificateAuthority"
 )
 
 var (
@@ -79,6 +91,14 @@ type CLI interface {
 	// - local HTTP(s) proxy
 	InitializedEndpoint() (endpoint.Endpoint, error)
 	PersistentEndpoint() (endpoint.Endpoint, error)
+	// NewEndpoint creates an endpoint without reusing a running proxy daemon
+	NewEndpoint(localPort int) (endpoint.Endpoint, error)
+	// NewUnixSocketEndpoint creates a proxy endpoint serving on a unix socket
+	NewUnixSocketEndpoint(socket string) (endpoint.Endpoint, error)
+
+	ProxyFiles() (stateFile string, logFile string, err error)
+	ReadProxyState() (*ProxyState, error)
+	WriteProxyState(state *ProxyState) error
 
 	Initialize() error
 
@@ -103,7 +123,18 @@ func NewCli(out io.Writer, rootCmd *cobra.Command) CLI {
 }
 
 func (c *backyardsCLI) Initialize() error {
-	err := c.loadPersistentConfig()
+	err := c.loadPersistentGlobalConfig()
+	if err != nil {
+		return err
+	}
+	context, err := c.activeContext()
+	if err != nil {
+		return err
+	}
+	if context != nil {
+		useKubeContext(context)
+	}
+	err = c.loadPersistentConfig(context)
 	if err != nil {
 		return err
 	}
@@ -128,10 +159,6 @@ func (c *backyardsCLI) Initialize() error {
 			}
 		}
 	}
-	err = c.loadPersistentGlobalConfig()
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -189,6 +216,22 @@ func (c *backyardsCLI) getPortforwardForPod(podLabels map[string]string, namespa
 		return nil, err
 	}
 
+	reconnecting := false
+	pf.SetStateCallback(func(state portforward.State, pod string, err error) {
+		switch state {
+		case portforward.StateReconnecting:
+			if !reconnecting {
+				logrus.Warnf("lost port forward connection to %s/%s, reconnecting: %s", namespace, pod, err)
+			}
+			reconnecting = true
+		case portforward.StateConnected:
+			if reconnecting {
+				logrus.Infof("port forward reconnected to %s/%s", namespace, pod)
+			}
+			reconnecting = false
+		}
+	})
+
 	return pf, nil
 }
 
@@ -203,11 +246,11 @@ func (c *backyardsCLI) loadPersistentGlobalConfig() error {
 	}
 
 	PersistentGlobalSettings.Bind(v, c.rootCmd.Flags())
-	c.persistentGlobalConfig = newViperPersistentGlobalConfig(v)
+	c.persistentGlobalConfig = newViperPersistentGlobalConfig(v, newSecretStore())
 	return err
 }
 
-func (c *backyardsCLI) loadPersistentConfig() error {
+func (c *backyardsCLI) loadPersistentConfig(context *Context) error {
 	configFile, err := c.persistentConfigFile()
 	if err != nil {
 		return err
@@ -218,10 +261,22 @@ func (c *backyardsCLI) loadPersistentConfig() error {
 		return err
 	}
 	PersistentSettings.Bind(v, c.rootCmd.Flags())
-	c.persistentConfig = newViperPersistentConfig(v)
+
+	// the token of a context is saved under its own key, so that logging in updates the token of the context
+	secretKey := strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+	var settings map[string]interface{}
+	if context != nil {
+		settings = contextSettings(c.rootCmd.Flags(), context)
+		secretKey = context.secretKey()
+	}
+	c.persistentConfig = newViperPersistentConfig(v, newSecretStore(), secretKey, settings)
 	return nil
 }
 
+func newSecretStore() secret.Store {
+	return secret.New(secretService, filepath.Join(homedir.HomeDir(), ".banzai/backyards"))
+}
+
 func (c *backyardsCLI) persistentConfigFile() (string, error) {
 	if viper.GetString(PersistentConfigKey) != "" {
 		return viper.GetString(PersistentConfigKey), nil
@@ -261,7 +316,7 @@ func (c *backyardsCLI) GetK8sClient() (k8sclient.Client, error) {
 }
 
 func (c *backyardsCLI) GetK8sConfig() (*rest.Config, error) {
-	config, err := k8sclient.GetConfigWithContext(viper.GetString("kubeconfig"), viper.GetString("kubecontext"))
+	config, err := k8sclient.GetConfigWithOverrides(viper.GetString("kubeconfig"), viper.GetString("kubecontext"), AuthOverrides())
 	if err != nil {
 		return nil, errors.WrapIf(err, "could not get k8s config")
 	}
@@ -269,6 +324,16 @@ func (c *backyardsCLI) GetK8sConfig() (*rest.Config, error) {
 	return config, nil
 }
 
+// AuthOverrides returns the credentials given by the request-scoped root flags
+func AuthOverrides() k8sclient.AuthOverrides {
+	return k8sclient.AuthOverrides{
+		Impersonate:          viper.GetString(ImpersonateKey),
+		ImpersonateGroups:    viper.GetStringSlice(ImpersonateGroupsKey),
+		Token:                viper.GetString(KubeTokenKey),
+		CertificateAuthority: viper.GetString(CertificateAuthorityKey),
+	}
+}
+
 func (c *backyardsCLI) LabelManager() k8s.LabelManager {
 	c.lmOnce.Do(func() {
 		c.labelManager = internalk8s.NewLabelManager(c.InteractiveTerminal(), c.GetRootCommand().Version)
@@ -277,11 +342,12 @@ func (c *backyardsCLI) LabelManager() k8s.LabelManager {
 }
 
 func (c *backyardsCLI) InitializedEndpoint() (endpoint.Endpoint, error) {
-	return c.endpoint(0)
+	return c.newEndpoint(0, reuseAnyDaemon)
 }
 
+// PersistentEndpoint returns an endpoint which can be opened in a browser, so a proxy daemon on a unix socket is not reused
 func (c *backyardsCLI) PersistentEndpoint() (endpoint.Endpoint, error) {
-	return c.endpoint(defaultLocalEndpointPort)
+	return c.newEndpoint(defaultLocalEndpointPort, reusePortDaemon)
 }
 
 func (c *backyardsCLI) withHealthCheck(ep endpoint.Endpoint) (endpoint.Endpoint, error) {
@@ -307,13 +373,56 @@ func (c *backyardsCLI) withHealthCheck(ep endpoint.Endpoint) (endpoint.Endpoint,
 	return ep, nil
 }
 
-func (c *backyardsCLI) endpoint(persistentPort int) (endpoint.Endpoint, error) {
+func (c *backyardsCLI) NewEndpoint(localPort int) (endpoint.Endpoint, error) {
+	return c.newEndpoint(localPort, reuseNoDaemon)
+}
+
+func (c *backyardsCLI) NewUnixSocketEndpoint(socket string) (endpoint.Endpoint, error) {
+	if c.persistentConfig.BaseURL() != "" || c.persistentConfig.UsePortForward() {
+		return nil, errors.New("only the proxy to the Kubernetes API can be served on a unix socket, it cannot be used with a base URL or port forwarding")
+	}
+
+	cfg, err := c.GetK8sConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ep, err := endpoint.NewUnixSocketProxyEndpoint(socket, cfg, endpoint.K8sService{
+		Name:      BackyardsIngressServiceName,
+		Namespace: c.persistentConfig.Namespace(),
+		Port:      80,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.withHealthCheck(ep)
+}
+
+// daemonReuse selects which running proxy daemons an endpoint can reuse
+type daemonReuse int
+
+const (
+	reuseNoDaemon daemonReuse = iota
+	reuseAnyDaemon
+	// reusePortDaemon skips a daemon serving on a unix socket, which is not reachable from a browser
+	reusePortDaemon
+)
+
+func (c *backyardsCLI) newEndpoint(persistentPort int, reuse daemonReuse) (endpoint.Endpoint, error) {
 	url := c.persistentConfig.BaseURL()
 	ca, err := c.getEndpointCA()
 	if err != nil {
 		return nil, err
 	}
 	if url == "" {
+		// an explicitly given local port or identity is not served by the proxy daemon
+		if reuse != reuseNoDaemon && c.persistentConfig.LocalPort() == -1 && AuthOverrides().Empty() {
+			if ep := c.proxyDaemonEndpoint(ca, reuse == reuseAnyDaemon); ep != nil {
+				return c.withHealthCheck(ep)
+			}
+		}
+
 		cfg, err := c.GetK8sConfig()
 		if err != nil {
 			return nil, err
@@ -359,22 +468,14 @@ func (c *backyardsCLI) getEndpointCA() ([]byte, error) {
 }
 
 func (c *backyardsCLI) GetToken() string {
+	// the saved token belongs to the identity of the kubeconfig, an overridden one has to log in
+	if !AuthOverrides().Empty() {
+		return ""
+	}
 	token := c.persistentConfig.Token()
-	if token != "" {
-		claims := &jwt.Claims{}
-		webToken, err := jwt.ParseSigned(token)
-		if err != nil {
-			logrus.Error("Failed to parse signed token", err)
-			return ""
-		}
-		if err := webToken.UnsafeClaimsWithoutVerification(claims); err != nil {
-			logrus.Error("Failed to extract claims from token", err)
-			return ""
-		}
-		if err := claims.ValidateWithLeeway(jwt.Expected{}.WithTime(time.Now()), 0); err != nil {
-			logrus.Debug("Token expired")
-			return ""
-		}
+	if token != "" && auth.NeedsRenewal(token) {
+		logrus.Debug("Token expired or about to expire")
+		return ""
 	}
 	return token
 }
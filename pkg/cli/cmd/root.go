@@ -34,6 +34,7 @@ import (
 	"github.com/banzaicloud/backyards-cli/cmd/backyards/static/licenses"
 
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/backup"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/canary"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/certmanager"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/config"
@@ -42,6 +43,7 @@ import (
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/istio"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/login"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/mtls"
+	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/proxy"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/routing"
 	"github.com/banzaicloud/backyards-cli/internal/cli/cmd/sidecarproxy"
 	"github.com/banzaicloud/backyards-cli/pkg/cli"
@@ -122,6 +124,15 @@ func init() {
 	_ = viper.BindPFlag("kubeconfig", flags.Lookup("kubeconfig"))
 	flags.StringVar(&kubeContext, "context", "", "name of the kubeconfig context to use")
 	_ = viper.BindPFlag("kubecontext", flags.Lookup("context"))
+	flags.String("as", "", "username to impersonate for the Kubernetes and Backyards API requests")
+	_ = viper.BindPFlag(cli.ImpersonateKey, flags.Lookup("as"))
+	flags.StringSlice("as-group", nil, "group to impersonate for the Kubernetes and Backyards API requests, can be repeated")
+	_ = viper.BindPFlag(cli.ImpersonateGroupsKey, flags.Lookup("as-group"))
+	// kubectl's --token is renamed, --token is taken by the Backyards authentication token
+	flags.String("kube-token", "", "bearer token for authentication to the Kubernetes API server, replaces the credentials of the kubeconfig (kubectl's --token, renamed since --token is the Backyards token)")
+	_ = viper.BindPFlag(cli.KubeTokenKey, flags.Lookup("kube-token"))
+	flags.String("certificate-authority", "", "path to a cert file for the Kubernetes API server certificate authority")
+	_ = viper.BindPFlag(cli.CertificateAuthorityKey, flags.Lookup("certificate-authority"))
 	flags.BoolVarP(&verbose, "verbose", "v", false, "turn on debug logging")
 	_ = viper.BindPFlag("verbose", flags.Lookup("verbose"))
 
@@ -139,6 +150,10 @@ func init() {
 	flags.String("persistent-config-file", "", "Backyards persistent config file to use instead of the default at ~/.banzai/backyards/")
 	_ = viper.BindPFlag(cli.PersistentConfigKey, flags.Lookup("persistent-config-file"))
 
+	flags.String("backyards-context", "", "name of the Backyards context to use instead of the current one [$BACKYARDS_CONTEXT]")
+	_ = viper.BindPFlag(cli.ContextKey, flags.Lookup("backyards-context"))
+	_ = viper.BindEnv(cli.ContextKey, "BACKYARDS_CONTEXT")
+
 	flags.Bool("accept-license", false, fmt.Sprintf("Accept the license: %s", LicenseLink))
 
 	cli.PersistentSettings.Configure(flags)
@@ -151,7 +166,18 @@ func init() {
 		return *trackingID
 	})))
 	RootCmd.AddCommand(cmd.NewUninstallCommand(cliRef))
+	RootCmd.AddCommand(cmd.NewUpgradeCommand(cliRef, cmd.NewUpgradeOptions(cmd.NewInstallOptions(func() string {
+		return *trackingID
+	}))))
+	RootCmd.AddCommand(cmd.NewImagesCommand(cliRef))
+	RootCmd.AddCommand(cmd.NewCheckCommand(cliRef))
+	RootCmd.AddCommand(cmd.NewHistoryCommand(cliRef))
+	RootCmd.AddCommand(cmd.NewRollbackCommand(cliRef))
+	RootCmd.AddCommand(backup.NewRootCmd(cliRef))
 	RootCmd.AddCommand(cmd.NewDashboardCommand(cliRef, cmd.NewDashboardOptions()))
+	RootCmd.AddCommand(proxy.NewRootCmd(cliRef))
+	RootCmd.AddCommand(cmd.NewPortForwardCommand(cliRef, cmd.NewPortForwardOptions()))
+	RootCmd.AddCommand(cmd.NewQueryCommand(cliRef, cmd.NewQueryOptions()))
 	RootCmd.AddCommand(istio.NewRootCmd(cliRef))
 	RootCmd.AddCommand(canary.NewRootCmd(cliRef))
 	RootCmd.AddCommand(demoapp.NewRootCmd(cliRef))
@@ -159,6 +185,8 @@ func init() {
 	RootCmd.AddCommand(certmanager.NewRootCmd(cliRef))
 	RootCmd.AddCommand(graph.NewGraphCmd(cliRef, "base.json"))
 	RootCmd.AddCommand(login.NewLoginCmd(cliRef))
+	RootCmd.AddCommand(login.NewLogoutCmd(cliRef))
+	RootCmd.AddCommand(login.NewWhoamiCmd(cliRef))
 	RootCmd.AddCommand(config.NewConfigCmd(cliRef))
 	RootCmd.AddCommand(sidecarproxy.NewRootCmd(cliRef))
 	RootCmd.AddCommand(mtls.NewRootCmd(cliRef))
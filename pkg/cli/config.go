@@ -15,7 +15,18 @@
 package cli
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+
+	"github.com/banzaicloud/backyards-cli/pkg/auth"
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
 )
 
 const (
@@ -32,9 +43,14 @@ type PersistentConfig interface {
 	Token() string
 
 	SetTrackingClientID(string)
-	SetToken(string)
+	SetToken(string) error
+
+	Get(key string) interface{}
+	Set(key string, value interface{}) error
+	Unset(key string) error
 
 	PersistConfig() error
+	Reload() error
 
 	GetConfigFileUsed() string
 }
@@ -42,25 +58,35 @@ type PersistentConfig interface {
 type viperPersistentConfig struct {
 	viper   *viper.Viper
 	changed map[string]interface{}
+
+	// the token received at login is kept in the secret store under the secret key
+	secrets   secret.Store
+	secretKey string
+
+	// context holds the settings of the Backyards context in use, those are not written to the config file
+	context map[string]interface{}
 }
 
-func newViperPersistentConfig(persistentConfig *viper.Viper) PersistentConfig {
+func newViperPersistentConfig(persistentConfig *viper.Viper, secrets secret.Store, secretKey string, context map[string]interface{}) PersistentConfig {
 	return &viperPersistentConfig{
-		viper:   persistentConfig,
-		changed: make(map[string]interface{}),
+		viper:     persistentConfig,
+		changed:   make(map[string]interface{}),
+		secrets:   secrets,
+		secretKey: secretKey,
+		context:   context,
 	}
 }
 
 func (b *viperPersistentConfig) Namespace() string {
-	return b.viper.GetString(Namespace)
+	return cast.ToString(b.get(Namespace))
 }
 
 func (b *viperPersistentConfig) BaseURL() string {
-	return b.viper.GetString(URL)
+	return cast.ToString(b.get(URL))
 }
 
 func (b *viperPersistentConfig) CACert() string {
-	return b.viper.GetString(CACert)
+	return cast.ToString(b.get(CACert))
 }
 
 func (b *viperPersistentConfig) LocalPort() int {
@@ -75,16 +101,131 @@ func (b *viperPersistentConfig) TrackingClientID() string {
 	return b.viper.GetString(TrackingClientID)
 }
 
+// Token returns the explicitly configured token, or the one saved at login if the configured one is expired,
+// otherwise logging in again would not have any effect
 func (b *viperPersistentConfig) Token() string {
-	return b.viper.GetString(Token)
+	token := b.viper.GetString(Token)
+	if token != "" && !auth.NeedsRenewal(token) {
+		return token
+	}
+
+	saved, err := b.secrets.Get(b.secretKey)
+	if err != nil {
+		if !errors.Is(err, secret.ErrNotFound) {
+			logrus.Errorf("could not read token from %s: %s", b.secrets.Name(), err)
+		}
+		return token
+	}
+
+	return saved
 }
 
 func (b *viperPersistentConfig) SetTrackingClientID(clientID string) {
 	b.set(TrackingClientID, clientID)
 }
 
-func (b *viperPersistentConfig) SetToken(token string) {
-	b.set(Token, token)
+// SetToken saves the token to the secret store, an empty token removes it
+func (b *viperPersistentConfig) SetToken(token string) error {
+	// tokens were saved to the config file in plain text earlier
+	if b.viper.InConfig(Token) {
+		b.set(Token, "")
+	}
+
+	if token == "" {
+		return b.secrets.Delete(b.secretKey)
+	}
+
+	err := b.secrets.Set(b.secretKey, token)
+	if err != nil {
+		return errors.WrapIff(err, "could not save token to %s", b.secrets.Name())
+	}
+
+	return nil
+}
+
+func (b *viperPersistentConfig) Get(key string) interface{} {
+	if key == Token {
+		return b.Token()
+	}
+
+	return b.get(key)
+}
+
+// get returns the setting of the context in use, or the one of the config file
+func (b *viperPersistentConfig) get(key string) interface{} {
+	if value, ok := b.context[key]; ok {
+		return value
+	}
+
+	return b.viper.Get(key)
+}
+
+func (b *viperPersistentConfig) Set(key string, value interface{}) error {
+	if key == Token {
+		return b.SetToken(cast.ToString(value))
+	}
+
+	b.set(key, value)
+
+	return nil
+}
+
+// Unset removes the key from the config file, so that its default takes effect again
+func (b *viperPersistentConfig) Unset(key string) error {
+	file := b.viper.ConfigFileUsed()
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not read config file", "file", file)
+	}
+
+	var config map[string]interface{}
+	err = yaml.Unmarshal(content, &config)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not parse config file", "file", file)
+	}
+
+	if !unsetNested(config, strings.Split(key, ".")) {
+		return nil
+	}
+
+	content, err = yaml.Marshal(config)
+	if err != nil {
+		return errors.WrapIf(err, "could not marshal config")
+	}
+	err = ioutil.WriteFile(file, content, 0600)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write config file", "file", file)
+	}
+
+	return b.Reload()
+}
+
+// unsetNested removes the path from the nested YAML maps, keys are matched case insensitively like viper does
+func unsetNested(config map[string]interface{}, path []string) bool {
+	for key, value := range config {
+		if !strings.EqualFold(key, path[0]) {
+			continue
+		}
+		if len(path) == 1 {
+			delete(config, key)
+			return true
+		}
+		if nested, ok := value.(map[interface{}]interface{}); ok {
+			converted := make(map[string]interface{}, len(nested))
+			for k, v := range nested {
+				converted[cast.ToString(k)] = v
+			}
+			if unsetNested(converted, path[1:]) {
+				config[key] = converted
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 func (b *viperPersistentConfig) set(key string, value interface{}) {
@@ -104,6 +245,22 @@ func (b *viperPersistentConfig) PersistConfig() error {
 	return nil
 }
 
+// Reload reads the config file again and drops the pending changes, so that a modified file is not overwritten
+func (b *viperPersistentConfig) Reload() error {
+	b.changed = make(map[string]interface{})
+
+	err := b.viper.ReadInConfig()
+	if err != nil {
+		var osPathError *os.PathError
+		if errors.As(err, &osPathError) {
+			return nil
+		}
+		return errors.WrapIf(err, "could not read config file")
+	}
+
+	return nil
+}
+
 func (b *viperPersistentConfig) GetConfigFileUsed() string {
 	return b.viper.ConfigFileUsed()
 }
@@ -0,0 +1,72 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/square/go-jose/v3"
+	"github.com/square/go-jose/v3/jwt"
+
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
+)
+
+func signedToken(t *testing.T, expiry time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{Expiry: jwt.NewNumericDate(expiry)}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secrets := secret.NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key"))
+	saved := signedToken(t, time.Now().Add(time.Hour))
+	err = secrets.Set("cluster", saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := viper.New()
+	config := newViperPersistentConfig(v, secrets, "cluster", nil)
+
+	valid := signedToken(t, time.Now().Add(time.Hour))
+	v.Set(Token, valid)
+	if config.Token() != valid {
+		t.Error("a valid token given explicitly should be used")
+	}
+
+	v.Set(Token, signedToken(t, time.Now().Add(-time.Hour)))
+	if config.Token() != saved {
+		t.Error("the token saved at login should be used instead of an expired one given explicitly")
+	}
+}
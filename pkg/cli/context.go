@@ -0,0 +1,247 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"sort"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
+)
+
+const (
+	// ContextKey is the viper key of the Backyards context selected by flag or environment variable
+	ContextKey = "backyardsContext"
+
+	Contexts       = "contexts"
+	CurrentContext = "currentContext"
+)
+
+var ErrContextNotFound = errors.New("context not found")
+
+// Context binds a kubeconfig context to a Backyards installation
+type Context struct {
+	Name        string `mapstructure:"name" json:"name"`
+	KubeContext string `mapstructure:"kubeContext" json:"kubeContext"`
+	Namespace   string `mapstructure:"namespace,omitempty" json:"namespace,omitempty"`
+	URL         string `mapstructure:"url,omitempty" json:"url,omitempty"`
+	CACert      string `mapstructure:"cacert,omitempty" json:"cacert,omitempty"`
+	// Token is kept in the secret store instead of the config file
+	Token string `mapstructure:"-" json:"token,omitempty"`
+}
+
+// settings returns the persistent settings overridden by the context
+func (c Context) settings() map[string]string {
+	return map[string]string{
+		Namespace: c.Namespace,
+		URL:       c.URL,
+		CACert:    c.CACert,
+	}
+}
+
+// secretKey is the key of the token of the context in the secret store
+func (c Context) secretKey() string {
+	return "context-" + c.Name
+}
+
+func (v *viperPersistentGlobalConfig) Contexts() ([]Context, error) {
+	var contexts []Context
+	err := v.viper.UnmarshalKey(Contexts, &contexts)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not parse contexts")
+	}
+
+	for i, c := range contexts {
+		token, err := v.secrets.Get(c.secretKey())
+		if err != nil && !errors.Is(err, secret.ErrNotFound) {
+			return nil, errors.WrapIff(err, "could not read token of context %s from %s", c.Name, v.secrets.Name())
+		}
+		contexts[i].Token = token
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+
+	return contexts, nil
+}
+
+func (v *viperPersistentGlobalConfig) GetContext(name string) (*Context, error) {
+	contexts, err := v.Contexts()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range contexts {
+		if c.Name == name {
+			return &c, nil
+		}
+	}
+
+	return nil, errors.WrapIff(ErrContextNotFound, "%s", name)
+}
+
+func (v *viperPersistentGlobalConfig) SetContext(context Context) error {
+	contexts, err := v.Contexts()
+	if err != nil {
+		return err
+	}
+
+	result := []Context{context}
+	for _, c := range contexts {
+		if c.Name != context.Name {
+			result = append(result, c)
+		}
+	}
+
+	return v.setContexts(result)
+}
+
+func (v *viperPersistentGlobalConfig) DeleteContext(name string) error {
+	contexts, err := v.Contexts()
+	if err != nil {
+		return err
+	}
+
+	result := make([]Context, 0, len(contexts))
+	for _, c := range contexts {
+		if c.Name != name {
+			result = append(result, c)
+		}
+	}
+	if len(result) == len(contexts) {
+		return errors.WrapIff(ErrContextNotFound, "%s", name)
+	}
+
+	if v.CurrentContext() == name {
+		v.viper.Set(CurrentContext, "")
+	}
+
+	err = v.secrets.Delete(Context{Name: name}.secretKey())
+	if err != nil {
+		return errors.WrapIff(err, "could not delete token of context %s from %s", name, v.secrets.Name())
+	}
+
+	return v.setContexts(result)
+}
+
+// setContexts stores the contexts as plain maps, so that they are written to the config file with their own keys,
+// the tokens are saved to the secret store
+func (v *viperPersistentGlobalConfig) setContexts(contexts []Context) error {
+	values := make([]map[string]interface{}, 0, len(contexts))
+	for _, c := range contexts {
+		value := map[string]interface{}{
+			"name":        c.Name,
+			"kubeContext": c.KubeContext,
+		}
+		for key, setting := range map[string]string{"namespace": c.Namespace, "url": c.URL, "cacert": c.CACert} {
+			if setting != "" {
+				value[key] = setting
+			}
+		}
+		values = append(values, value)
+
+		if c.Token != "" {
+			err := v.secrets.Set(c.secretKey(), c.Token)
+			if err != nil {
+				return errors.WrapIff(err, "could not save token of context %s to %s", c.Name, v.secrets.Name())
+			}
+		}
+	}
+	v.viper.Set(Contexts, values)
+
+	return nil
+}
+
+func (v *viperPersistentGlobalConfig) CurrentContext() string {
+	return v.viper.GetString(CurrentContext)
+}
+
+func (v *viperPersistentGlobalConfig) UseContext(name string) error {
+	if name != "" {
+		if _, err := v.GetContext(name); err != nil {
+			return err
+		}
+	}
+	v.viper.Set(CurrentContext, name)
+
+	return nil
+}
+
+// activeContext returns the context selected by flag or environment variable, or the current context
+func (c *backyardsCLI) activeContext() (*Context, error) {
+	name := viper.GetString(ContextKey)
+	if name == "" {
+		name = c.persistentGlobalConfig.CurrentContext()
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	context, err := c.persistentGlobalConfig.GetContext(name)
+	if errors.Is(err, ErrContextNotFound) {
+		return nil, errors.Errorf("context %s not found, use the 'backyards config context list' command to list the contexts", name)
+	}
+
+	return context, err
+}
+
+// useKubeContext selects the kubeconfig context of the Backyards context, unless a kubeconfig context is given explicitly
+func useKubeContext(context *Context) {
+	if viper.GetString("kubecontext") == "" {
+		viper.Set("kubecontext", context.KubeContext)
+	}
+}
+
+// contextSettings returns the persistent settings overridden by the context,
+// the settings given explicitly by flags or environment variables are left out
+func contextSettings(flags *flag.FlagSet, context *Context) map[string]interface{} {
+	settings := make(map[string]interface{})
+	for key, value := range context.settings() {
+		if value == "" {
+			continue
+		}
+		setting := PersistentSettings[key]
+		if setting.Flag != "" && flags.Changed(setting.Flag) {
+			continue
+		}
+		if setting.Env != "" && os.Getenv(setting.Env) != "" {
+			continue
+		}
+		settings[key] = value
+	}
+
+	logrus.Debugf("using context %s", context.Name)
+
+	return settings
+}
+
+// KubeContextExists checks whether the kubeconfig has the given context
+func KubeContextExists(name string) (bool, error) {
+	config, err := client.GetRawConfig(viper.GetString("kubeconfig"), "")
+	if err != nil {
+		return false, errors.WrapIf(err, "failed to get raw kubernetes config")
+	}
+
+	_, ok := config.Contexts[name]
+
+	return ok, nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
+)
+
+func TestContexts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secrets := secret.NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key"))
+	v, err := createViper(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := newViperPersistentGlobalConfig(v, secrets)
+
+	err = config.SetContext(Context{Name: "prod", KubeContext: "prod-cluster", Namespace: "backyards", Token: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = config.SetContext(Context{Name: "dev", KubeContext: "dev-cluster"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = config.UseContext("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = config.PersistConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Errorf("the token of the context should not be written to the config file:\n%s", content)
+	}
+
+	v, err = createViper(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = newViperPersistentGlobalConfig(v, secrets)
+
+	contexts, err := config.Contexts()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(contexts) != 2 || contexts[0].Name != "dev" || contexts[1].Token != "secret" {
+		t.Errorf("unexpected contexts: %+v", contexts)
+	}
+	if config.CurrentContext() != "prod" {
+		t.Errorf("unexpected current context: %s", config.CurrentContext())
+	}
+
+	if err := config.UseContext("missing"); !errors.Is(err, ErrContextNotFound) {
+		t.Errorf("switching to a missing context should fail, got %v", err)
+	}
+
+	err = config.DeleteContext("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.CurrentContext() != "" {
+		t.Error("deleting the current context should clear it")
+	}
+	if _, err := secrets.Get("context-prod"); !errors.Is(err, secret.ErrNotFound) {
+		t.Errorf("deleting the context should delete its token, got %v", err)
+	}
+}
+
+func TestContextSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "context-settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	PersistentSettings.Configure(flags)
+	v, err := createViper(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	PersistentSettings.Bind(v, flags)
+
+	err = flags.Parse([]string{"--base-url", "https://explicit.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := contextSettings(flags, &Context{Name: "prod", Namespace: "backyards", URL: "https://context.example"})
+	config := newViperPersistentConfig(v, nil, "", settings)
+
+	if config.Namespace() != "backyards" {
+		t.Errorf("the namespace of the context should be used, got %s", config.Namespace())
+	}
+	if config.BaseURL() != "https://explicit.example" {
+		t.Errorf("explicitly given flags should take precedence, got %s", config.BaseURL())
+	}
+
+	config.SetTrackingClientID("client")
+	err = config.PersistConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "namespace: backyards\n") {
+		t.Errorf("the settings of the context should not be written to the config file:\n%s", content)
+	}
+}
@@ -16,6 +16,8 @@ package cli
 
 import (
 	"github.com/spf13/viper"
+
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
 )
 
 const (
@@ -25,16 +27,26 @@ const (
 type PersistentGlobalConfig interface {
 	LicenseAcceptedForVersion(string) bool
 	SetLicenseAcceptedForVersion(string)
+	Contexts() ([]Context, error)
+	GetContext(name string) (*Context, error)
+	SetContext(context Context) error
+	DeleteContext(name string) error
+	CurrentContext() string
+	UseContext(name string) error
 	PersistConfig() error
 }
 
 type viperPersistentGlobalConfig struct {
 	viper *viper.Viper
+
+	// the tokens of the contexts are kept in the secret store
+	secrets secret.Store
 }
 
-func newViperPersistentGlobalConfig(persistentGlobalConfig *viper.Viper) PersistentGlobalConfig {
+func newViperPersistentGlobalConfig(persistentGlobalConfig *viper.Viper, secrets secret.Store) PersistentGlobalConfig {
 	config := &viperPersistentGlobalConfig{
-		viper: persistentGlobalConfig,
+		viper:   persistentGlobalConfig,
+		secrets: secrets,
 	}
 	return config
 }
@@ -0,0 +1,224 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/banzaicloud/backyards-cli/internal/endpoint"
+)
+
+// timeout of the health check of a running proxy daemon
+const proxyHealthCheckTimeout = time.Second
+
+// ProxyState describes a running proxy daemon, it is saved next to the persistent config of the cluster
+type ProxyState struct {
+	PID        int       `json:"pid"`
+	URL        string    `json:"url"`
+	Namespace  string    `json:"namespace"`
+	CLIVersion string    `json:"cliVersion"`
+	Started    time.Time `json:"started"`
+	// CustomCredentials is set if the proxy was started with the request-scoped credential flags, e.g. impersonating a user
+	CustomCredentials bool `json:"customCredentials,omitempty"`
+}
+
+// ErrProxyNotServing is returned when stopping a proxy daemon which does not serve at its address anymore,
+// its pid might belong to another process by now
+var ErrProxyNotServing = errors.New("proxy does not serve at its address")
+
+// Healthy checks whether the proxy daemon serves requests, a server error means it cannot reach Backyards
+func (s *ProxyState) Healthy(ca []byte) bool {
+	status, err := s.status(ca)
+
+	return err == nil && status < http.StatusInternalServerError
+}
+
+// Serving checks whether the proxy daemon still answers requests, even if it cannot reach Backyards
+func (s *ProxyState) Serving(ca []byte) bool {
+	_, err := s.status(ca)
+
+	return err == nil
+}
+
+func (s *ProxyState) status(ca []byte) (int, error) {
+	ep := s.Endpoint(ca)
+	client := *ep.HTTPClient()
+	client.Timeout = proxyHealthCheckTimeout
+
+	resp, err := client.Get(ep.URLForPath(""))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Endpoint returns an endpoint connecting to the proxy daemon on its local port or unix socket
+func (s *ProxyState) Endpoint(ca []byte) endpoint.Endpoint {
+	if s.UnixSocket() {
+		return endpoint.NewUnixSocketEndpoint(strings.TrimPrefix(s.URL, endpoint.UnixSocketScheme))
+	}
+
+	return endpoint.NewExternalEndpoint(s.URL, ca)
+}
+
+// UnixSocket returns whether the proxy daemon serves on a unix socket
+func (s *ProxyState) UnixSocket() bool {
+	return strings.HasPrefix(s.URL, endpoint.UnixSocketScheme)
+}
+
+// Stop interrupts the proxy daemon, or kills it where interrupting is not supported.
+// The process is only signalled while the proxy serves at its address, otherwise ErrProxyNotServing is returned.
+func (s *ProxyState) Stop(ca []byte) error {
+	if !s.Serving(ca) {
+		return errors.WithDetails(ErrProxyNotServing, "url", s.URL, "pid", s.PID)
+	}
+
+	process, err := os.FindProcess(s.PID)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not find proxy process", "pid", s.PID)
+	}
+
+	err = process.Signal(os.Interrupt)
+	if err == nil {
+		return nil
+	}
+
+	err = process.Kill()
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not stop proxy process", "pid", s.PID)
+	}
+
+	return nil
+}
+
+// ProxyFiles returns the state and log files of the proxy daemon of the current cluster
+func (c *backyardsCLI) ProxyFiles() (stateFile string, logFile string, err error) {
+	configFile, err := c.persistentConfigFile()
+	if err != nil {
+		return "", "", err
+	}
+
+	base := strings.TrimSuffix(configFile, filepath.Ext(configFile))
+
+	return base + ".proxy.json", base + ".proxy.log", nil
+}
+
+// ReadProxyState returns the state of the proxy daemon of the current cluster, or nil if it is not running
+func (c *backyardsCLI) ReadProxyState() (*ProxyState, error) {
+	stateFile, _, err := c.ProxyFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read proxy state", "file", stateFile)
+	}
+
+	var state ProxyState
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not parse proxy state", "file", stateFile)
+	}
+
+	return &state, nil
+}
+
+// WriteProxyState saves the state of the proxy daemon of the current cluster, a nil state removes it
+func (c *backyardsCLI) WriteProxyState(state *ProxyState) error {
+	stateFile, _, err := c.ProxyFiles()
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		err = os.Remove(stateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WrapIfWithDetails(err, "could not remove proxy state", "file", stateFile)
+		}
+		return nil
+	}
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return errors.WrapIf(err, "could not marshal proxy state")
+	}
+
+	err = os.MkdirAll(filepath.Dir(stateFile), 0700)
+	if err != nil {
+		return errors.WrapIf(err, "could not create config dir")
+	}
+
+	err = ioutil.WriteFile(stateFile, content, 0600)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write proxy state", "file", stateFile)
+	}
+
+	return nil
+}
+
+// proxyDaemonEndpoint returns an endpoint reusing the proxy daemon of the current cluster,
+// or nil if no healthy daemon is running for the Backyards namespace
+func (c *backyardsCLI) proxyDaemonEndpoint(ca []byte, allowUnixSocket bool) endpoint.Endpoint {
+	state, err := c.ReadProxyState()
+	if err != nil {
+		logrus.Debugf("ignoring proxy daemon: %s", err)
+		return nil
+	}
+	if state == nil || state.Namespace != c.persistentConfig.Namespace() {
+		return nil
+	}
+	if state.UnixSocket() && !allowUnixSocket {
+		logrus.Debugf("proxy daemon at %s serves on a unix socket, ignoring it", state.URL)
+		return nil
+	}
+	if state.CustomCredentials {
+		logrus.Debugf("proxy daemon at %s uses custom credentials, ignoring it", state.URL)
+		return nil
+	}
+
+	if !state.Healthy(ca) {
+		logrus.Debugf("proxy daemon at %s is not healthy, ignoring it", state.URL)
+		return nil
+	}
+
+	logrus.Debugf("using proxy daemon at %s", state.URL)
+
+	return state.Endpoint(ca)
+}
+
+// CurrentKubeContext returns the name of the kubeconfig context in use
+func CurrentKubeContext() (string, error) {
+	config, err := getValidatedRawConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return config.CurrentContext, nil
+}
@@ -0,0 +1,249 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/spf13/viper"
+
+	"github.com/banzaicloud/backyards-cli/internal/endpoint"
+	"github.com/banzaicloud/backyards-cli/pkg/secret"
+)
+
+func newProxyTestCLI(dir string) *backyardsCLI {
+	configFile := filepath.Join(dir, "cluster.yaml")
+	viper.Set(PersistentConfigKey, configFile)
+
+	v := viper.New()
+	v.Set(Namespace, "backyards-system")
+
+	return &backyardsCLI{
+		persistentConfig: newViperPersistentConfig(v, secret.NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key")), "cluster", nil),
+	}
+}
+
+func TestProxyState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Set(PersistentConfigKey, "")
+
+	c := newProxyTestCLI(dir)
+
+	stateFile, logFile, err := c.ProxyFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stateFile != filepath.Join(dir, "cluster.proxy.json") || logFile != filepath.Join(dir, "cluster.proxy.log") {
+		t.Errorf("unexpected proxy files: %s, %s", stateFile, logFile)
+	}
+
+	state, err := c.ReadProxyState()
+	if err != nil || state != nil {
+		t.Fatalf("a missing state should mean a stopped proxy, got %+v, %v", state, err)
+	}
+
+	started := time.Now().UTC().Truncate(time.Second)
+	err = c.WriteProxyState(&ProxyState{PID: 42, URL: "http://127.0.0.1:50500", Namespace: "backyards-system", Started: started})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err = c.ReadProxyState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == nil || state.PID != 42 || state.URL != "http://127.0.0.1:50500" || !state.Started.Equal(started) {
+		t.Errorf("unexpected state read back: %+v", state)
+	}
+
+	err = c.WriteProxyState(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Error("removing the state should delete the state file")
+	}
+	if err := c.WriteProxyState(nil); err != nil {
+		t.Errorf("removing a missing state should not fail: %s", err)
+	}
+}
+
+func TestProxyStateHealthy(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	state := &ProxyState{URL: srv.URL}
+	if !state.Healthy(nil) {
+		t.Error("a proxy serving requests should be healthy")
+	}
+
+	status = http.StatusUnauthorized
+	if !state.Healthy(nil) {
+		t.Error("a proxy reaching Backyards without credentials should be healthy")
+	}
+
+	status = http.StatusServiceUnavailable
+	if state.Healthy(nil) {
+		t.Error("a proxy failing to reach Backyards should not be healthy")
+	}
+
+	srv.Close()
+	if state.Healthy(nil) {
+		t.Error("a stopped proxy should not be healthy")
+	}
+}
+
+func TestProxyStateUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "proxy.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %s", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer srv.Close()
+
+	state := &ProxyState{URL: endpoint.UnixSocketScheme + socket}
+	if !state.UnixSocket() {
+		t.Fatal("the state should describe a unix socket")
+	}
+	if !state.Healthy(nil) {
+		t.Error("a proxy serving on a unix socket should be healthy")
+	}
+}
+
+func TestProxyDaemonEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer viper.Set(PersistentConfigKey, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := newProxyTestCLI(dir)
+
+	if ep := c.proxyDaemonEndpoint(nil, true); ep != nil {
+		t.Error("no endpoint should be returned without a running proxy")
+	}
+
+	state := &ProxyState{URL: srv.URL, Namespace: "backyards-system"}
+	if err := c.WriteProxyState(state); err != nil {
+		t.Fatal(err)
+	}
+	if ep := c.proxyDaemonEndpoint(nil, true); ep == nil || ep.URLForPath("/api") != srv.URL+"/api" {
+		t.Errorf("the running proxy should be reused, got %v", ep)
+	}
+
+	state.Namespace = "other"
+	if err := c.WriteProxyState(state); err != nil {
+		t.Fatal(err)
+	}
+	if ep := c.proxyDaemonEndpoint(nil, true); ep != nil {
+		t.Error("a proxy of another Backyards namespace should not be reused")
+	}
+
+	state.Namespace = "backyards-system"
+	state.CustomCredentials = true
+	if err := c.WriteProxyState(state); err != nil {
+		t.Fatal(err)
+	}
+	if ep := c.proxyDaemonEndpoint(nil, true); ep != nil {
+		t.Error("a proxy with custom credentials should not be reused")
+	}
+
+	state.CustomCredentials = false
+	state.URL = endpoint.UnixSocketScheme + filepath.Join(dir, "proxy.sock")
+	if err := c.WriteProxyState(state); err != nil {
+		t.Fatal(err)
+	}
+	if ep := c.proxyDaemonEndpoint(nil, false); ep != nil {
+		t.Error("a proxy on a unix socket should not be reused for browser links")
+	}
+}
+
+func TestProxyStateStop(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	cmd := exec.Command(sleep, "30")
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cmd.Process.Kill() }()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	url := srv.URL
+	srv.Close()
+
+	state := &ProxyState{PID: cmd.Process.Pid, URL: url}
+	err = state.Stop(nil)
+	if !errors.Is(err, ErrProxyNotServing) {
+		t.Fatalf("a proxy not serving at its address should not be signalled, got: %v", err)
+	}
+	select {
+	case <-exited:
+		t.Fatal("the process of a proxy not serving at its address should not be stopped")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	srv = httptest.NewServer(srv.Config.Handler)
+	defer srv.Close()
+	state.URL = srv.URL
+	err = state.Stop(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the proxy process should be stopped")
+	}
+}
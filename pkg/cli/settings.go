@@ -15,8 +15,12 @@
 package cli
 
 import (
+	"bytes"
 	"reflect"
+	"sort"
+	"strings"
 
+	"emperror.dev/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cast"
 	flag "github.com/spf13/pflag"
@@ -39,6 +43,7 @@ const (
 
 type Setting struct {
 	Flag                string
+	ReplacedBy          string
 	Default             string
 	Description         string
 	Shorthand           string
@@ -66,6 +71,7 @@ var PersistentSettings = Settings{
 		Env:                 "BACKYARDS_NAMESPACE",
 		Deprecated:          "please use --backyards-namespace instead",
 		ShorthandDeprecated: "please use --backyards-namespace instead",
+		ReplacedBy:          Namespace,
 	},
 	Namespace: {
 		Flag:        "backyards-namespace",
@@ -157,3 +163,97 @@ func (i Settings) Bind(viper *viper.Viper, flags *flag.FlagSet) {
 		}
 	}
 }
+
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// Lookup returns the canonical key and the definition of a setting, keys are matched case insensitively like viper does.
+// Deprecated settings are resolved to their replacements with a warning.
+func (i Settings) Lookup(key string) (string, Setting, error) {
+	name, item, err := i.lookup(key)
+	if errors.Is(err, ErrUnknownSetting) {
+		return "", Setting{}, errors.Errorf("unknown setting %s, valid settings: %s", key, strings.Join(i.Keys(), ", "))
+	}
+	if item.ReplacedBy != "" {
+		logrus.Warnf("%s is deprecated, please use %s instead", name, item.ReplacedBy)
+		return item.ReplacedBy, i[item.ReplacedBy], nil
+	}
+
+	return name, item, nil
+}
+
+// Keys returns the sorted keys of the settings which are not deprecated
+func (i Settings) Keys() []string {
+	keys := make([]string, 0, len(i))
+	for key, item := range i {
+		if item.ReplacedBy == "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Convert checks the value against the kind of the setting and returns it converted to that kind
+func (i Setting) Convert(value interface{}) (interface{}, error) {
+	var result interface{}
+	var err error
+	switch i.Kind {
+	case reflect.String:
+		result, err = cast.ToStringE(value)
+	case reflect.Bool:
+		result, err = cast.ToBoolE(value)
+	case reflect.Int:
+		result, err = cast.ToIntE(value)
+	case reflect.Slice:
+		if s, ok := value.(string); ok {
+			value = strings.Split(s, ",")
+		}
+		result, err = cast.ToStringSliceE(value)
+	default:
+		return nil, errors.Errorf("unsupported setting type: %s", i.Kind)
+	}
+	if err != nil {
+		return nil, errors.Errorf("invalid %s value: %v", i.Kind, value)
+	}
+
+	return result, nil
+}
+
+// Validate checks that the YAML config only contains known settings of the right kind
+func (i Settings) Validate(content []byte) error {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(bytes.NewReader(content))
+	if err != nil {
+		return errors.WrapIf(err, "invalid YAML")
+	}
+
+	var errs []error
+	for _, key := range v.AllKeys() {
+		name, item, err := i.lookup(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// deprecated settings are still written with their defaults, since they are bound to flags
+		if item.ReplacedBy != "" && cast.ToString(v.Get(key)) != item.Default {
+			logrus.Warnf("%s is deprecated, please use %s instead", name, item.ReplacedBy)
+		}
+		if _, err := item.Convert(v.Get(key)); err != nil {
+			errs = append(errs, errors.WrapIff(err, "%s", name))
+		}
+	}
+
+	return errors.Combine(errs...)
+}
+
+func (i Settings) lookup(key string) (string, Setting, error) {
+	for name, item := range i {
+		if strings.EqualFold(name, key) {
+			return name, item, nil
+		}
+	}
+
+	return "", Setting{}, errors.WrapIff(ErrUnknownSetting, "%s", key)
+}
@@ -0,0 +1,68 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+)
+
+func TestSettingsLookup(t *testing.T) {
+	key, setting, err := PersistentSettings.Lookup("backyards.localport")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key != LocalPort {
+		t.Errorf("keys should be matched case insensitively, got %s", key)
+	}
+	if _, err := setting.Convert("abc"); err == nil {
+		t.Error("non-numeric values should be rejected for int settings")
+	}
+	if value, err := setting.Convert("9000"); err != nil || value != 9000 {
+		t.Errorf("unexpected conversion result: %v, %v", value, err)
+	}
+
+	key, _, err = PersistentSettings.Lookup(NamespaceDeprecated)
+	if err != nil || key != Namespace {
+		t.Errorf("deprecated settings should resolve to their replacements, got %s, %v", key, err)
+	}
+
+	if _, _, err := PersistentSettings.Lookup("backyards.missing"); err == nil {
+		t.Error("unknown settings should be rejected")
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errors  int
+	}{
+		{"valid", "backyards:\n  namespace: backyards-system\n  localPort: 9000\n  usePortForward: true\n", 0},
+		{"invalid YAML", "backyards: [\n", 1},
+		{"unknown setting", "backyards:\n  missing: value\n", 1},
+		{"wrong kinds", "backyards:\n  localPort: abc\n  usePortForward: maybe\n", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := PersistentSettings.Validate([]byte(test.content))
+			if len(errors.GetErrors(err)) != test.errors {
+				t.Errorf("expected %d errors, got %v", test.errors, err)
+			}
+		})
+	}
+}
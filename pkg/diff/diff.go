@@ -0,0 +1,189 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	// line indexes in the old and the new text
+	a, b int
+}
+
+// Lines splits a text into lines for diffing
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified returns the unified diff of two line slices with the given number of context lines,
+// or an empty string if they are equal. Changed lines are colored when color is set.
+func Unified(fromName, toName string, a, b []string, context int, color bool) string {
+	ops := compute(a, b)
+
+	changed := false
+	for _, o := range ops {
+		if o.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	buf := new(bytes.Buffer)
+	writeLine(buf, "--- "+fromName, colorRed, color)
+	writeLine(buf, "+++ "+toName, colorGreen, color)
+
+	for _, h := range hunks(ops, context) {
+		aStart, bStart := ops[h[0]].a, ops[h[0]].b
+		aCount, bCount := 0, 0
+		for _, o := range ops[h[0]:h[1]] {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+		writeLine(buf, fmt.Sprintf("@@ -%s +%s @@", hunkRange(aStart, aCount), hunkRange(bStart, bCount)), colorCyan, color)
+
+		for _, o := range ops[h[0]:h[1]] {
+			switch o.kind {
+			case opEqual:
+				writeLine(buf, " "+a[o.a], "", color)
+			case opDelete:
+				writeLine(buf, "-"+a[o.a], colorRed, color)
+			case opInsert:
+				writeLine(buf, "+"+b[o.b], colorGreen, color)
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+func writeLine(buf *bytes.Buffer, line, lineColor string, color bool) {
+	if color && lineColor != "" {
+		line = lineColor + line + colorReset
+	}
+	buf.WriteString(line)
+	buf.WriteString("\n")
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// compute produces the edit script between a and b based on their longest common subsequence
+func compute(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, a: i, b: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{kind: opDelete, a: i, b: j})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, a: i, b: j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{kind: opDelete, a: i, b: j})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{kind: opInsert, a: i, b: j})
+	}
+
+	return ops
+}
+
+// hunks groups the changes with their surrounding context into [start, end) ranges of the edit script
+func hunks(ops []op, context int) [][2]int {
+	result := make([][2]int, 0)
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1 + context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		if len(result) > 0 && start <= result[len(result)-1][1] {
+			result[len(result)-1][1] = end
+		} else {
+			result = append(result, [2]int{start, end})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := map[string]struct {
+		a, b     string
+		context  int
+		expected string
+	}{
+		"equal": {
+			a:        "foo\nbar\n",
+			b:        "foo\nbar\n",
+			expected: "",
+		},
+		"change": {
+			a:        "a\nb\nc\nd\ne\nf\ng\n",
+			b:        "a\nb\nc\nD\ne\nf\ng\n",
+			context:  2,
+			expected: "--- old\n+++ new\n@@ -2,5 +2,5 @@\n b\n c\n-d\n+D\n e\n f\n",
+		},
+		"from empty": {
+			a:        "",
+			b:        "foo\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1 @@\n+foo\n",
+		},
+		"separate hunks": {
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:        "0\n1\n2\n3\n4\n5\n6\n7\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1 @@\n+0\n@@ -8 +8,0 @@\n-8\n",
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+
+		t.Run(name, func(t *testing.T) {
+			got := Unified("old", "new", Lines(test.a), Lines(test.b), test.context, false)
+			if got != test.expected {
+				t.Errorf("unexpected diff\ngot : %q\nwant: %q", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	// AdminPort is the port of the Envoy admin interface in Istio sidecars
+	AdminPort = 15000
+
+	defaultAdminTimeout = time.Second * 10
+)
+
+// AdminClient talks to the admin interface of a single Envoy proxy
+type AdminClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewAdminClient(url string) *AdminClient {
+	return &AdminClient{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: defaultAdminTimeout,
+		},
+	}
+}
+
+// ConfigDump retrieves and parses the /config_dump output of the proxy
+func (c *AdminClient) ConfigDump() (*ConfigDump, error) {
+	var dump ConfigDump
+	err := c.getJSON("/config_dump", &dump)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get config dump")
+	}
+
+	return &dump, nil
+}
+
+// ClusterStatuses retrieves the per host status of every cluster known by the proxy
+func (c *AdminClient) ClusterStatuses() ([]ClusterStatus, error) {
+	var clusters struct {
+		ClusterStatuses []ClusterStatus `json:"cluster_statuses"`
+	}
+	err := c.getJSON("/clusters?format=json", &clusters)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get cluster statuses")
+	}
+
+	return clusters.ClusterStatuses, nil
+}
+
+func (c *AdminClient) getJSON(path string, v interface{}) error {
+	body, err := c.do(http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not parse response", "path", path)
+	}
+
+	return nil
+}
+
+func (c *AdminClient) do(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, c.url+path, nil)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not create request", "path", path)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "request failed", "path", path)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read response body", "path", path)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewWithDetails("unexpected response from envoy admin", "path", path, "status", resp.Status, "body", string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminClientLogging(t *testing.T) {
+	levels := map[string]string{"admin": "info", "http": "info", "router": "info"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		for logger, values := range r.URL.Query() {
+			levels[logger] = values[0]
+		}
+		fmt.Fprintln(w, "active loggers:")
+		for logger, level := range levels {
+			fmt.Fprintf(w, "  %s: %s\n", logger, level)
+		}
+	}))
+	defer server.Close()
+
+	client := NewAdminClient(server.URL)
+
+	current, err := client.SetLogLevels(map[string]string{"http": "debug"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if current["http"] != "debug" || current["router"] != "info" || len(current) != 3 {
+		t.Errorf("unexpected levels: %v", current)
+	}
+
+	if _, err := client.SetLogLevels(map[string]string{"http": "verbose"}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestAdminClientStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") != "upstream_rq" {
+			t.Errorf("unexpected filter: %s", r.URL.Query().Get("filter"))
+		}
+		fmt.Fprintln(w, "cluster.inbound|8080||.upstream_rq_200: 12")
+		fmt.Fprintln(w, "cluster.inbound|8080||.upstream_rq_time: P0(nan,0) P25(nan,1)")
+		fmt.Fprintln(w, "cluster.inbound|8080||.upstream_rq_total: 13")
+	}))
+	defer server.Close()
+
+	stats, err := NewAdminClient(server.URL).Stats("upstream_rq")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(stats) != 2 || stats[0].Value != 12 || stats[1].Name != "cluster.inbound|8080||.upstream_rq_total" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
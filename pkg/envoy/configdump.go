@@ -0,0 +1,518 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// The types below only model the parts of the Envoy admin API output the CLI works with.
+// Using the go-control-plane protos would require every typed_config (including Istio specific
+// filters) to be registered, which makes parsing fail on any unknown extension.
+
+const (
+	listenersConfigDumpType = "ListenersConfigDump"
+	routesConfigDumpType    = "RoutesConfigDump"
+	clustersConfigDumpType  = "ClustersConfigDump"
+	secretsConfigDumpType   = "SecretsConfigDump"
+
+	httpConnectionManagerFilter = "envoy.http_connection_manager"
+	tcpProxyFilter              = "envoy.tcp_proxy"
+
+	StatusActive   = "active"
+	StatusWarming  = "warming"
+	StatusDraining = "draining"
+	StatusStatic   = "static"
+)
+
+type ConfigDump struct {
+	Listeners []Listener    `json:"listeners"`
+	Routes    []RouteConfig `json:"routes"`
+	Clusters  []Cluster     `json:"clusters"`
+	Secrets   []Secret      `json:"secrets"`
+}
+
+type SocketAddress struct {
+	Address   string `json:"address"`
+	PortValue int    `json:"port_value"`
+}
+
+type Address struct {
+	SocketAddress *SocketAddress `json:"socket_address,omitempty"`
+	Pipe          *struct {
+		Path string `json:"path"`
+	} `json:"pipe,omitempty"`
+}
+
+func (a Address) String() string {
+	switch {
+	case a.SocketAddress != nil:
+		return a.SocketAddress.Address
+	case a.Pipe != nil:
+		return "unix://" + a.Pipe.Path
+	default:
+		return ""
+	}
+}
+
+func (a Address) Port() int {
+	if a.SocketAddress != nil {
+		return a.SocketAddress.PortValue
+	}
+	return 0
+}
+
+type Filter struct {
+	Name        string `json:"name"`
+	TypedConfig struct {
+		RDS *struct {
+			RouteConfigName string `json:"route_config_name"`
+		} `json:"rds,omitempty"`
+		RouteConfig *RouteConfig `json:"route_config,omitempty"`
+		Cluster     string       `json:"cluster,omitempty"`
+	} `json:"typed_config"`
+}
+
+type FilterChain struct {
+	FilterChainMatch *struct {
+		ServerNames          []string `json:"server_names,omitempty"`
+		TransportProtocol    string   `json:"transport_protocol,omitempty"`
+		ApplicationProtocols []string `json:"application_protocols,omitempty"`
+	} `json:"filter_chain_match,omitempty"`
+	Filters []Filter `json:"filters"`
+}
+
+type Listener struct {
+	Name         string        `json:"name"`
+	Address      Address       `json:"address"`
+	FilterChains []FilterChain `json:"filter_chains"`
+	Status       string        `json:"status"`
+	VersionInfo  string        `json:"version_info,omitempty"`
+}
+
+// Type returns whether the listener handles HTTP or TCP traffic
+func (l Listener) Type() string {
+	var http, tcp bool
+	for _, fc := range l.FilterChains {
+		for _, f := range fc.Filters {
+			switch f.Name {
+			case httpConnectionManagerFilter:
+				http = true
+			case tcpProxyFilter:
+				tcp = true
+			}
+		}
+	}
+
+	switch {
+	case http && tcp:
+		return "HTTP+TCP"
+	case http:
+		return "HTTP"
+	case tcp:
+		return "TCP"
+	default:
+		return "-"
+	}
+}
+
+// Destinations returns the route configs and clusters the listener forwards to
+func (l Listener) Destinations() []string {
+	destinations := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(d string) {
+		if !seen[d] {
+			seen[d] = true
+			destinations = append(destinations, d)
+		}
+	}
+	for _, fc := range l.FilterChains {
+		for _, f := range fc.Filters {
+			switch {
+			case f.TypedConfig.RDS != nil:
+				add("Route: " + f.TypedConfig.RDS.RouteConfigName)
+			case f.TypedConfig.RouteConfig != nil:
+				add("Inline route: " + f.TypedConfig.RouteConfig.Name)
+			case f.TypedConfig.Cluster != "":
+				add("Cluster: " + f.TypedConfig.Cluster)
+			}
+		}
+	}
+
+	return destinations
+}
+
+type RouteMatch struct {
+	Prefix    string `json:"prefix,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Regex     string `json:"regex,omitempty"`
+	SafeRegex *struct {
+		Regex string `json:"regex"`
+	} `json:"safe_regex,omitempty"`
+}
+
+func (m RouteMatch) String() string {
+	switch {
+	case m.Path != "":
+		return m.Path
+	case m.Regex != "":
+		return m.Regex
+	case m.SafeRegex != nil:
+		return m.SafeRegex.Regex
+	case m.Prefix != "":
+		return m.Prefix + "*"
+	default:
+		return "/*"
+	}
+}
+
+type Route struct {
+	Name   string     `json:"name,omitempty"`
+	Match  RouteMatch `json:"match"`
+	Action *struct {
+		Cluster          string `json:"cluster,omitempty"`
+		WeightedClusters *struct {
+			Clusters []struct {
+				Name   string `json:"name"`
+				Weight int    `json:"weight"`
+			} `json:"clusters"`
+		} `json:"weighted_clusters,omitempty"`
+	} `json:"route,omitempty"`
+	Redirect *struct {
+		HostRedirect string `json:"host_redirect,omitempty"`
+		PathRedirect string `json:"path_redirect,omitempty"`
+	} `json:"redirect,omitempty"`
+	DirectResponse *struct {
+		Status int `json:"status"`
+	} `json:"direct_response,omitempty"`
+}
+
+// Destination returns a short description of where the route sends the matching requests
+func (r Route) Destination() string {
+	switch {
+	case r.Action != nil && r.Action.Cluster != "":
+		return r.Action.Cluster
+	case r.Action != nil && r.Action.WeightedClusters != nil:
+		clusters := make([]string, len(r.Action.WeightedClusters.Clusters))
+		for i, c := range r.Action.WeightedClusters.Clusters {
+			clusters[i] = c.Name
+		}
+		return strings.Join(clusters, ", ")
+	case r.Redirect != nil:
+		return "redirect " + r.Redirect.HostRedirect + r.Redirect.PathRedirect
+	case r.DirectResponse != nil:
+		return "direct response"
+	default:
+		return "-"
+	}
+}
+
+type VirtualHost struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+	Routes  []Route  `json:"routes"`
+}
+
+type RouteConfig struct {
+	Name         string        `json:"name"`
+	VirtualHosts []VirtualHost `json:"virtual_hosts"`
+	Status       string        `json:"status"`
+	VersionInfo  string        `json:"version_info,omitempty"`
+}
+
+type Cluster struct {
+	Name             string `json:"name"`
+	Type             string `json:"type,omitempty"`
+	EDSClusterConfig *struct {
+		ServiceName string `json:"service_name"`
+	} `json:"eds_cluster_config,omitempty"`
+	Status      string `json:"status"`
+	VersionInfo string `json:"version_info,omitempty"`
+}
+
+// ParsedName splits an Istio generated cluster name (e.g. outbound|9080|v1|reviews.default.svc.cluster.local)
+// into its parts. For clusters with custom names only the FQDN is filled.
+func (c Cluster) ParsedName() (direction, port, subset, fqdn string) {
+	parts := strings.Split(c.Name, "|")
+	if len(parts) != 4 {
+		return "", "", "", c.Name
+	}
+	return parts[0], parts[1], parts[2], parts[3]
+}
+
+type DataSource struct {
+	InlineBytes  []byte `json:"inline_bytes,omitempty"`
+	InlineString string `json:"inline_string,omitempty"`
+	Filename     string `json:"filename,omitempty"`
+}
+
+type Secret struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	VersionInfo    string `json:"version_info,omitempty"`
+	LastUpdated    string `json:"last_updated,omitempty"`
+	TLSCertificate *struct {
+		CertificateChain DataSource `json:"certificate_chain"`
+	} `json:"tls_certificate,omitempty"`
+	ValidationContext *struct {
+		TrustedCA DataSource `json:"trusted_ca"`
+	} `json:"validation_context,omitempty"`
+}
+
+type HostStatus struct {
+	Address      Address `json:"address"`
+	HealthStatus struct {
+		EDSHealthStatus            string `json:"eds_health_status,omitempty"`
+		FailedOutlierCheck         bool   `json:"failed_outlier_check,omitempty"`
+		FailedActiveHealthCheck    bool   `json:"failed_active_health_check,omitempty"`
+		PendingActiveHealthCheck   bool   `json:"pending_active_hc,omitempty"`
+		FailedActiveDegradedCheck  bool   `json:"failed_active_degraded_check,omitempty"`
+		PendingDynamicRemoval      bool   `json:"pending_dynamic_removal,omitempty"`
+		ExcludedViaImmediateHCFail bool   `json:"excluded_via_immediate_hc_fail,omitempty"`
+	} `json:"health_status"`
+	Weight int `json:"weight,omitempty"`
+}
+
+// Health returns a single word summary of the host health flags
+func (h HostStatus) Health() string {
+	switch {
+	case h.HealthStatus.FailedOutlierCheck:
+		return "OUTLIER"
+	case h.HealthStatus.FailedActiveHealthCheck:
+		return "UNHEALTHY"
+	case h.HealthStatus.EDSHealthStatus != "":
+		return h.HealthStatus.EDSHealthStatus
+	default:
+		return "HEALTHY"
+	}
+}
+
+type ClusterStatus struct {
+	Name         string       `json:"name"`
+	AddedViaAPI  bool         `json:"added_via_api,omitempty"`
+	HostStatuses []HostStatus `json:"host_statuses,omitempty"`
+}
+
+type typedConfig struct {
+	Type string `json:"@type"`
+}
+
+type listenersConfigDump struct {
+	StaticListeners []struct {
+		Listener Listener `json:"listener"`
+	} `json:"static_listeners"`
+	// envoy < 1.13
+	DynamicActiveListeners   []dynamicListener `json:"dynamic_active_listeners"`
+	DynamicWarmingListeners  []dynamicListener `json:"dynamic_warming_listeners"`
+	DynamicDrainingListeners []dynamicListener `json:"dynamic_draining_listeners"`
+	// envoy >= 1.13
+	DynamicListeners []struct {
+		ActiveState   *dynamicListener `json:"active_state"`
+		WarmingState  *dynamicListener `json:"warming_state"`
+		DrainingState *dynamicListener `json:"draining_state"`
+	} `json:"dynamic_listeners"`
+}
+
+type dynamicListener struct {
+	VersionInfo string   `json:"version_info"`
+	Listener    Listener `json:"listener"`
+}
+
+type routesConfigDump struct {
+	StaticRouteConfigs []struct {
+		RouteConfig RouteConfig `json:"route_config"`
+	} `json:"static_route_configs"`
+	DynamicRouteConfigs []struct {
+		VersionInfo string      `json:"version_info"`
+		RouteConfig RouteConfig `json:"route_config"`
+	} `json:"dynamic_route_configs"`
+}
+
+type clustersConfigDump struct {
+	StaticClusters []struct {
+		Cluster Cluster `json:"cluster"`
+	} `json:"static_clusters"`
+	DynamicActiveClusters  []dynamicCluster `json:"dynamic_active_clusters"`
+	DynamicWarmingClusters []dynamicCluster `json:"dynamic_warming_clusters"`
+}
+
+type dynamicCluster struct {
+	VersionInfo string  `json:"version_info"`
+	Cluster     Cluster `json:"cluster"`
+}
+
+type secretsConfigDump struct {
+	StaticSecrets []struct {
+		Name   string `json:"name"`
+		Secret Secret `json:"secret"`
+	} `json:"static_secrets"`
+	DynamicActiveSecrets  []dynamicSecret `json:"dynamic_active_secrets"`
+	DynamicWarmingSecrets []dynamicSecret `json:"dynamic_warming_secrets"`
+}
+
+type dynamicSecret struct {
+	Name        string `json:"name"`
+	VersionInfo string `json:"version_info"`
+	LastUpdated string `json:"last_updated"`
+	Secret      Secret `json:"secret"`
+}
+
+func (d *ConfigDump) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Configs []json.RawMessage `json:"configs"`
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	for _, config := range raw.Configs {
+		var t typedConfig
+		err = json.Unmarshal(config, &t)
+		if err != nil {
+			return err
+		}
+
+		switch t.Type[strings.LastIndex(t.Type, ".")+1:] {
+		case listenersConfigDumpType:
+			err = d.parseListeners(config)
+		case routesConfigDumpType:
+			err = d.parseRoutes(config)
+		case clustersConfigDumpType:
+			err = d.parseClusters(config)
+		case secretsConfigDumpType:
+			err = d.parseSecrets(config)
+		}
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "could not parse config", "type", t.Type)
+		}
+	}
+
+	return nil
+}
+
+func (d *ConfigDump) parseListeners(data []byte) error {
+	var dump listenersConfigDump
+	err := json.Unmarshal(data, &dump)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range dump.StaticListeners {
+		l.Listener.Status = StatusStatic
+		d.Listeners = append(d.Listeners, l.Listener)
+	}
+	addDynamic := func(l *dynamicListener, status string) {
+		if l == nil {
+			return
+		}
+		l.Listener.Status = status
+		l.Listener.VersionInfo = l.VersionInfo
+		d.Listeners = append(d.Listeners, l.Listener)
+	}
+	for i := range dump.DynamicActiveListeners {
+		addDynamic(&dump.DynamicActiveListeners[i], StatusActive)
+	}
+	for i := range dump.DynamicWarmingListeners {
+		addDynamic(&dump.DynamicWarmingListeners[i], StatusWarming)
+	}
+	for i := range dump.DynamicDrainingListeners {
+		addDynamic(&dump.DynamicDrainingListeners[i], StatusDraining)
+	}
+	for _, l := range dump.DynamicListeners {
+		addDynamic(l.ActiveState, StatusActive)
+		addDynamic(l.WarmingState, StatusWarming)
+		addDynamic(l.DrainingState, StatusDraining)
+	}
+
+	return nil
+}
+
+func (d *ConfigDump) parseRoutes(data []byte) error {
+	var dump routesConfigDump
+	err := json.Unmarshal(data, &dump)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dump.StaticRouteConfigs {
+		r.RouteConfig.Status = StatusStatic
+		d.Routes = append(d.Routes, r.RouteConfig)
+	}
+	for _, r := range dump.DynamicRouteConfigs {
+		r.RouteConfig.Status = StatusActive
+		r.RouteConfig.VersionInfo = r.VersionInfo
+		d.Routes = append(d.Routes, r.RouteConfig)
+	}
+
+	return nil
+}
+
+func (d *ConfigDump) parseClusters(data []byte) error {
+	var dump clustersConfigDump
+	err := json.Unmarshal(data, &dump)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range dump.StaticClusters {
+		c.Cluster.Status = StatusStatic
+		d.Clusters = append(d.Clusters, c.Cluster)
+	}
+	for _, c := range dump.DynamicActiveClusters {
+		c.Cluster.Status = StatusActive
+		c.Cluster.VersionInfo = c.VersionInfo
+		d.Clusters = append(d.Clusters, c.Cluster)
+	}
+	for _, c := range dump.DynamicWarmingClusters {
+		c.Cluster.Status = StatusWarming
+		c.Cluster.VersionInfo = c.VersionInfo
+		d.Clusters = append(d.Clusters, c.Cluster)
+	}
+
+	return nil
+}
+
+func (d *ConfigDump) parseSecrets(data []byte) error {
+	var dump secretsConfigDump
+	err := json.Unmarshal(data, &dump)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range dump.StaticSecrets {
+		s.Secret.Name = s.Name
+		s.Secret.Status = StatusStatic
+		d.Secrets = append(d.Secrets, s.Secret)
+	}
+	addDynamic := func(s dynamicSecret, status string) {
+		s.Secret.Name = s.Name
+		s.Secret.Status = status
+		s.Secret.VersionInfo = s.VersionInfo
+		s.Secret.LastUpdated = s.LastUpdated
+		d.Secrets = append(d.Secrets, s.Secret)
+	}
+	for _, s := range dump.DynamicActiveSecrets {
+		addDynamic(s, StatusActive)
+	}
+	for _, s := range dump.DynamicWarmingSecrets {
+		addDynamic(s, StatusWarming)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const configDump = `{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v2alpha.BootstrapConfigDump",
+      "bootstrap": {"node": {"id": "sidecar~10.0.0.1~catalog.default~default.svc.cluster.local"}}
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v2alpha.ClustersConfigDump",
+      "static_clusters": [{"cluster": {"name": "prometheus_stats", "type": "STATIC"}}],
+      "dynamic_active_clusters": [
+        {"version_info": "1", "cluster": {"name": "outbound|8080|v1|catalog.default.svc.cluster.local", "type": "EDS"}}
+      ]
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v2alpha.ListenersConfigDump",
+      "dynamic_active_listeners": [
+        {
+          "version_info": "1",
+          "listener": {
+            "name": "0.0.0.0_8080",
+            "address": {"socket_address": {"address": "0.0.0.0", "port_value": 8080}},
+            "filter_chains": [
+              {"filters": [{"name": "envoy.http_connection_manager", "typed_config": {"rds": {"route_config_name": "8080"}}}]},
+              {"filters": [{"name": "envoy.tcp_proxy", "typed_config": {"cluster": "PassthroughCluster"}}]}
+            ]
+          }
+        }
+      ],
+      "dynamic_listeners": [
+        {"name": "virtualInbound", "warming_state": {"listener": {"name": "virtualInbound", "address": {"socket_address": {"address": "0.0.0.0", "port_value": 15006}}}}}
+      ]
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v2alpha.RoutesConfigDump",
+      "dynamic_route_configs": [
+        {
+          "route_config": {
+            "name": "8080",
+            "virtual_hosts": [
+              {
+                "name": "catalog.default.svc.cluster.local:8080",
+                "domains": ["catalog.default.svc.cluster.local"],
+                "routes": [
+                  {"match": {"prefix": "/"}, "route": {"weighted_clusters": {"clusters": [{"name": "v1", "weight": 50}, {"name": "v2", "weight": 50}]}}}
+                ]
+              }
+            ]
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestConfigDumpUnmarshal(t *testing.T) {
+	var dump ConfigDump
+	if err := json.Unmarshal([]byte(configDump), &dump); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(dump.Clusters) != 2 {
+		t.Fatalf("unexpected number of clusters: %d", len(dump.Clusters))
+	}
+	direction, port, subset, fqdn := dump.Clusters[1].ParsedName()
+	if direction != "outbound" || port != "8080" || subset != "v1" || fqdn != "catalog.default.svc.cluster.local" {
+		t.Errorf("unexpected parsed cluster name: %s %s %s %s", direction, port, subset, fqdn)
+	}
+	if dump.Clusters[0].Status != StatusStatic || dump.Clusters[1].Status != StatusActive {
+		t.Errorf("unexpected cluster statuses: %s %s", dump.Clusters[0].Status, dump.Clusters[1].Status)
+	}
+
+	if len(dump.Listeners) != 2 {
+		t.Fatalf("unexpected number of listeners: %d", len(dump.Listeners))
+	}
+	l := dump.Listeners[0]
+	if l.Type() != "HTTP+TCP" || l.Address.Port() != 8080 {
+		t.Errorf("unexpected listener: %s %d", l.Type(), l.Address.Port())
+	}
+	if d := l.Destinations(); len(d) != 2 || d[0] != "Route: 8080" || d[1] != "Cluster: PassthroughCluster" {
+		t.Errorf("unexpected listener destinations: %v", d)
+	}
+	if dump.Listeners[1].Status != StatusWarming {
+		t.Errorf("unexpected listener status: %s", dump.Listeners[1].Status)
+	}
+
+	if len(dump.Routes) != 1 || len(dump.Routes[0].VirtualHosts) != 1 {
+		t.Fatalf("unexpected routes: %+v", dump.Routes)
+	}
+	r := dump.Routes[0].VirtualHosts[0].Routes[0]
+	if r.Match.String() != "/*" || r.Destination() != "v1, v2" {
+		t.Errorf("unexpected route: %s %s", r.Match.String(), r.Destination())
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+var ValidLogLevels = []string{"trace", "debug", "info", "warning", "error", "critical", "off"}
+
+// LogLevels returns the current level of every logger of the proxy
+func (c *AdminClient) LogLevels() (map[string]string, error) {
+	// a POST without parameters does not change anything, only lists the active loggers
+	body, err := c.do(http.MethodPost, "/logging")
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get log levels")
+	}
+
+	return parseLogLevels(body), nil
+}
+
+// SetLogLevels changes the level of the given loggers and returns the resulting levels of every logger
+func (c *AdminClient) SetLogLevels(levels map[string]string) (map[string]string, error) {
+	var current map[string]string
+	for logger, level := range levels {
+		if !IsValidLogLevel(level) {
+			return nil, errors.NewWithDetails("invalid log level", "logger", logger, "level", level)
+		}
+
+		body, err := c.do(http.MethodPost, "/logging?"+url.Values{logger: []string{level}}.Encode())
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not set log level", "logger", logger, "level", level)
+		}
+		current = parseLogLevels(body)
+	}
+
+	return current, nil
+}
+
+func IsValidLogLevel(level string) bool {
+	for _, l := range ValidLogLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLogLevels parses the "active loggers" list returned by the /logging endpoint
+func parseLogLevels(body []byte) map[string]string {
+	levels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		levels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return levels
+}
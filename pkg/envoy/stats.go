@@ -0,0 +1,68 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+type Stat struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// Stats returns the counters and gauges of the proxy whose name matches the given regular expression.
+// Histograms are left out as they don't have a single value.
+func (c *AdminClient) Stats(filter string) ([]Stat, error) {
+	path := "/stats"
+	if filter != "" {
+		path += "?" + url.Values{"filter": []string{filter}}.Encode()
+	}
+
+	body, err := c.do(http.MethodGet, path)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not get stats")
+	}
+
+	return parseStats(body), nil
+}
+
+func parseStats(body []byte) []Stat {
+	stats := make([]Stat, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ": ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		stats = append(stats, Stat{
+			Name:  parts[0],
+			Value: value,
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,108 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"io/ioutil"
+
+	"emperror.dev/errors"
+	"k8s.io/helm/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+// ValueOverrides are user supplied chart values, the same way `helm install -f values.yaml --set key=value` takes them
+type ValueOverrides struct {
+	ValueFiles []string
+	Values     []string
+}
+
+// Empty returns whether no values files or values are given
+func (o ValueOverrides) Empty() bool {
+	return len(o.ValueFiles) == 0 && len(o.Values) == 0
+}
+
+// Load reads the value files in order, then applies the key=value pairs over them
+func (o ValueOverrides) Load() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	for _, file := range o.ValueFiles {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not read values file", "file", file)
+		}
+
+		fileValues := make(map[string]interface{})
+		err = yaml.Unmarshal(content, &fileValues)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not parse values file", "file", file)
+		}
+
+		values = MergeValues(values, fileValues)
+	}
+
+	for _, value := range o.Values {
+		err := strvals.ParseInto(value, values)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not parse value", "value", value)
+		}
+	}
+
+	return values, nil
+}
+
+// Apply merges the overrides over the given YAML values
+func (o ValueOverrides) Apply(valuesYAML []byte) ([]byte, error) {
+	if o.Empty() {
+		return valuesYAML, nil
+	}
+
+	overrides, err := o.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return ApplyValues(valuesYAML, overrides)
+}
+
+// ApplyValues merges the given values over YAML values
+func ApplyValues(valuesYAML []byte, overrides map[string]interface{}) ([]byte, error) {
+	values := make(map[string]interface{})
+	err := yaml.Unmarshal(valuesYAML, &values)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not unmarshal yaml values")
+	}
+
+	merged, err := yaml.Marshal(MergeValues(values, overrides))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not marshal yaml values")
+	}
+
+	return merged, nil
+}
+
+// MergeValues merges src into dest recursively, values from src take precedence except when both are maps
+func MergeValues(dest, src map[string]interface{}) map[string]interface{} {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		destMap, destIsMap := dest[key].(map[string]interface{})
+		if srcIsMap && destIsMap {
+			dest[key] = MergeValues(destMap, srcMap)
+			continue
+		}
+		dest[key] = value
+	}
+
+	return dest
+}
@@ -0,0 +1,78 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const defaultValues = `image:
+  repository: banzaicloud/backyards
+  tag: 1.1.3
+resources:
+  requests:
+    cpu: 100m
+    memory: 128Mi
+`
+
+func TestValueOverridesApply(t *testing.T) {
+	file, err := ioutil.TempFile("", "values-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("image:\n  repository: registry.example.com/backyards\nresources:\n  requests:\n    cpu: 500m\nnodeSelector:\n  pool: system\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	overrides := ValueOverrides{
+		ValueFiles: []string{file.Name()},
+		Values:     []string{"image.tag=1.2.0", "resources.requests.cpu=1"},
+	}
+
+	result, err := overrides.Apply([]byte(defaultValues))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `image:
+  repository: registry.example.com/backyards
+  tag: 1.2.0
+nodeSelector:
+  pool: system
+resources:
+  requests:
+    cpu: 1
+    memory: 128Mi
+`
+	if string(result) != expected {
+		t.Errorf("unexpected values\ngot : %q\nwant: %q", result, expected)
+	}
+}
+
+func TestValueOverridesApplyEmpty(t *testing.T) {
+	result, err := ValueOverrides{}.Apply([]byte(defaultValues))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(result) != defaultValues {
+		t.Errorf("values should be left untouched without overrides, got %q", result)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+)
+
+const imageKey = "image"
+
+// List returns the sorted, unique container image references of the objects.
+// Every string value under an 'image' key is considered, which covers pod templates and the Istio CR as well.
+func List(objects object.K8sObjects) []string {
+	images := make(map[string]bool)
+	for _, obj := range objects {
+		walk(obj.UnstructuredObject().Object, func(image string) string {
+			images[image] = true
+			return image
+		})
+	}
+
+	result := make([]string, 0, len(images))
+	for image := range images {
+		result = append(result, image)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Relocate rewrites the image references of the objects in place to point to the given registry.
+// It returns the original and the relocated image references.
+func Relocate(objects object.K8sObjects, registry string) map[string]string {
+	relocated := make(map[string]string)
+	if registry == "" {
+		return relocated
+	}
+
+	for i, obj := range objects {
+		changed := false
+		u := obj.UnstructuredObject()
+		walk(u.Object, func(image string) string {
+			target := RelocatedName(image, registry)
+			if target != image {
+				relocated[image] = target
+				changed = true
+			}
+			return target
+		})
+		if changed {
+			// recreate the object to reset its cached yaml and json manifests
+			objects[i] = object.NewK8sObject(u, nil, nil)
+		}
+	}
+
+	return relocated
+}
+
+// RelocatedName returns the image reference in the given registry.
+// The original registry host is dropped, the repository path and the tag or digest are kept,
+// so quay.io/jetstack/cert-manager-controller:v0.11.0 becomes <registry>/jetstack/cert-manager-controller:v0.11.0.
+func RelocatedName(image, registry string) string {
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" || strings.HasPrefix(image, registry+"/") {
+		return image
+	}
+
+	name := image
+	if i := strings.Index(image, "/"); i > 0 {
+		host := image[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			name = image[i+1:]
+		}
+	}
+
+	return registry + "/" + name
+}
+
+func walk(value interface{}, fn func(image string) string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if image, ok := item.(string); ok && key == imageKey && image != "" {
+				v[key] = fn(image)
+				continue
+			}
+			walk(item, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walk(item, fn)
+		}
+	}
+}
+
+// WriteImageList writes a line with the source and the relocated reference of every image, separated by a space,
+// so that the images can be copied by a separate tool, e.g. in a shell loop running 'crane copy' or 'skopeo copy'
+func WriteImageList(w io.Writer, relocated map[string]string) error {
+	sources := make([]string, 0, len(relocated))
+	for source := range relocated {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		_, err := fmt.Fprintf(w, "%s %s\n", source, relocated[source])
+		if err != nil {
+			return errors.WrapIf(err, "could not write image list")
+		}
+	}
+
+	return nil
+}
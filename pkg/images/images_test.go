@@ -0,0 +1,132 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/operator/pkg/object"
+)
+
+const manifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backyards
+  namespace: backyards-system
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: backyards
+        image: banzaicloud/backyards:1.1.3
+      - name: controller
+        image: quay.io/jetstack/cert-manager-controller:v0.11.0
+---
+apiVersion: istio.banzaicloud.io/v1beta1
+kind: Istio
+metadata:
+  name: mesh
+spec:
+  imagePullPolicy: Always
+  pilot:
+    image: banzaicloud/istio-pilot:1.4.4-bzc
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: istios.istio.banzaicloud.io
+spec:
+  validation:
+    openAPIV3Schema:
+      properties:
+        image:
+          type: string
+`
+
+func TestRelocatedName(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"busybox", "registry.corp/mesh/busybox"},
+		{"banzaicloud/backyards:1.1.3", "registry.corp/mesh/banzaicloud/backyards:1.1.3"},
+		{"quay.io/jetstack/cert-manager-controller:v0.11.0", "registry.corp/mesh/jetstack/cert-manager-controller:v0.11.0"},
+		{"localhost:5000/app@sha256:abcd", "registry.corp/mesh/app@sha256:abcd"},
+		{"registry.corp/mesh/banzaicloud/backyards:1.1.3", "registry.corp/mesh/banzaicloud/backyards:1.1.3"},
+	}
+
+	for _, test := range tests {
+		if actual := RelocatedName(test.image, "registry.corp/mesh/"); actual != test.expected {
+			t.Errorf("unexpected relocated name of %s: got %s, want %s", test.image, actual, test.expected)
+		}
+	}
+}
+
+func TestListAndRelocate(t *testing.T) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"banzaicloud/backyards:1.1.3",
+		"banzaicloud/istio-pilot:1.4.4-bzc",
+		"busybox",
+		"quay.io/jetstack/cert-manager-controller:v0.11.0",
+	}
+	if images := List(objects); !reflect.DeepEqual(images, expected) {
+		t.Fatalf("unexpected images: %v", images)
+	}
+
+	relocated := Relocate(objects, "registry.corp/mesh")
+	if len(relocated) != len(expected) {
+		t.Errorf("unexpected relocated images: %v", relocated)
+	}
+
+	yaml, err := objects.YAMLManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, image := range expected {
+		if !strings.Contains(yaml, "image: "+RelocatedName(image, "registry.corp/mesh")+"\n") {
+			t.Errorf("image %s is not relocated in the manifest", image)
+		}
+	}
+	if !strings.Contains(yaml, "type: string") {
+		t.Error("schema properties should be left untouched")
+	}
+}
+
+func TestWriteImageList(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteImageList(&buf, map[string]string{
+		"busybox":     "registry.corp/mesh/busybox",
+		"alpine:3.10": "registry.corp/mesh/alpine:3.10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "alpine:3.10 registry.corp/mesh/alpine:3.10\nbusybox registry.corp/mesh/busybox\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected image list:\n%s", buf.String())
+	}
+}
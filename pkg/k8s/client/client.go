@@ -16,6 +16,7 @@ package client
 import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -59,7 +60,15 @@ func NewClient(config *rest.Config, options Options) (Client, error) {
 		return nil, err
 	}
 
-	return c, nil
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logsClient{
+		Client:    c,
+		clientset: clientset,
+	}, nil
 }
 
 func NewClientFromKubeconfigAndContext(kubeconfigPath, kubeContext string) (Client, error) {
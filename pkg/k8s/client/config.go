@@ -14,6 +14,7 @@
 package client
 
 import (
+	"emperror.dev/errors"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
@@ -24,13 +25,75 @@ import (
 // GetConfig uses default strategy to load configuration from $KUBECONFIG,
 // .kube/config, or just returns in-cluster config.
 func GetConfigWithContext(kubeconfigPath, kubeContext string) (*rest.Config, error) {
+	return GetConfigWithOverrides(kubeconfigPath, kubeContext, AuthOverrides{})
+}
+
+// AuthOverrides override the credentials of the kubeconfig like the corresponding kubectl flags
+type AuthOverrides struct {
+	// Impersonate and ImpersonateGroups set the user and the groups to act as
+	Impersonate       string
+	ImpersonateGroups []string
+	// Token is a bearer token to authenticate with
+	Token string
+	// CertificateAuthority is the path of the CA file to verify the API server with
+	CertificateAuthority string
+}
+
+// Empty returns whether the kubeconfig is used as is
+func (o AuthOverrides) Empty() bool {
+	return o.Impersonate == "" && len(o.ImpersonateGroups) == 0 && o.Token == "" && o.CertificateAuthority == ""
+}
+
+// GetConfigWithOverrides returns kubernetes config like GetConfigWithContext, with the credentials overridden
+func GetConfigWithOverrides(kubeconfigPath, kubeContext string, auth AuthOverrides) (*rest.Config, error) {
+	if len(auth.ImpersonateGroups) > 0 && auth.Impersonate == "" {
+		return nil, errors.New("impersonating groups requires impersonating a user with --as")
+	}
+
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	if kubeconfigPath != "" {
 		rules.ExplicitPath = kubeconfigPath
 	}
-	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: kubeContext,
+		AuthInfo: api.AuthInfo{
+			Impersonate:       auth.Impersonate,
+			ImpersonateGroups: auth.ImpersonateGroups,
+			Token:             auth.Token,
+		},
+		ClusterInfo: api.Cluster{
+			CertificateAuthority: auth.CertificateAuthority,
+		},
+	}
+	if auth.Token == "" && auth.CertificateAuthority == "" {
+		return clientcmd.
+			NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).
+			ClientConfig()
+	}
+
+	// the overrides are merged into the kubeconfig, the credentials and the CA data they replace have to be removed first
+	config, err := rules.Load()
+	if err != nil {
+		return nil, err
+	}
+	if kubeContext == "" {
+		kubeContext = config.CurrentContext
+	}
+	if context, ok := config.Contexts[kubeContext]; ok {
+		if cluster, ok := config.Clusters[context.Cluster]; ok && auth.CertificateAuthority != "" {
+			cluster.CertificateAuthorityData = nil
+		}
+		if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok && auth.Token != "" {
+			config.AuthInfos[context.AuthInfo] = &api.AuthInfo{
+				Impersonate:          authInfo.Impersonate,
+				ImpersonateGroups:    authInfo.ImpersonateGroups,
+				ImpersonateUserExtra: authInfo.ImpersonateUserExtra,
+			}
+		}
+	}
+
 	return clientcmd.
-		NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).
+		NewNonInteractiveClientConfig(*config, kubeContext, overrides, rules).
 		ClientConfig()
 }
 
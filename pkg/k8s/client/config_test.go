@@ -0,0 +1,105 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority-data: Y2EtZGF0YQ==
+  name: cluster
+contexts:
+- context: {cluster: cluster, user: admin}
+  name: admin
+current-context: admin
+users:
+- name: admin
+  user:
+    client-certificate-data: Y2VydC1kYXRh
+    client-key-data: a2V5LWRhdGE=
+`
+
+func writeTestKubeconfig(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "config")
+	err := ioutil.WriteFile(path, []byte(testKubeconfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestGetConfigWithOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig := writeTestKubeconfig(t, dir)
+
+	config, err := GetConfigWithOverrides(kubeconfig, "", AuthOverrides{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(config.CertData) != "cert-data" || string(config.CAData) != "ca-data" || config.BearerToken != "" {
+		t.Errorf("kubeconfig is not used as is: %+v", config)
+	}
+
+	ca := filepath.Join(dir, "ca.crt")
+	err = ioutil.WriteFile(ca, []byte("ca-file"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = GetConfigWithOverrides(kubeconfig, "", AuthOverrides{
+		Impersonate:          "jane",
+		ImpersonateGroups:    []string{"developers"},
+		Token:                "override-token",
+		CertificateAuthority: ca,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.BearerToken != "override-token" || len(config.CertData) > 0 || len(config.KeyData) > 0 {
+		t.Errorf("the token does not replace the client certificate: %+v", config)
+	}
+	if config.CAFile != ca || len(config.CAData) > 0 {
+		t.Errorf("the certificate authority is not replaced: %+v", config.TLSClientConfig)
+	}
+	if config.Impersonate.UserName != "jane" || len(config.Impersonate.Groups) != 1 || config.Impersonate.Groups[0] != "developers" {
+		t.Errorf("unexpected impersonation: %+v", config.Impersonate)
+	}
+}
+
+func TestGetConfigWithGroupsOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = GetConfigWithOverrides(writeTestKubeconfig(t, dir), "", AuthOverrides{ImpersonateGroups: []string{"developers"}})
+	if err == nil {
+		t.Error("expected an error for impersonated groups without a user")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"emperror.dev/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodLogsReader reads the logs of pod containers, the clients created by NewClient implement it
+type PodLogsReader interface {
+	// PodLogs returns the last lines of the log of a container, or of its previous instance if previous is set
+	PodLogs(namespace, pod, container string, tailLines int64, previous bool) (string, error)
+}
+
+type logsClient struct {
+	client.Client
+
+	clientset kubernetes.Interface
+}
+
+func (c *logsClient) PodLogs(namespace, pod, container string, tailLines int64, previous bool) (string, error) {
+	content, err := c.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+		Previous:  previous,
+	}).DoRaw()
+	if err != nil {
+		return "", errors.WrapIfWithDetails(err, "could not get pod logs", "namespace", namespace, "pod", pod, "container", container)
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,338 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+const (
+	// number of log lines shown of crashlooping containers
+	diagnosisLogLines = 10
+	// number of the latest Warning events shown per object
+	diagnosisEventLimit = 5
+)
+
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// WorkloadDiagnosis describes why the pods of a workload are not ready
+type WorkloadDiagnosis struct {
+	Name   string
+	Events []string
+	Pods   []PodDiagnosis
+}
+
+type PodDiagnosis struct {
+	Name          string
+	Phase         corev1.PodPhase
+	Unschedulable string
+	Events        []string
+	Containers    []ContainerDiagnosis
+}
+
+type ContainerDiagnosis struct {
+	Name           string
+	Image          string
+	Ready          bool
+	RestartCount   int32
+	State          string
+	ImagePullError string
+	Log            string
+}
+
+// DiagnoseWorkload collects the state of the pods owned by a Deployment, StatefulSet, DaemonSet or Job,
+// nil is returned for other objects.
+// The logs of crashlooping containers are only collected if the client implements k8sclient.PodLogsReader.
+func DiagnoseWorkload(cl k8sclient.Client, obj *unstructured.Unstructured) (*WorkloadDiagnosis, error) {
+	selector, owners, err := workloadPodSelector(cl, obj)
+	if err != nil || selector == nil {
+		return nil, err
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, errors.WrapIf(err, "invalid pod selector")
+	}
+
+	var pods corev1.PodList
+	err = cl.List(context.Background(), &pods, client.UseListOptions(&client.ListOptions{
+		Namespace:     obj.GetNamespace(),
+		LabelSelector: labelSelector,
+	}))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not list pods")
+	}
+
+	var events corev1.EventList
+	err = cl.List(context.Background(), &events, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not list events")
+	}
+
+	diagnosis := &WorkloadDiagnosis{
+		Name:   GetFormattedName(obj),
+		Events: warningEvents(events.Items, obj.GetKind(), obj.GetName()),
+	}
+
+	for _, pod := range pods.Items {
+		if !ownedBy(pod.ObjectMeta, owners) {
+			continue
+		}
+		diagnosis.Pods = append(diagnosis.Pods, diagnosePod(cl, pod, events.Items))
+	}
+
+	return diagnosis, nil
+}
+
+// workloadPodSelector returns the pod selector of the workload and the UIDs of the direct owners of its pods
+func workloadPodSelector(cl k8sclient.Client, obj *unstructured.Unstructured) (*metav1.LabelSelector, map[types.UID]bool, error) {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	switch obj.GetKind() {
+	case "Deployment":
+		var deployment appsv1.Deployment
+		err := cl.Get(context.Background(), key, &deployment)
+		if err != nil {
+			return nil, nil, errors.WrapIf(err, "could not get deployment")
+		}
+
+		// the pods of a deployment are owned by its replica sets
+		var replicasets appsv1.ReplicaSetList
+		err = cl.List(context.Background(), &replicasets, client.InNamespace(obj.GetNamespace()))
+		if err != nil {
+			return nil, nil, errors.WrapIf(err, "could not list replica sets")
+		}
+		owners := make(map[types.UID]bool)
+		for _, rs := range replicasets.Items {
+			if ownedBy(rs.ObjectMeta, map[types.UID]bool{deployment.UID: true}) {
+				owners[rs.UID] = true
+			}
+		}
+
+		return deployment.Spec.Selector, owners, nil
+	case "StatefulSet":
+		var statefulset appsv1.StatefulSet
+		err := cl.Get(context.Background(), key, &statefulset)
+		if err != nil {
+			return nil, nil, errors.WrapIf(err, "could not get statefulset")
+		}
+
+		return statefulset.Spec.Selector, map[types.UID]bool{statefulset.UID: true}, nil
+	case "DaemonSet":
+		var daemonset appsv1.DaemonSet
+		err := cl.Get(context.Background(), key, &daemonset)
+		if err != nil {
+			return nil, nil, errors.WrapIf(err, "could not get daemonset")
+		}
+
+		return daemonset.Spec.Selector, map[types.UID]bool{daemonset.UID: true}, nil
+	case "Job":
+		var job batchv1.Job
+		err := cl.Get(context.Background(), key, &job)
+		if err != nil {
+			return nil, nil, errors.WrapIf(err, "could not get job")
+		}
+
+		return job.Spec.Selector, map[types.UID]bool{job.UID: true}, nil
+	}
+
+	return nil, nil, nil
+}
+
+func ownedBy(meta metav1.ObjectMeta, owners map[types.UID]bool) bool {
+	for _, ref := range meta.OwnerReferences {
+		if owners[ref.UID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func diagnosePod(cl k8sclient.Client, pod corev1.Pod, events []corev1.Event) PodDiagnosis {
+	diagnosis := PodDiagnosis{
+		Name:   pod.Name,
+		Phase:  pod.Status.Phase,
+		Events: warningEvents(events, "Pod", pod.Name),
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			diagnosis.Unschedulable = condition.Message
+		}
+	}
+
+	images := make(map[string]string)
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		images[container.Name] = container.Image
+	}
+
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		container := ContainerDiagnosis{
+			Name:         status.Name,
+			Image:        images[status.Name],
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+			State:        containerState(status.State),
+		}
+
+		if waiting := status.State.Waiting; waiting != nil {
+			if imagePullReasons[waiting.Reason] {
+				container.ImagePullError = waiting.Message
+				if container.ImagePullError == "" {
+					container.ImagePullError = waiting.Reason
+				}
+			}
+			if waiting.Reason == "CrashLoopBackOff" {
+				container.Log = containerLog(cl, pod, status.Name)
+			}
+		}
+
+		diagnosis.Containers = append(diagnosis.Containers, container)
+	}
+
+	return diagnosis
+}
+
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Waiting != nil:
+		return fmt.Sprintf("waiting (%s)", state.Waiting.Reason)
+	case state.Terminated != nil:
+		return fmt.Sprintf("terminated (%s, exit code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	case state.Running != nil:
+		return "running"
+	}
+
+	return "unknown"
+}
+
+// containerLog returns the last lines of the previous instance of a crashlooping container
+func containerLog(cl k8sclient.Client, pod corev1.Pod, container string) string {
+	reader, ok := cl.(k8sclient.PodLogsReader)
+	if !ok {
+		return ""
+	}
+
+	content, err := reader.PodLogs(pod.Namespace, pod.Name, container, diagnosisLogLines, true)
+	if err != nil {
+		return fmt.Sprintf("could not get logs: %s", err)
+	}
+
+	return strings.TrimRight(content, "\n")
+}
+
+// warningEvents returns the latest Warning events of an object
+func warningEvents(events []corev1.Event, kind, name string) []string {
+	matching := make([]corev1.Event, 0)
+	for _, event := range events {
+		if event.Type == corev1.EventTypeWarning && event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+			matching = append(matching, event)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].LastTimestamp.After(matching[j].LastTimestamp.Time)
+	})
+	if len(matching) > diagnosisEventLimit {
+		matching = matching[:diagnosisEventLimit]
+	}
+
+	messages := make([]string, 0, len(matching))
+	for _, event := range matching {
+		message := fmt.Sprintf("%s: %s", event.Reason, event.Message)
+		if event.Count > 1 {
+			message = fmt.Sprintf("%s (x%d)", message, event.Count)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+func (d *WorkloadDiagnosis) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s is not ready", d.Name)
+	if len(d.Pods) == 0 {
+		b.WriteString(", no pods found")
+	}
+	b.WriteString("\n")
+	writeEvents(&b, "  ", d.Events)
+
+	for _, pod := range d.Pods {
+		fmt.Fprintf(&b, "  pod %s: %s\n", pod.Name, pod.Phase)
+		if pod.Unschedulable != "" {
+			fmt.Fprintf(&b, "    unschedulable: %s\n", pod.Unschedulable)
+		}
+		for _, container := range pod.Containers {
+			ready := "not ready"
+			if container.Ready {
+				ready = "ready"
+			}
+			fmt.Fprintf(&b, "    container %s: %s, %s, %d restarts\n", container.Name, container.State, ready, container.RestartCount)
+			if container.ImagePullError != "" {
+				fmt.Fprintf(&b, "      image pull error for %s: %s\n", container.Image, container.ImagePullError)
+			}
+			if container.Log != "" {
+				b.WriteString("      last log lines:\n")
+				for _, line := range strings.Split(container.Log, "\n") {
+					fmt.Fprintf(&b, "        %s\n", line)
+				}
+			}
+		}
+		writeEvents(&b, "    ", pod.Events)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func writeEvents(b *strings.Builder, indent string, events []string) {
+	for _, event := range events {
+		fmt.Fprintf(b, "%swarning event: %s\n", indent, event)
+	}
+}
+
+// workloadDiagnosisText returns the diagnosis of the workload as text, or an empty string for objects other than workloads
+func workloadDiagnosisText(cl k8sclient.Client, obj *unstructured.Unstructured) string {
+	diagnosis, err := DiagnoseWorkload(cl, obj)
+	if err != nil {
+		return fmt.Sprintf("could not diagnose %s: %s", GetFormattedName(obj), err)
+	}
+	if diagnosis == nil {
+		return ""
+	}
+
+	return diagnosis.String()
+}
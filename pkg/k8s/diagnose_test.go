@@ -0,0 +1,130 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type logsClient struct {
+	client.Client
+}
+
+func (c logsClient) PodLogs(namespace, pod, container string, tailLines int64, previous bool) (string, error) {
+	return "panic: config not found\n", nil
+}
+
+func TestDiagnoseWorkload(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "demo", UID: "deployment"},
+		Spec:       appsv1.DeploymentSpec{Selector: selector},
+	}
+	replicaset := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "demo", UID: "replicaset",
+			OwnerReferences: []metav1.OwnerReference{{UID: "deployment"}}},
+	}
+	owner := []metav1.OwnerReference{{UID: "replicaset"}}
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1-a", Namespace: "demo", Labels: selector.MatchLabels, OwnerReferences: owner},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1"}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				RestartCount: 4,
+				State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		},
+	}
+	pulling := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1-b", Namespace: "demo", Labels: selector.MatchLabels, OwnerReferences: owner},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1"}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "manifest unknown"}},
+			}},
+		},
+	}
+	unschedulable := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1-c", Namespace: "demo", Labels: selector.MatchLabels, OwnerReferences: owner},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}},
+		},
+	}
+	foreign := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "demo", Labels: selector.MatchLabels},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "app-1-c.1", Namespace: "demo"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "app-1-c"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available",
+		Count:          3,
+	}
+
+	cl := logsClient{fake.NewFakeClientWithScheme(scheme.Scheme, deployment, replicaset, crashing, pulling, unschedulable, foreign, event)}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("demo")
+	obj.SetName("app")
+
+	diagnosis, err := DiagnoseWorkload(cl, obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(diagnosis.Pods) != 3 {
+		t.Fatalf("pods not owned by the deployment should be skipped, got %d pods", len(diagnosis.Pods))
+	}
+
+	text := diagnosis.String()
+	for _, expected := range []string{
+		"container app: waiting (CrashLoopBackOff), not ready, 4 restarts",
+		"        panic: config not found",
+		"image pull error for app:1: manifest unknown",
+		"unschedulable: 0/3 nodes are available: 3 Insufficient cpu.",
+		"warning event: FailedScheduling: 0/3 nodes are available (x3)",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("diagnosis should contain %q:\n%s", expected, text)
+		}
+	}
+
+	obj.SetKind("ConfigMap")
+	diagnosis, err = DiagnoseWorkload(cl, obj)
+	if err != nil || diagnosis != nil {
+		t.Errorf("objects other than workloads should not be diagnosed: %v, %v", diagnosis, err)
+	}
+}
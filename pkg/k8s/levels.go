@@ -0,0 +1,120 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"istio.io/operator/pkg/object"
+)
+
+// ObjectLevel is the dependency level of an object, the objects of a level only depend on the objects of the previous levels
+type ObjectLevel int
+
+const (
+	CRDLevel ObjectLevel = iota
+	NamespaceLevel
+	ConfigLevel
+	WorkloadLevel
+	CustomResourceLevel
+)
+
+var (
+	objectLevelNames = map[ObjectLevel]string{
+		CRDLevel:            "custom resource definitions",
+		NamespaceLevel:      "namespaces",
+		ConfigLevel:         "RBAC and configuration",
+		WorkloadLevel:       "workloads",
+		CustomResourceLevel: "custom resources",
+	}
+
+	workloadKinds = map[string]bool{
+		"Pod":                     true,
+		"ReplicationController":   true,
+		"ReplicaSet":              true,
+		"Deployment":              true,
+		"StatefulSet":             true,
+		"DaemonSet":               true,
+		"Job":                     true,
+		"CronJob":                 true,
+		"HorizontalPodAutoscaler": true,
+	}
+
+	// objects served by the workloads, those are applied together with the custom resources
+	servedKinds = map[string]bool{
+		"MutatingWebhookConfiguration":   true,
+		"ValidatingWebhookConfiguration": true,
+		"APIService":                     true,
+	}
+
+	builtinGroups = map[string]bool{
+		"":                             true,
+		"admissionregistration.k8s.io": true,
+		"apiextensions.k8s.io":         true,
+		"apiregistration.k8s.io":       true,
+		"apps":                         true,
+		"auditregistration.k8s.io":     true,
+		"autoscaling":                  true,
+		"batch":                        true,
+		"certificates.k8s.io":          true,
+		"coordination.k8s.io":          true,
+		"extensions":                   true,
+		"networking.k8s.io":            true,
+		"node.k8s.io":                  true,
+		"policy":                       true,
+		"rbac.authorization.k8s.io":    true,
+		"scheduling.k8s.io":            true,
+		"storage.k8s.io":               true,
+	}
+)
+
+func (l ObjectLevel) String() string {
+	return objectLevelNames[l]
+}
+
+// GetObjectLevel returns the dependency level of the object
+func GetObjectLevel(o *object.K8sObject) ObjectLevel {
+	switch {
+	case o.Kind == "CustomResourceDefinition":
+		return CRDLevel
+	case o.Kind == "Namespace":
+		return NamespaceLevel
+	case !builtinGroups[o.Group] || servedKinds[o.Kind]:
+		return CustomResourceLevel
+	case workloadKinds[o.Kind]:
+		return WorkloadLevel
+	}
+
+	return ConfigLevel
+}
+
+// ObjectsByLevel groups the objects by their dependency level, keeping their order within a level.
+// Empty levels are left out.
+func ObjectsByLevel(objects object.K8sObjects) ([]ObjectLevel, []object.K8sObjects) {
+	grouped := make(map[ObjectLevel]object.K8sObjects)
+	for _, o := range objects {
+		level := GetObjectLevel(o)
+		grouped[level] = append(grouped[level], o)
+	}
+
+	levels := make([]ObjectLevel, 0, len(grouped))
+	groups := make([]object.K8sObjects, 0, len(grouped))
+	for level := CRDLevel; level <= CustomResourceLevel; level++ {
+		if len(grouped[level]) > 0 {
+			levels = append(levels, level)
+			groups = append(groups, grouped[level])
+		}
+	}
+
+	return levels, groups
+}
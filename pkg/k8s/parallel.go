@@ -0,0 +1,177 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"sync"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"istio.io/operator/pkg/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+// syncLabelManager serializes the calls of a label manager, since those might interactively ask the user
+type syncLabelManager struct {
+	mu sync.Mutex
+
+	labelManager LabelManager
+}
+
+func (m *syncLabelManager) CheckLabelsBeforeUpdate(actual, desired *unstructured.Unstructured) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.labelManager.CheckLabelsBeforeUpdate(actual, desired)
+}
+
+func (m *syncLabelManager) CheckLabelsBeforeCreate(actual *unstructured.Unstructured) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.labelManager.CheckLabelsBeforeCreate(actual)
+}
+
+func (m *syncLabelManager) CheckLabelsBeforeDelete(actual *unstructured.Unstructured) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.labelManager.CheckLabelsBeforeDelete(actual)
+}
+
+// ApplyResourcesByLevel applies the objects level by level, see ObjectLevel.
+// The objects of a level are applied concurrently by at most parallelism workers,
+// then every object of the level is waited for concurrently before the next level is started.
+func ApplyResourcesByLevel(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, options ApplyOptions, parallelism int, backoff wait.Backoff, progress *Progress) error {
+	lm := &syncLabelManager{
+		labelManager: labelManager,
+	}
+
+	levels, groups := ObjectsByLevel(objects)
+	for i, level := range levels {
+		log.Debugf("applying %s", level)
+
+		err := applyResourcesConcurrently(client, lm, groups[i], options, parallelism)
+		if err != nil {
+			return err
+		}
+
+		checkFuncs := []ResourceConditionCheck{ExistsConditionCheck, ReadyReplicasConditionCheck, StatefulSetReadyConditionCheck, DaemonSetReadyConditionCheck, JobCompleteConditionCheck}
+		if level == CRDLevel {
+			checkFuncs = []ResourceConditionCheck{ExistsConditionCheck, CRDEstablishedConditionCheck}
+		}
+
+		err = WaitForResourcesConditionsConcurrently(client, NamesWithGVKFromK8sObjects(groups[i]), backoff, progress, checkFuncs...)
+		if err != nil {
+			return errors.WrapIfWithDetails(err, "resources did not become ready", "level", level.String())
+		}
+	}
+
+	return nil
+}
+
+func applyResourcesConcurrently(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, options ApplyOptions, parallelism int) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var combinedErr error
+
+	sem := make(chan struct{}, parallelism)
+	for _, obj := range objects {
+		obj := obj
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			_, err := applyResource(client, labelManager, obj, options)
+			if err != nil {
+				mu.Lock()
+				combinedErr = errors.Combine(combinedErr, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return combinedErr
+}
+
+// WaitForResourcesConditionsConcurrently waits for every object in parallel and reports the pending ones to the progress
+func WaitForResourcesConditionsConcurrently(client k8sclient.Client, objects []NamespacedNameWithGVK, backoff wait.Backoff, progress *Progress, checkFuncs ...ResourceConditionCheck) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var combinedErr error
+	timedOut := false
+
+	for _, o := range objects {
+		obj := o.Unstructured()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			name := GetFormattedName(obj)
+			progress.Pending(name, "waiting")
+
+			err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+				resource := obj.DeepCopy()
+				err := client.Get(context.Background(), types.NamespacedName{
+					Name:      resource.GetName(),
+					Namespace: resource.GetNamespace(),
+				}, resource)
+				for _, fn := range checkFuncs {
+					if !fn(resource, err) {
+						progress.Pending(name, PendingReason(resource, err))
+						return false, nil
+					}
+				}
+				return true, ResourceFailure(resource, err)
+			})
+			if err != nil {
+				diagnosis := ""
+				if err == wait.ErrWaitTimeout || errors.Is(err, ErrJobFailed) {
+					diagnosis = workloadDiagnosisText(client, obj)
+				}
+				progress.Failed(name, err, diagnosis)
+
+				mu.Lock()
+				combinedErr = errors.Combine(combinedErr, errors.WrapIfWithDetails(err, "wait failed", "name", name))
+				timedOut = timedOut || err == wait.ErrWaitTimeout
+				mu.Unlock()
+				return
+			}
+
+			progress.Done(name)
+		}()
+	}
+	wg.Wait()
+
+	if timedOut {
+		log.Errorf("%s: For a potential solution, check out the docs: %s", wait.ErrWaitTimeout.Error(), "https://banzaicloud.com/docs/backyards/faq/#timed-out-waiting")
+	}
+
+	return combinedErr
+}
@@ -0,0 +1,224 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const levelsManifest = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: demo
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: widgets
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: demo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: demo
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: role
+  namespace: demo
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`
+
+func TestObjectsByLevel(t *testing.T) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(levelsManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels, groups := ObjectsByLevel(objects)
+
+	got := make([]string, 0, len(levels))
+	for i, level := range levels {
+		kinds := make([]string, 0, len(groups[i]))
+		for _, o := range groups[i] {
+			kinds = append(kinds, o.Kind)
+		}
+		got = append(got, fmt.Sprintf("%s: %s", level, strings.Join(kinds, ",")))
+	}
+
+	expected := []string{
+		"custom resource definitions: CustomResourceDefinition",
+		"namespaces: Namespace",
+		"RBAC and configuration: ConfigMap,Role",
+		"workloads: Deployment",
+		"custom resources: Widget,ValidatingWebhookConfiguration",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected levels:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestApplyResourcesByLevel(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: demo
+`
+	for i := 0; i < 10; i++ {
+		manifest += fmt.Sprintf(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-%d
+  namespace: demo
+`, i)
+	}
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := fake.NewFakeClientWithScheme(scheme.Scheme)
+	var out bytes.Buffer
+
+	err = ApplyResourcesByLevel(client, testLabelManager{}, objects, ApplyOptions{}, 3, wait.Backoff{Duration: time.Millisecond, Steps: 1}, NewProgress(&out, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		var cm corev1.ConfigMap
+		if err := client.Get(context.Background(), types.NamespacedName{Namespace: "demo", Name: fmt.Sprintf("config-%d", i)}, &cm); err != nil {
+			t.Errorf("config map %d was not applied: %s", i, err)
+		}
+	}
+
+	var deployment appsv1.Deployment
+	if err := client.Get(context.Background(), types.NamespacedName{Namespace: "demo", Name: "app"}, &deployment); err != nil {
+		t.Errorf("deployment was not applied: %s", err)
+	}
+
+	if !strings.HasSuffix(out.String(), "\033[J") {
+		t.Errorf("progress should be cleared at the end: %q", out.String())
+	}
+}
+
+func TestWaitForResourcesConditionsConcurrently(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "demo"},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 1},
+	}
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, deployment)
+
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: demo
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = WaitForResourcesConditionsConcurrently(client, NamesWithGVKFromK8sObjects(objects), wait.Backoff{Duration: time.Millisecond, Steps: 2}, NewProgress(&out, true),
+		ExistsConditionCheck, ReadyReplicasConditionCheck)
+	if err == nil {
+		t.Fatal("waiting for a deployment without ready replicas should time out")
+	}
+
+	if !strings.Contains(out.String(), "deployment.apps:demo/app: 1/2 replicas ready") {
+		t.Errorf("progress should show the pending reason: %q", out.String())
+	}
+}
+
+func TestWaitForFailedJob(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "demo"},
+		Status: batchv1.JobStatus{Failed: 1, Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+		}},
+	}
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, job)
+
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: demo
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the backoff would wait for an hour if the failure was not detected
+	backoff := wait.Backoff{Duration: time.Minute, Steps: 60}
+
+	var out bytes.Buffer
+	err = WaitForResourcesConditionsConcurrently(client, NamesWithGVKFromK8sObjects(objects), backoff, NewProgress(&out, true),
+		ExistsConditionCheck, JobCompleteConditionCheck)
+	if !errors.Is(err, ErrJobFailed) {
+		t.Fatalf("waiting for a failed job should fail, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "backoff limit") {
+		t.Errorf("the error should contain the reason of the failure: %s", err)
+	}
+
+	err = WaitForResourceConditions(backoff, ExistsConditionCheck, JobCompleteConditionCheck)(client, NamesWithGVKFromK8sObjects(objects)[0].Unstructured())
+	if !errors.Is(err, ErrJobFailed) {
+		t.Fatalf("waiting for a failed job should fail, got %v", err)
+	}
+}
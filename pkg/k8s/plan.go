@@ -0,0 +1,295 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/banzaicloud/backyards-cli/pkg/diff"
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+)
+
+type PlanAction string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanUpdate    PlanAction = "update"
+	PlanDelete    PlanAction = "delete"
+	PlanUnchanged PlanAction = "unchanged"
+	PlanSkip      PlanAction = "skip"
+)
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// PlanItem describes what would happen to a single resource
+type PlanItem struct {
+	Name      string
+	Action    PlanAction
+	Ownership string
+	Diff      string
+}
+
+// PlanResources calculates the changes ApplyResources would make without modifying anything in the cluster.
+// The label manager should not be interactive, otherwise the ownership decisions are asked for.
+func PlanResources(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, color bool) ([]PlanItem, error) {
+	items := make([]PlanItem, 0, len(objects))
+
+	for _, obj := range objects {
+		actual := obj.UnstructuredObject().DeepCopy()
+		desired := obj.UnstructuredObject().DeepCopy()
+
+		item := PlanItem{
+			Name: GetFormattedName(desired),
+		}
+
+		err := client.Get(context.Background(), types.NamespacedName{
+			Name:      actual.GetName(),
+			Namespace: actual.GetNamespace(),
+		}, actual)
+		if err != nil && !k8serrors.IsNotFound(err) && !k8smeta.IsNoMatchError(err) {
+			return nil, errors.WrapIfWithDetails(err, "could not get resource", "name", item.Name)
+		}
+
+		if err != nil {
+			skip, err := labelManager.CheckLabelsBeforeCreate(desired)
+			if err != nil {
+				return nil, errors.WrapIfWithDetails(err, "could not check labels", "name", item.Name)
+			}
+			if skip {
+				item.Action = PlanSkip
+				item.Ownership = "skipped by the label manager"
+				items = append(items, item)
+				continue
+			}
+			item.Action = PlanCreate
+			item.Ownership = "new, will be managed by the CLI"
+			item.Diff, err = resourceDiff(nil, desired, color)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		skip, err := labelManager.CheckLabelsBeforeUpdate(actual, desired)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not check labels", "name", item.Name)
+		}
+		if skip {
+			item.Action = PlanSkip
+			item.Ownership = "exists but not managed by the CLI, skipped unless taken over interactively"
+			items = append(items, item)
+			continue
+		}
+		item.Ownership = "managed by the CLI"
+
+		desired.SetResourceVersion(actual.GetResourceVersion())
+		patchResult, err := patch.DefaultPatchMaker.Calculate(actual, desired)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not match objects", "name", item.Name)
+		}
+		if patchResult.IsEmpty() {
+			item.Action = PlanUnchanged
+			items = append(items, item)
+			continue
+		}
+
+		item.Action = PlanUpdate
+		previous := patchResult.Original
+		if len(previous) == 0 {
+			previous = patchResult.Current
+		}
+		item.Diff, err = resourceDiff(previous, desired, color)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// PlanDeletions calculates which of the objects removed from the desired state would be deleted from the cluster,
+// objects already missing or not managed by the CLI are left out. The ownership is told by the version label set by the CLI.
+func PlanDeletions(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, versionLabel string, color bool) ([]PlanItem, error) {
+	items := make([]PlanItem, 0, len(objects))
+
+	for _, obj := range objects {
+		actual := obj.UnstructuredObject().DeepCopy()
+		name := GetFormattedName(actual)
+
+		err := client.Get(context.Background(), types.NamespacedName{
+			Name:      actual.GetName(),
+			Namespace: actual.GetNamespace(),
+		}, actual)
+		if k8serrors.IsNotFound(err) || k8smeta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not get resource", "name", name)
+		}
+
+		skip, err := labelManager.CheckLabelsBeforeDelete(actual)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not check labels", "name", name)
+		}
+		if skip {
+			continue
+		}
+
+		from, err := cleanYAML(actual)
+		if err != nil {
+			return nil, err
+		}
+		ownership := "not managed by the CLI, no longer desired"
+		if version, ok := actual.GetLabels()[versionLabel]; ok {
+			ownership = fmt.Sprintf("managed by the CLI (version %s), no longer desired", version)
+		}
+		items = append(items, PlanItem{
+			Name:      name,
+			Action:    PlanDelete,
+			Ownership: ownership,
+			Diff:      diff.Unified("current", "desired", diff.Lines(from), nil, 3, color),
+		})
+	}
+
+	return items, nil
+}
+
+// PlanPatch describes the change of a resource patched in place, e.g. a resource owned by another component
+func PlanPatch(current, desired *unstructured.Unstructured, ownership string, color bool) (PlanItem, error) {
+	item := PlanItem{
+		Name:      GetFormattedName(desired),
+		Action:    PlanUnchanged,
+		Ownership: ownership,
+	}
+
+	from, err := cleanYAML(current)
+	if err != nil {
+		return item, err
+	}
+	to, err := cleanYAML(desired)
+	if err != nil {
+		return item, err
+	}
+	if from != to {
+		item.Action = PlanUpdate
+		item.Diff = diff.Unified("current", "desired", diff.Lines(from), diff.Lines(to), 3, color)
+	}
+
+	return item, nil
+}
+
+// PrintPlan writes a summary line for every planned change followed by the diff of the resource
+func PrintPlan(out io.Writer, items []PlanItem, color bool) {
+	counts := make(map[PlanAction]int)
+	for _, item := range items {
+		counts[item.Action]++
+
+		symbol, itemColor := planSymbol(item.Action)
+		line := fmt.Sprintf("%s %s %s", symbol, item.Name, item.Action)
+		if color && itemColor != "" {
+			line = itemColor + line + colorReset
+		}
+		fmt.Fprintf(out, "%s (%s)\n", line, item.Ownership)
+
+		if item.Diff != "" {
+			fmt.Fprintf(out, "%s\n", item.Diff)
+		}
+	}
+
+	fmt.Fprintf(out, "\nPlan: %d to create, %d to update, %d to delete, %d unchanged, %d skipped\n",
+		counts[PlanCreate], counts[PlanUpdate], counts[PlanDelete], counts[PlanUnchanged], counts[PlanSkip])
+}
+
+func planSymbol(action PlanAction) (string, string) {
+	switch action {
+	case PlanCreate:
+		return "+", colorGreen
+	case PlanUpdate:
+		return "~", colorYellow
+	case PlanDelete:
+		return "-", colorRed
+	case PlanSkip:
+		return "!", colorYellow
+	default:
+		return "=", ""
+	}
+}
+
+// resourceDiff renders the unified diff between the previously applied JSON configuration and the desired object
+func resourceDiff(previous []byte, desired *unstructured.Unstructured, color bool) (string, error) {
+	var from string
+	if len(previous) > 0 {
+		var obj map[string]interface{}
+		err := json.Unmarshal(previous, &obj)
+		if err != nil {
+			return "", errors.WrapIf(err, "could not unmarshal previous configuration")
+		}
+		from, err = cleanYAML(&unstructured.Unstructured{Object: obj})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	to, err := cleanYAML(desired)
+	if err != nil {
+		return "", err
+	}
+
+	return diff.Unified("current", "desired", diff.Lines(from), diff.Lines(to), 3, color), nil
+}
+
+// cleanYAML renders an object without the fields maintained by the API server and the last applied annotation
+func cleanYAML(obj *unstructured.Unstructured) (string, error) {
+	obj = obj.DeepCopy()
+	for _, field := range []string{"resourceVersion", "uid", "selfLink", "creationTimestamp", "generation", "managedFields"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[patch.LastAppliedConfig]; ok {
+		delete(annotations, patch.LastAppliedConfig)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	content, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", errors.WrapIf(err, "could not marshal object")
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,192 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/operator/pkg/object"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+)
+
+type testLabelManager struct{}
+
+func (testLabelManager) CheckLabelsBeforeUpdate(actual, desired *unstructured.Unstructured) (bool, error) {
+	return actual.GetLabels()["managed"] != "true", nil
+}
+
+func (testLabelManager) CheckLabelsBeforeCreate(actual *unstructured.Unstructured) (bool, error) {
+	return false, nil
+}
+
+func (testLabelManager) CheckLabelsBeforeDelete(actual *unstructured.Unstructured) (bool, error) {
+	return false, nil
+}
+
+func TestPlanResources(t *testing.T) {
+	managed := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "managed", Namespace: "default", Labels: map[string]string{"managed": "true"}},
+		Data:       map[string]string{"key": "old"},
+	}
+	if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(managed); err != nil {
+		t.Fatal(err)
+	}
+	unmanaged := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"},
+	}
+
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, managed, unmanaged)
+
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: managed
+  namespace: default
+  labels:
+    managed: "true"
+data:
+  key: new
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unmanaged
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: new
+  namespace: default
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := PlanResources(client, testLabelManager{}, objects, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	actions := make(map[string]PlanItem)
+	for _, item := range items {
+		actions[item.Name] = item
+	}
+
+	if item := actions["configmap:default/managed"]; item.Action != PlanUpdate || !strings.Contains(item.Diff, "-  key: old\n+  key: new") {
+		t.Errorf("unexpected plan for managed resource: %+v", item)
+	}
+	if item := actions["configmap:default/unmanaged"]; item.Action != PlanSkip {
+		t.Errorf("unexpected plan for unmanaged resource: %+v", item)
+	}
+	if item := actions["configmap:default/new"]; item.Action != PlanCreate || !strings.Contains(item.Diff, "+  name: new") {
+		t.Errorf("unexpected plan for new resource: %+v", item)
+	}
+}
+
+type protectingLabelManager struct {
+	testLabelManager
+}
+
+func (protectingLabelManager) CheckLabelsBeforeDelete(actual *unstructured.Unstructured) (bool, error) {
+	return actual.GetLabels()["managed"] != "true", nil
+}
+
+func TestPlanDeletions(t *testing.T) {
+	managed := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "managed", Namespace: "default", Labels: map[string]string{"managed": "true", "version": "1.0.0"}},
+		Data:       map[string]string{"key": "value"},
+	}
+	unmanaged := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"},
+	}
+
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, managed, unmanaged)
+
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: managed
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unmanaged
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: missing
+  namespace: default
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := PlanDeletions(client, protectingLabelManager{}, objects, "version", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("expected only the managed resource to be deleted, got %+v", items)
+	}
+	if item := items[0]; item.Name != "configmap:default/managed" || item.Action != PlanDelete || !strings.Contains(item.Diff, "-  key: value") ||
+		item.Ownership != "managed by the CLI (version 1.0.0), no longer desired" {
+		t.Errorf("unexpected plan for managed resource: %+v", item)
+	}
+}
+
+func TestPlanPatch(t *testing.T) {
+	current := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "patched", "namespace": "default", "resourceVersion": "1"},
+		"data":       map[string]interface{}{"key": "old"},
+	}}
+
+	item, err := PlanPatch(current, current, "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if item.Action != PlanUnchanged || item.Diff != "" {
+		t.Errorf("unexpected plan for unchanged resource: %+v", item)
+	}
+
+	desired := current.DeepCopy()
+	if err := unstructured.SetNestedField(desired.Object, "new", "data", "key"); err != nil {
+		t.Fatal(err)
+	}
+	item, err = PlanPatch(current, desired, "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if item.Action != PlanUpdate || !strings.Contains(item.Diff, "-  key: old\n+  key: new") {
+		t.Errorf("unexpected plan for patched resource: %+v", item)
+	}
+}
@@ -20,11 +20,14 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
-	"net/url"
+	"sync"
+	"time"
 
 	"emperror.dev/errors"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
@@ -34,48 +37,70 @@ import (
 	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
 )
 
+// State is the state of the connection of a port-forward
+type State string
+
+const (
+	StateConnecting   State = "connecting"
+	StateConnected    State = "connected"
+	StateReconnecting State = "reconnecting"
+	StateStopped      State = "stopped"
+)
+
+// StateCallback is called on every state change of the connection with the name of the pod and the error causing the change
+type StateCallback func(state State, pod string, err error)
+
+const (
+	// interval of checking whether the pod of the connection is still ready
+	podCheckInterval = 5 * time.Second
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+var ErrConnectionLost = errors.New("lost connection to pod")
+
+// Portforward forwards a local port to a pod.
+// The port-forward reconnects when the connection is lost, and if it was created by pod labels,
+// it selects a ready pod again when the pod is deleted or it is not ready anymore.
 type Portforward struct {
-	namespace  string
-	podname    string
-	localPort  int
-	remotePort int
-	url        *url.URL
+	namespace   string
+	podname     string
+	matchLabels map[string]string
+	localPort   int
+	remotePort  int
 
 	stopChannel  chan struct{}
+	stopOnce     sync.Once
 	readyChannel chan struct{}
+	readyOnce    sync.Once
+
+	mu            sync.Mutex
+	stateCallback StateCallback
 
-	config *rest.Config
+	k8sClient k8sclient.Client
+	config    *rest.Config
 }
 
 func New(k8sClient k8sclient.Client, config *rest.Config, matchLabels map[string]string, namespace string, localPort, remotePort int) (*Portforward, error) {
-	var pods v1.PodList
-	err := k8sClient.List(context.Background(), &pods, client.InNamespace(namespace), client.MatchingLabels(matchLabels))
+	podName, err := selectPod(k8sClient, matchLabels, namespace)
 	if err != nil {
-		return nil, errors.WrapIfWithDetails(err, "could not list pods", "namespace", namespace)
-	}
-
-	podName := ""
-	for _, pod := range pods.Items {
-		if pod.Status.Phase == v1.PodRunning {
-			podName = pod.Name
-			break
-		}
+		return nil, err
 	}
 
-	if podName == "" {
-		return nil, errors.NewWithDetails("no running pods found", "matchLabels", matchLabels, "namespace", namespace)
-	}
-
-	clientset, err := kubernetes.NewForConfig(config)
+	pf, err := NewForPod(config, namespace, podName, localPort, remotePort)
 	if err != nil {
-		return nil, errors.WrapIf(err, "could not get k8s clientset")
+		return nil, err
 	}
+	pf.k8sClient = k8sClient
+	pf.matchLabels = matchLabels
 
-	req := clientset.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Namespace(namespace).
-		Name(podName).
-		SubResource("portforward")
+	return pf, nil
+}
+
+// NewForPod creates a port-forward to the given pod without looking it up by labels
+func NewForPod(config *rest.Config, namespace, podName string, localPort, remotePort int) (*Portforward, error) {
+	var err error
 
 	if localPort == 0 {
 		localPort, err = getEphemeralPort()
@@ -89,19 +114,68 @@ func New(k8sClient k8sclient.Client, config *rest.Config, matchLabels map[string
 		podname:    podName,
 		localPort:  localPort,
 		remotePort: remotePort,
-		url:        req.URL(),
 
-		stopChannel:  make(chan struct{}, 1),
+		stopChannel:  make(chan struct{}),
 		readyChannel: make(chan struct{}),
 
 		config: config,
 	}, nil
 }
 
+// selectPod returns the name of a ready pod matching the labels
+func selectPod(k8sClient k8sclient.Client, matchLabels map[string]string, namespace string) (string, error) {
+	var pods v1.PodList
+	err := k8sClient.List(context.Background(), &pods, client.InNamespace(namespace), client.MatchingLabels(matchLabels))
+	if err != nil {
+		return "", errors.WrapIfWithDetails(err, "could not list pods", "namespace", namespace)
+	}
+
+	for _, pod := range pods.Items {
+		if podReady(&pod) {
+			return pod.Name, nil
+		}
+	}
+
+	return "", errors.NewWithDetails("no ready pods found", "matchLabels", matchLabels, "namespace", namespace)
+}
+
+func podReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// SetStateCallback sets the function called on the state changes of the connection
+func (pf *Portforward) SetStateCallback(callback StateCallback) {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+
+	pf.stateCallback = callback
+}
+
+func (pf *Portforward) setState(state State, pod string, err error) {
+	pf.mu.Lock()
+	callback := pf.stateCallback
+	pf.mu.Unlock()
+
+	log.Debugf("port forward to %s/%s %s", pf.namespace, pod, state)
+	if callback != nil {
+		callback(state, pod, err)
+	}
+}
+
 func (pf *Portforward) Stop() {
-	if pf.stopChannel != nil {
+	pf.stopOnce.Do(func() {
 		close(pf.stopChannel)
-	}
+	})
 }
 
 func (pf *Portforward) WaitForStop() {
@@ -113,44 +187,157 @@ func (pf *Portforward) GetURL(path string) string {
 	return fmt.Sprintf("http://127.0.0.1:%d%s", pf.localPort, path)
 }
 
+// Run starts the port-forward and returns when the first connection is ready or it failed
 func (pf *Portforward) Run() error {
-	failure := make(chan error)
+	failure := make(chan error, 1)
 
-	go func() {
-		if err := pf.run(); err != nil {
+	go pf.supervise(failure)
+
+	select {
+	case <-pf.readyChannel:
+		log.Debug("port forward initialized successfully")
+	case err := <-failure:
+		err = errors.WrapIf(err, "port forward failed")
+		return err
+	}
+
+	return nil
+}
+
+// supervise keeps the port-forward connected until it is stopped, the failure of the first connection is reported
+func (pf *Portforward) supervise(failure chan<- error) {
+	pod := pf.podname
+	delay := minReconnectDelay
+
+	pf.setState(StateConnecting, pod, nil)
+	for {
+		connected, err := pf.connect(pod)
+		if pf.stopped() {
+			pf.setState(StateStopped, pod, nil)
+			return
+		}
+		if !pf.ready() {
 			failure <- err
+			pf.Stop()
+			return
+		}
+		if connected {
+			delay = minReconnectDelay
 		}
 
+		pf.setState(StateReconnecting, pod, err)
 		select {
 		case <-pf.stopChannel:
-			// stopCh was closed, do nothing
-		default:
-			// pf.run() returned for some other reason, close stopCh
-			pf.Stop()
+			pf.setState(StateStopped, pod, nil)
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+
+		if pf.matchLabels != nil {
+			selected, err := selectPod(pf.k8sClient, pf.matchLabels, pf.namespace)
+			if err != nil {
+				log.Debugf("could not select pod: %s", err)
+				continue
+			}
+			pod = selected
 		}
+	}
+}
+
+// connect forwards the port to the pod until the connection is lost, the pod is not ready anymore or the port-forward is stopped
+func (pf *Portforward) connect(pod string) (bool, error) {
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- pf.run(pod, stop, ready)
 	}()
 
 	select {
-	case <-pf.readyChannel:
-		log.Debug("port forward initialized successfully")
-	case err := <-failure:
-		err = errors.WrapIf(err, "port forward failed")
-		return err
+	case <-ready:
+	case err := <-done:
+		return false, err
+	case <-pf.stopChannel:
+		close(stop)
+		<-done
+		return false, nil
 	}
 
-	return nil
+	pf.readyOnce.Do(func() {
+		close(pf.readyChannel)
+	})
+	pf.setState(StateConnected, pod, nil)
+
+	ticker := time.NewTicker(podCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				err = ErrConnectionLost
+			}
+			return true, err
+		case <-pf.stopChannel:
+			close(stop)
+			<-done
+			return true, nil
+		case <-ticker.C:
+			if pf.k8sClient == nil {
+				continue
+			}
+			var p v1.Pod
+			err := pf.k8sClient.Get(context.Background(), types.NamespacedName{Namespace: pf.namespace, Name: pod}, &p)
+			if k8serrors.IsNotFound(err) || (err == nil && !podReady(&p)) {
+				close(stop)
+				<-done
+				return true, errors.NewWithDetails("pod is not ready anymore", "pod", pod)
+			}
+		}
+	}
+}
+
+func (pf *Portforward) stopped() bool {
+	select {
+	case <-pf.stopChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+func (pf *Portforward) ready() bool {
+	select {
+	case <-pf.readyChannel:
+		return true
+	default:
+		return false
+	}
 }
 
-func (pf *Portforward) run() error {
+func (pf *Portforward) run(pod string, stopChannel, readyChannel chan struct{}) error {
 	var err error
 
+	clientset, err := kubernetes.NewForConfig(pf.config)
+	if err != nil {
+		return errors.WrapIf(err, "could not get k8s clientset")
+	}
+
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pf.namespace).
+		Name(pod).
+		SubResource("portforward").URL()
+
 	transport, upgrader, err := spdy.RoundTripperFor(pf.config)
 	if err != nil {
 		return errors.WrapIf(err, "could not initialize round tripper")
 	}
 
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", pf.url)
-	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", pf.localPort, pf.remotePort)}, pf.stopChannel, pf.readyChannel, ioutil.Discard, ioutil.Discard)
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", pf.localPort, pf.remotePort)}, stopChannel, readyChannel, ioutil.Discard, ioutil.Discard)
 	if err != nil {
 		return errors.WrapIf(err, "could not create port forwarder")
 	}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package portforward
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testPod(name string, phase v1.PodPhase, ready v1.ConditionStatus) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "backyards-system", Labels: map[string]string{"app": "igw"}},
+		Status: v1.PodStatus{
+			Phase:      phase,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: ready}},
+		},
+	}
+}
+
+func TestSelectPod(t *testing.T) {
+	labels := map[string]string{"app": "igw"}
+
+	client := fake.NewFakeClientWithScheme(scheme.Scheme,
+		testPod("pending", v1.PodPending, v1.ConditionFalse),
+		testPod("running", v1.PodRunning, v1.ConditionFalse),
+		testPod("ready", v1.PodRunning, v1.ConditionTrue),
+	)
+
+	pod, err := selectPod(client, labels, "backyards-system")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if pod != "ready" {
+		t.Errorf("the ready pod should be selected, got %s", pod)
+	}
+
+	client = fake.NewFakeClientWithScheme(scheme.Scheme,
+		testPod("running", v1.PodRunning, v1.ConditionFalse),
+	)
+	_, err = selectPod(client, labels, "backyards-system")
+	if err == nil {
+		t.Error("running pods which are not ready should not be selected")
+	}
+}
+
+func TestStop(t *testing.T) {
+	pf, err := NewForPod(nil, "backyards-system", "pod", 0, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf.Stop()
+	pf.Stop()
+	pf.WaitForStop()
+
+	if !pf.stopped() {
+		t.Error("port forward should be stopped")
+	}
+}
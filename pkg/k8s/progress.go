@@ -0,0 +1,133 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+	log "github.com/sirupsen/logrus"
+)
+
+// Progress tracks the objects being waited for.
+// In live mode the pending objects are shown together with the reason in a block redrawn on every change,
+// otherwise the state changes are logged line by line.
+type Progress struct {
+	out  io.Writer
+	live bool
+
+	mu      sync.Mutex
+	names   []string
+	reasons map[string]string
+	lines   int
+}
+
+func NewProgress(out io.Writer, live bool) *Progress {
+	return &Progress{
+		out:     out,
+		live:    live,
+		reasons: make(map[string]string),
+	}
+}
+
+// NewTerminalProgress returns a progress writing to the standard error, live if it is a terminal
+func NewTerminalProgress() *Progress {
+	return NewProgress(os.Stderr, isatty.IsTerminal(os.Stderr.Fd()))
+}
+
+// Pending sets the reason the object is still pending
+func (p *Progress) Pending(name, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current, ok := p.reasons[name]
+	if ok && current == reason {
+		return
+	}
+	if !ok {
+		p.names = append(p.names, name)
+		if !p.live {
+			log.Infof("%s - pending", name)
+		}
+	}
+	p.reasons[name] = reason
+
+	if p.live {
+		p.render()
+	}
+}
+
+// Done marks the object ready
+func (p *Progress) Done(name string) {
+	p.finish(name, func() {
+		log.Infof("%s - ok", name)
+	})
+}
+
+// Failed marks the object failed
+func (p *Progress) Failed(name string, err error, diagnosis string) {
+	p.finish(name, func() {
+		log.Errorf("%s - %s", name, err)
+		if diagnosis != "" {
+			log.Error(diagnosis)
+		}
+	})
+}
+
+func (p *Progress) finish(name string, logFunc func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.live {
+		p.clear()
+	}
+
+	delete(p.reasons, name)
+	for i, n := range p.names {
+		if n == name {
+			p.names = append(p.names[:i], p.names[i+1:]...)
+			break
+		}
+	}
+	logFunc()
+
+	if p.live {
+		p.render()
+	}
+}
+
+func (p *Progress) render() {
+	p.clear()
+	if len(p.names) == 0 {
+		return
+	}
+
+	fmt.Fprintf(p.out, "Waiting for %d resources\n", len(p.names))
+	for _, name := range p.names {
+		fmt.Fprintf(p.out, "  %s: %s\n", name, p.reasons[name])
+	}
+	p.lines = len(p.names) + 1
+}
+
+// clear removes the previously rendered block by moving the cursor up and erasing the rest of the screen
+func (p *Progress) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\033[%dA\033[J", p.lines)
+		p.lines = 0
+	}
+}
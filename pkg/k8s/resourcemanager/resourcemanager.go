@@ -40,12 +40,17 @@ type Action string
 const (
 	InstallAction   Action = "install"
 	UninstallAction Action = "uninstall"
+
+	// DefaultParallelism is the number of objects applied at once within a dependency level
+	DefaultParallelism = 8
 )
 
 type ResourcesManager struct {
 	objects      object.K8sObjects
 	client       k8sclient.Client
 	labelManager k8s.LabelManager
+	parallelism  int
+	applyOptions k8s.ApplyOptions
 
 	action Action
 }
@@ -54,9 +59,20 @@ func New(client k8sclient.Client, labelManager k8s.LabelManager) *ResourcesManag
 	return &ResourcesManager{
 		client:       client,
 		labelManager: labelManager,
+		parallelism:  DefaultParallelism,
 	}
 }
 
+// SetApplyOptions sets how the objects are applied, e.g. with server-side apply
+func (r *ResourcesManager) SetApplyOptions(options k8s.ApplyOptions) {
+	r.applyOptions = options
+}
+
+// SetParallelism sets the number of objects applied at once, 1 applies the objects one by one
+func (r *ResourcesManager) SetParallelism(parallelism int) {
+	r.parallelism = parallelism
+}
+
 func (r *ResourcesManager) SetObjects(objects object.K8sObjects) {
 	r.objects = objects
 }
@@ -95,24 +111,12 @@ func (r *ResourcesManager) YAML() (string, error) {
 }
 
 func (r *ResourcesManager) install() error {
-	var err error
-
-	err = k8s.ApplyResources(r.client, r.labelManager, r.objects)
-	if err != nil {
-		return err
-	}
-
-	err = k8s.WaitForResourcesConditions(r.client, k8s.NamesWithGVKFromK8sObjects(r.objects), wait.Backoff{
+	return k8s.ApplyResourcesByLevel(r.client, r.labelManager, r.objects, r.applyOptions, r.parallelism, wait.Backoff{
 		Duration: time.Second * 5,
 		Factor:   1,
 		Jitter:   0,
 		Steps:    24,
-	}, k8s.ExistsConditionCheck, k8s.ReadyReplicasConditionCheck)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	}, k8s.NewTerminalProgress())
 }
 
 func (r *ResourcesManager) uninstall() error {
@@ -46,66 +46,14 @@ type LabelManager interface {
 
 type PostResourceApplyFunc func(k8sclient.Client, Object) error
 
-func ApplyResources(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, waitFuncs ...WaitForResourceConditionsFunc) error {
-	var err error
-
+func ApplyResources(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, options ApplyOptions, waitFuncs ...WaitForResourceConditionsFunc) error {
 	for _, obj := range objects {
-		actual := obj.UnstructuredObject().DeepCopy()
-		desired := obj.UnstructuredObject().DeepCopy()
-
-		objectName := GetFormattedName(desired)
-
-		if err = client.Get(context.Background(), types.NamespacedName{
-			Name:      actual.GetName(),
-			Namespace: actual.GetNamespace(),
-		}, actual); err == nil {
-			skip, err := labelManager.CheckLabelsBeforeUpdate(actual, desired)
-			if err != nil {
-				log.Errorf("%s failed to check labels: %s", objectName, err)
-				continue
-			}
-			if skip {
-				log.Warnf("%s skipping resource", objectName)
-				continue
-			}
-			desired.SetResourceVersion(actual.GetResourceVersion())
-			patchResult, err := patch.DefaultPatchMaker.Calculate(actual, desired)
-			if err != nil {
-				log.Error(err, "could not match objects", "object", actual.GetKind())
-			} else if patchResult.IsEmpty() {
-				log.Infof("%s unchanged", GetFormattedName(actual))
-				continue
-			}
-
-			if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(desired); err != nil {
-				log.Error(err, "failed to set last applied annotation", "desired", desired)
-			}
-
-			desired = prepareObjectBeforeUpdate(actual, desired)
-
-			err = client.Update(context.Background(), desired)
-			if err != nil {
-				return errors.WrapIfWithDetails(err, "could not update resource", "name", objectName)
-			}
-			log.Infof("%s configured", objectName)
-		} else {
-			if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(desired); err != nil {
-				log.Error(err, "failed to set last applied annotation", "desired", desired)
-			}
-			skip, err := labelManager.CheckLabelsBeforeCreate(desired)
-			if err != nil {
-				log.Errorf("%s failed to check labels: %s", objectName, err)
-				continue
-			}
-			if skip {
-				log.Warnf("%s skipping resource", objectName)
-				continue
-			}
-			err = client.Create(context.Background(), desired)
-			if err != nil {
-				return errors.WrapIfWithDetails(err, "could not create resource", "name", objectName)
-			}
-			log.Infof("%s created", objectName)
+		actual, err := applyResource(client, labelManager, obj, options)
+		if err != nil {
+			return err
+		}
+		if actual == nil {
+			continue
 		}
 
 		if len(waitFuncs) > 0 {
@@ -122,6 +70,73 @@ func ApplyResources(client k8sclient.Client, labelManager LabelManager, objects
 	return nil
 }
 
+// applyResource creates or updates a single object, nil is returned for skipped and unchanged objects
+func applyResource(client k8sclient.Client, labelManager LabelManager, obj *object.K8sObject, options ApplyOptions) (*unstructured.Unstructured, error) {
+	if options.ServerSide {
+		return applyResourceServerSide(client, labelManager, obj, options)
+	}
+
+	actual := obj.UnstructuredObject().DeepCopy()
+	desired := obj.UnstructuredObject().DeepCopy()
+
+	objectName := GetFormattedName(desired)
+
+	if err := client.Get(context.Background(), types.NamespacedName{
+		Name:      actual.GetName(),
+		Namespace: actual.GetNamespace(),
+	}, actual); err == nil {
+		skip, err := labelManager.CheckLabelsBeforeUpdate(actual, desired)
+		if err != nil {
+			log.Errorf("%s failed to check labels: %s", objectName, err)
+			return nil, nil
+		}
+		if skip {
+			log.Warnf("%s skipping resource", objectName)
+			return nil, nil
+		}
+		desired.SetResourceVersion(actual.GetResourceVersion())
+		patchResult, err := patch.DefaultPatchMaker.Calculate(actual, desired)
+		if err != nil {
+			log.Error(err, "could not match objects", "object", actual.GetKind())
+		} else if patchResult.IsEmpty() {
+			log.Infof("%s unchanged", GetFormattedName(actual))
+			return nil, nil
+		}
+
+		if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(desired); err != nil {
+			log.Error(err, "failed to set last applied annotation", "desired", desired)
+		}
+
+		desired = prepareObjectBeforeUpdate(actual, desired)
+
+		err = client.Update(context.Background(), desired)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not update resource", "name", objectName)
+		}
+		log.Infof("%s configured", objectName)
+	} else {
+		if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(desired); err != nil {
+			log.Error(err, "failed to set last applied annotation", "desired", desired)
+		}
+		skip, err := labelManager.CheckLabelsBeforeCreate(desired)
+		if err != nil {
+			log.Errorf("%s failed to check labels: %s", objectName, err)
+			return nil, nil
+		}
+		if skip {
+			log.Warnf("%s skipping resource", objectName)
+			return nil, nil
+		}
+		err = client.Create(context.Background(), desired)
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not create resource", "name", objectName)
+		}
+		log.Infof("%s created", objectName)
+	}
+
+	return actual, nil
+}
+
 type PostResourceDeleteFunc func(k8sclient.Client, Object) error
 
 func DeleteResources(client k8sclient.Client, labelManager LabelManager, objects object.K8sObjects, waitFuncs ...WaitForResourceConditionsFunc) error {
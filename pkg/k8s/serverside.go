@@ -0,0 +1,135 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"strings"
+
+	"emperror.dev/errors"
+	log "github.com/sirupsen/logrus"
+	"istio.io/operator/pkg/object"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+// FieldManager is the field manager of the fields applied with server-side apply
+const FieldManager = "backyards-cli"
+
+var ErrApplyConflict = errors.New("fields are owned by other field managers, use the '--force-conflicts' option to take them over")
+
+// ApplyOptions configures how the resources are applied
+type ApplyOptions struct {
+	// ServerSide applies the resources with server-side apply instead of a client-side three-way patch
+	ServerSide bool
+	// ForceConflicts takes over the fields owned by other field managers on server-side apply
+	ForceConflicts bool
+}
+
+// ApplyConflict is a field of an object owned by another field manager
+type ApplyConflict struct {
+	Field   string
+	Message string
+}
+
+// GetApplyConflicts returns the field ownership conflicts reported in a server-side apply error
+func GetApplyConflicts(err error) []ApplyConflict {
+	var status k8serrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+
+	conflicts := make([]ApplyConflict, 0)
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, ApplyConflict{
+			Field:   cause.Field,
+			Message: cause.Message,
+		})
+	}
+
+	return conflicts
+}
+
+func applyResourceServerSide(cl k8sclient.Client, labelManager LabelManager, obj *object.K8sObject, options ApplyOptions) (*unstructured.Unstructured, error) {
+	actual := obj.UnstructuredObject().DeepCopy()
+	desired := obj.UnstructuredObject().DeepCopy()
+
+	objectName := GetFormattedName(desired)
+
+	exists := false
+	if err := cl.Get(context.Background(), types.NamespacedName{
+		Name:      actual.GetName(),
+		Namespace: actual.GetNamespace(),
+	}, actual); err == nil {
+		exists = true
+		skip, err := labelManager.CheckLabelsBeforeUpdate(actual, desired)
+		if err != nil {
+			log.Errorf("%s failed to check labels: %s", objectName, err)
+			return nil, nil
+		}
+		if skip {
+			log.Warnf("%s skipping resource", objectName)
+			return nil, nil
+		}
+	} else {
+		skip, err := labelManager.CheckLabelsBeforeCreate(desired)
+		if err != nil {
+			log.Errorf("%s failed to check labels: %s", objectName, err)
+			return nil, nil
+		}
+		if skip {
+			log.Warnf("%s skipping resource", objectName)
+			return nil, nil
+		}
+	}
+
+	// the apply patch must not hold server managed metadata
+	desired.SetResourceVersion("")
+	desired.SetManagedFields(nil)
+
+	patchOptions := []client.PatchOptionFunc{client.FieldOwner(FieldManager)}
+	if options.ForceConflicts {
+		patchOptions = append(patchOptions, client.ForceOwnership)
+	}
+
+	err := cl.Patch(context.Background(), desired, client.Apply, patchOptions...)
+	if conflicts := GetApplyConflicts(err); len(conflicts) > 0 {
+		fields := make([]string, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			log.Errorf("%s field %s: %s", objectName, conflict.Field, conflict.Message)
+			fields = append(fields, conflict.Field)
+		}
+		return nil, errors.WithDetails(ErrApplyConflict, "name", objectName, "fields", strings.Join(fields, ", "))
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not apply resource", "name", objectName)
+	}
+
+	if exists {
+		log.Infof("%s serverside-applied", objectName)
+	} else {
+		log.Infof("%s created", objectName)
+	}
+
+	return actual, nil
+}
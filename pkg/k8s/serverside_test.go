@@ -0,0 +1,91 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// conflictingClient rejects apply patches not forcing the ownership with a field manager conflict
+type conflictingClient struct {
+	client.Client
+
+	patchOptions *client.PatchOptions
+}
+
+func (c *conflictingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOptionFunc) error {
+	c.patchOptions = (&client.PatchOptions{}).ApplyOptions(opts)
+	if c.patchOptions.Force != nil && *c.patchOptions.Force {
+		return nil
+	}
+
+	return &k8serrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusConflict,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "istio-operator" using apps/v1`,
+					Field:   ".spec.replicas",
+				},
+			},
+		},
+	}}
+}
+
+func TestApplyResourceServerSide(t *testing.T) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: demo
+spec:
+  replicas: 2
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := &conflictingClient{Client: fake.NewFakeClientWithScheme(scheme.Scheme)}
+
+	_, err = applyResource(cl, testLabelManager{}, objects[0], ApplyOptions{ServerSide: true})
+	if !errors.Is(err, ErrApplyConflict) {
+		t.Fatalf("expected apply conflict, got: %v", err)
+	}
+	if details := errors.GetDetails(err); len(details) < 4 || details[3] != ".spec.replicas" {
+		t.Errorf("conflicting fields should be reported: %v", details)
+	}
+	if cl.patchOptions.FieldManager != FieldManager {
+		t.Errorf("unexpected field manager: %s", cl.patchOptions.FieldManager)
+	}
+
+	_, err = applyResource(cl, testLabelManager{}, objects[0], ApplyOptions{ServerSide: true, ForceConflicts: true})
+	if err != nil {
+		t.Errorf("forced apply should succeed: %s", err)
+	}
+}
@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"strings"
 
+	"emperror.dev/errors"
 	log "github.com/sirupsen/logrus"
 	"istio.io/operator/pkg/object"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -101,6 +104,135 @@ func ReadyReplicasConditionCheck(obj *unstructured.Unstructured, k8serror error)
 	return false
 }
 
+// StatefulSetReadyConditionCheck checks that every replica of a StatefulSet is updated to the current revision and ready
+func StatefulSetReadyConditionCheck(obj *unstructured.Unstructured, k8serror error) bool {
+	if obj.GetKind() != "StatefulSet" {
+		return true
+	}
+
+	var statefulset appsv1.StatefulSet
+	err := k8sclient.GetScheme().Convert(obj, &statefulset, nil)
+	if err != nil {
+		return true
+	}
+
+	replicas := int32(1)
+	if statefulset.Spec.Replicas != nil {
+		replicas = *statefulset.Spec.Replicas
+	}
+
+	if statefulset.Status.ObservedGeneration < statefulset.Generation {
+		return false
+	}
+	if statefulset.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType && statefulset.Status.UpdateRevision != statefulset.Status.CurrentRevision {
+		return false
+	}
+
+	return statefulset.Status.ReadyReplicas >= replicas
+}
+
+// DaemonSetReadyConditionCheck checks that the pods of a DaemonSet are updated and available on every scheduled node
+func DaemonSetReadyConditionCheck(obj *unstructured.Unstructured, k8serror error) bool {
+	if obj.GetKind() != "DaemonSet" {
+		return true
+	}
+
+	var daemonset appsv1.DaemonSet
+	err := k8sclient.GetScheme().Convert(obj, &daemonset, nil)
+	if err != nil {
+		return true
+	}
+
+	if daemonset.Status.ObservedGeneration < daemonset.Generation {
+		return false
+	}
+
+	desired := daemonset.Status.DesiredNumberScheduled
+	if daemonset.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType && daemonset.Status.UpdatedNumberScheduled < desired {
+		return false
+	}
+
+	return daemonset.Status.NumberAvailable >= desired && daemonset.Status.NumberReady >= desired
+}
+
+// ErrJobFailed is returned when waiting for a Job which has failed, waiting longer would not complete it
+var ErrJobFailed = errors.New("job failed")
+
+// JobCompleteConditionCheck checks that a Job is complete or failed, a failed Job is reported by ResourceFailure
+func JobCompleteConditionCheck(obj *unstructured.Unstructured, k8serror error) bool {
+	if obj.GetKind() != "Job" {
+		return true
+	}
+
+	var job batchv1.Job
+	err := k8sclient.GetScheme().Convert(obj, &job, nil)
+	if err != nil {
+		return true
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResourceFailure returns an error if the object is in a final failed state, e.g. a failed Job,
+// it is checked once the conditions of the object are met
+func ResourceFailure(obj *unstructured.Unstructured, k8serror error) error {
+	if k8serror != nil || obj.GetKind() != "Job" {
+		return nil
+	}
+
+	var job batchv1.Job
+	err := k8sclient.GetScheme().Convert(obj, &job, nil)
+	if err != nil {
+		return nil
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return errors.WrapIfWithDetails(ErrJobFailed, condition.Message, "reason", condition.Reason)
+		}
+	}
+
+	return nil
+}
+
+// PendingReason describes why an object does not meet its conditions yet
+func PendingReason(obj *unstructured.Unstructured, k8serror error) string {
+	if k8serrors.IsNotFound(k8serror) {
+		return "not found"
+	}
+	if k8serror != nil {
+		return k8serror.Error()
+	}
+
+	switch obj.GetKind() {
+	case "CustomResourceDefinition":
+		return "not established"
+	case "Deployment", "StatefulSet":
+		replicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		return fmt.Sprintf("%d/%d pods ready", ready, desired)
+	case "Job":
+		completions, found, _ := unstructured.NestedInt64(obj.Object, "spec", "completions")
+		if !found {
+			completions = 1
+		}
+		succeeded, _, _ := unstructured.NestedInt64(obj.Object, "status", "succeeded")
+		return fmt.Sprintf("%d/%d completions", succeeded, completions)
+	}
+
+	return "conditions not met"
+}
+
 func WaitForResourcesConditions(client k8sclient.Client, objects []NamespacedNameWithGVK, backoff wait.Backoff, checkFuncs ...ResourceConditionCheck) error {
 	for _, o := range objects {
 		err := waitForResourceConditions(client, o.Unstructured(), backoff, checkFuncs...)
@@ -133,10 +265,16 @@ func waitForResourceConditions(client k8sclient.Client, object *unstructured.Uns
 				return false, nil
 			}
 		}
-		return true, nil
+		// the conditions of a failed resource are met as waiting would not change it, but it is not ready
+		return true, ResourceFailure(resource, err)
 	})
 
 	if err != nil {
+		if err == wait.ErrWaitTimeout || errors.Is(err, ErrJobFailed) {
+			if diagnosis := workloadDiagnosisText(client, object); diagnosis != "" {
+				log.Error(diagnosis)
+			}
+		}
 		if err == wait.ErrWaitTimeout {
 			log.Errorf("%s: For a potential solution, check out the docs: %s", err.Error(), "https://banzaicloud.com/docs/backyards/faq/#timed-out-waiting")
 		}
@@ -0,0 +1,529 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+	istiov1beta1 "github.com/banzaicloud/istio-operator/pkg/apis/istio/v1beta1"
+	"istio.io/operator/pkg/object"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sapimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+)
+
+const (
+	MinKubernetesVersion       = "1.13.0"
+	MaxTestedKubernetesVersion = "1.16"
+	capacityWarningRatio       = 0.8
+	defaultStorageClassKey     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassKey = "storageclass.beta.kubernetes.io/is-default-class"
+	istioPilotLabelKey         = "istio"
+	istioPilotLabelValue       = "pilot"
+	listLimit                  = 5
+)
+
+var (
+	applyVerbs    = []string{"get", "create", "update"}
+	workloadKinds = map[string]bool{
+		"Deployment":  true,
+		"StatefulSet": true,
+		"DaemonSet":   true,
+		"ReplicaSet":  true,
+	}
+)
+
+// KubernetesVersion checks that the server version is supported
+func KubernetesVersion(discoveryClient discovery.ServerVersionInterface) Check {
+	return Check{
+		Name: "Kubernetes version",
+		Run: func() (Status, string, error) {
+			info, err := discoveryClient.ServerVersion()
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not get server version")
+			}
+
+			version, err := semver.NewVersion(info.GitVersion)
+			if err != nil {
+				return Warn, fmt.Sprintf("unknown version %s", info.GitVersion), nil
+			}
+
+			min := semver.MustParse(MinKubernetesVersion)
+			if version.LessThan(min) {
+				return Fail, fmt.Sprintf("%s is older than the minimum supported %s", info.GitVersion, MinKubernetesVersion), nil
+			}
+
+			max := semver.MustParse(MaxTestedKubernetesVersion)
+			if version.Major() > max.Major() || (version.Major() == max.Major() && version.Minor() > max.Minor()) {
+				return Warn, fmt.Sprintf("%s is newer than the latest tested %s", info.GitVersion, MaxTestedKubernetesVersion), nil
+			}
+
+			return Pass, info.GitVersion, nil
+		},
+	}
+}
+
+// RBACPermissions checks with SelfSubjectAccessReviews that the current user is allowed to apply the objects
+func RBACPermissions(cl client.Client, mapper k8sapimeta.RESTMapper, objects object.K8sObjects) Check {
+	return Check{
+		Name: "RBAC permissions",
+		Run: func() (Status, string, error) {
+			attributes := make(map[authorizationv1.ResourceAttributes]bool)
+			for _, obj := range objects {
+				gvk := obj.GroupVersionKind()
+				resource := strings.ToLower(gvk.Kind) + "s"
+				if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+					resource = mapping.Resource.Resource
+				}
+
+				for _, verb := range applyVerbs {
+					attributes[authorizationv1.ResourceAttributes{
+						Namespace: obj.Namespace,
+						Verb:      verb,
+						Group:     gvk.Group,
+						Resource:  resource,
+					}] = true
+				}
+			}
+
+			denied := make([]string, 0)
+			for attrs := range attributes {
+				attrs := attrs
+				review := &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &attrs,
+					},
+				}
+				err := cl.Create(context.Background(), review)
+				if err != nil {
+					return Fail, "", errors.WrapIf(err, "could not create self subject access review")
+				}
+				if !review.Status.Allowed {
+					denied = append(denied, formatResourceAttributes(attrs))
+				}
+			}
+
+			if len(denied) > 0 {
+				sort.Strings(denied)
+				return Fail, fmt.Sprintf("the current user is not allowed to %s", limitedList(denied)), nil
+			}
+
+			return Pass, fmt.Sprintf("the current user is allowed to apply every resource (%d permissions checked)", len(attributes)), nil
+		},
+	}
+}
+
+// CRDConflicts checks the existing CRDs of the objects, unmanaged ones are skipped during install,
+// the unmanaged ones serving different versions would break the components using them
+func CRDConflicts(cl client.Client, objects object.K8sObjects) Check {
+	return Check{
+		Name: "CRD conflicts",
+		Run: func() (Status, string, error) {
+			unmanaged := make([]string, 0)
+			conflicting := make([]string, 0)
+			for _, obj := range objects {
+				if obj.Kind != "CustomResourceDefinition" {
+					continue
+				}
+
+				var existing apiextensionsv1beta1.CustomResourceDefinition
+				err := cl.Get(context.Background(), types.NamespacedName{Name: obj.Name}, &existing)
+				if k8serrors.IsNotFound(err) {
+					continue
+				}
+				if err != nil {
+					return Fail, "", errors.WrapIfWithDetails(err, "could not get CRD", "name", obj.Name)
+				}
+
+				var desired apiextensionsv1beta1.CustomResourceDefinition
+				err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredObject().Object, &desired)
+				if err != nil {
+					return Fail, "", errors.WrapIfWithDetails(err, "could not convert CRD", "name", obj.Name)
+				}
+
+				// managed CRDs are updated to the desired versions
+				if _, ok := existing.GetLabels()[internalk8s.CLIVersionLabel]; ok {
+					continue
+				}
+				if !servesVersions(existing, crdVersions(desired)) {
+					conflicting = append(conflicting, obj.Name)
+					continue
+				}
+				unmanaged = append(unmanaged, obj.Name)
+			}
+
+			switch {
+			case len(conflicting) > 0:
+				return Fail, fmt.Sprintf("existing CRDs serve different versions: %s", limitedList(conflicting)), nil
+			case len(unmanaged) > 0:
+				return Warn, fmt.Sprintf("existing CRDs are not managed by Backyards: %s", limitedList(unmanaged)), nil
+			}
+
+			return Pass, "no conflicting CRDs", nil
+		},
+	}
+}
+
+// UnmanagedIstio checks for Istio control planes not managed by an Istio CR of the Banzai Cloud Istio operator
+func UnmanagedIstio(cl client.Client) Check {
+	return Check{
+		Name: "Unmanaged Istio",
+		Run: func() (Status, string, error) {
+			managedNamespaces := make(map[string]bool)
+			var istios istiov1beta1.IstioList
+			err := cl.List(context.Background(), &istios)
+			if err != nil && !k8sapimeta.IsNoMatchError(errors.Cause(err)) {
+				return Fail, "", errors.WrapIf(err, "could not list Istio CRs")
+			}
+			for _, istio := range istios.Items {
+				managedNamespaces[istio.Namespace] = true
+			}
+
+			var deployments appsv1.DeploymentList
+			err = cl.List(context.Background(), &deployments, client.MatchingLabels(map[string]string{istioPilotLabelKey: istioPilotLabelValue}))
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list Istio deployments")
+			}
+
+			unmanaged := make([]string, 0)
+			for _, deployment := range deployments.Items {
+				if !managedNamespaces[deployment.Namespace] {
+					unmanaged = append(unmanaged, deployment.Namespace)
+				}
+			}
+
+			if len(unmanaged) > 0 {
+				return Fail, fmt.Sprintf("Istio is installed but not managed by the Istio operator in namespaces: %s", limitedList(unmanaged)), nil
+			}
+
+			return Pass, "no unmanaged Istio control plane found", nil
+		},
+	}
+}
+
+// DefaultStorageClass checks that exactly one default StorageClass exists for persistent volume claims
+func DefaultStorageClass(cl client.Client) Check {
+	return Check{
+		Name: "Default StorageClass",
+		Run: func() (Status, string, error) {
+			var storageClasses storagev1.StorageClassList
+			err := cl.List(context.Background(), &storageClasses)
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list storage classes")
+			}
+
+			defaults := make([]string, 0)
+			for _, storageClass := range storageClasses.Items {
+				annotations := storageClass.GetAnnotations()
+				if annotations[defaultStorageClassKey] == "true" || annotations[betaDefaultStorageClassKey] == "true" {
+					defaults = append(defaults, storageClass.Name)
+				}
+			}
+
+			switch len(defaults) {
+			case 0:
+				return Warn, "no default StorageClass, persistent volumes need an explicit storage class", nil
+			case 1:
+				return Pass, defaults[0], nil
+			default:
+				return Warn, fmt.Sprintf("multiple default StorageClasses: %s", strings.Join(defaults, ", ")), nil
+			}
+		},
+	}
+}
+
+// NodeCapacity checks that the resource requests of the objects fit into the unrequested allocatable capacity of the nodes
+func NodeCapacity(cl client.Client, objects object.K8sObjects) Check {
+	return Check{
+		Name: "Node capacity",
+		Run: func() (Status, string, error) {
+			var nodes corev1.NodeList
+			err := cl.List(context.Background(), &nodes)
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list nodes")
+			}
+
+			var pods corev1.PodList
+			err = cl.List(context.Background(), &pods)
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list pods")
+			}
+
+			free := corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0"),
+				corev1.ResourceMemory: resource.MustParse("0"),
+			}
+			schedulable := 0
+			for _, node := range nodes.Items {
+				if node.Spec.Unschedulable {
+					continue
+				}
+				schedulable++
+				addResources(free, node.Status.Allocatable, 1)
+			}
+			for _, pod := range pods.Items {
+				if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+					continue
+				}
+				addResources(free, podRequests(pod.Spec), -1)
+			}
+
+			required := corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0"),
+				corev1.ResourceMemory: resource.MustParse("0"),
+			}
+			for _, obj := range objects {
+				spec, replicas, err := podTemplateSpec(obj.UnstructuredObject())
+				if err != nil {
+					return Fail, "", err
+				}
+				if spec == nil {
+					continue
+				}
+				if obj.Kind == "DaemonSet" {
+					replicas = int64(schedulable)
+				}
+				addResources(required, podRequests(*spec), replicas)
+			}
+
+			status := Pass
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				requested := required[name]
+				available := free[name]
+				switch {
+				case requested.Cmp(available) > 0:
+					status = Fail
+				case status == Pass && float64(requested.MilliValue()) > float64(available.MilliValue())*capacityWarningRatio:
+					status = Warn
+				}
+			}
+
+			requiredCPU, requiredMemory := required[corev1.ResourceCPU], required[corev1.ResourceMemory]
+			freeCPU, freeMemory := free[corev1.ResourceCPU], free[corev1.ResourceMemory]
+
+			return status, fmt.Sprintf("requested cpu %s, memory %s of unrequested cpu %s, memory %s on %d schedulable nodes",
+				requiredCPU.String(), requiredMemory.String(), freeCPU.String(), freeMemory.String(), schedulable), nil
+		},
+	}
+}
+
+// WebhookReachability checks that the services of the admission webhooks have ready endpoints,
+// as unreachable webhooks with a Fail policy reject every matching request, the install included
+func WebhookReachability(cl client.Client) Check {
+	return Check{
+		Name: "Webhook reachability",
+		Run: func() (Status, string, error) {
+			var mutating admissionregistrationv1beta1.MutatingWebhookConfigurationList
+			err := cl.List(context.Background(), &mutating)
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list mutating webhook configurations")
+			}
+
+			var validating admissionregistrationv1beta1.ValidatingWebhookConfigurationList
+			err = cl.List(context.Background(), &validating)
+			if err != nil {
+				return Fail, "", errors.WrapIf(err, "could not list validating webhook configurations")
+			}
+
+			type webhook struct {
+				name          string
+				service       *admissionregistrationv1beta1.ServiceReference
+				failurePolicy *admissionregistrationv1beta1.FailurePolicyType
+			}
+			webhooks := make([]webhook, 0)
+			for _, config := range mutating.Items {
+				for _, w := range config.Webhooks {
+					webhooks = append(webhooks, webhook{name: w.Name, service: w.ClientConfig.Service, failurePolicy: w.FailurePolicy})
+				}
+			}
+			for _, config := range validating.Items {
+				for _, w := range config.Webhooks {
+					webhooks = append(webhooks, webhook{name: w.Name, service: w.ClientConfig.Service, failurePolicy: w.FailurePolicy})
+				}
+			}
+
+			failing := make([]string, 0)
+			ignored := make([]string, 0)
+			for _, w := range webhooks {
+				if w.service == nil {
+					continue
+				}
+
+				ready, err := hasReadyEndpoints(cl, types.NamespacedName{Namespace: w.service.Namespace, Name: w.service.Name})
+				if err != nil {
+					return Fail, "", err
+				}
+				if ready {
+					continue
+				}
+
+				// the failure policy defaults to Ignore in admissionregistration/v1beta1
+				if w.failurePolicy != nil && *w.failurePolicy == admissionregistrationv1beta1.Fail {
+					failing = append(failing, w.name)
+				} else {
+					ignored = append(ignored, w.name)
+				}
+			}
+
+			switch {
+			case len(failing) > 0:
+				return Fail, fmt.Sprintf("webhooks without ready endpoints reject requests: %s", limitedList(failing)), nil
+			case len(ignored) > 0:
+				return Warn, fmt.Sprintf("webhooks without ready endpoints are ignored: %s", limitedList(ignored)), nil
+			}
+
+			return Pass, fmt.Sprintf("%d webhooks reachable", len(webhooks)), nil
+		},
+	}
+}
+
+func hasReadyEndpoints(cl client.Client, name types.NamespacedName) (bool, error) {
+	var endpoints corev1.Endpoints
+	err := cl.Get(context.Background(), name, &endpoints)
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WrapIfWithDetails(err, "could not get endpoints", "name", name.String())
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// podTemplateSpec returns the pod spec and the replica count of workload objects, nil for other objects
+func podTemplateSpec(u *unstructured.Unstructured) (*corev1.PodSpec, int64, error) {
+	if !workloadKinds[u.GetKind()] {
+		return nil, 0, nil
+	}
+
+	rawSpec, found, err := unstructured.NestedMap(u.Object, "spec", "template", "spec")
+	if err != nil || !found {
+		return nil, 0, errors.WrapIfWithDetails(err, "could not get pod template", "name", u.GetName())
+	}
+
+	var spec corev1.PodSpec
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec)
+	if err != nil {
+		return nil, 0, errors.WrapIfWithDetails(err, "could not convert pod template", "name", u.GetName())
+	}
+
+	replicas, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	if err != nil || !found {
+		replicas = 1
+	}
+
+	return &spec, replicas, nil
+}
+
+// podRequests returns the effective resource requests of a pod, init containers run before the others
+func podRequests(spec corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range spec.Containers {
+		addResources(requests, container.Resources.Requests, 1)
+	}
+	for _, container := range spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	return requests
+}
+
+func addResources(list, add corev1.ResourceList, times int64) {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		quantity, ok := add[name]
+		if !ok {
+			continue
+		}
+		current := list[name]
+		current.Add(*resource.NewMilliQuantity(quantity.MilliValue()*times, quantity.Format))
+		list[name] = current
+	}
+}
+
+func crdVersions(crd apiextensionsv1beta1.CustomResourceDefinition) []string {
+	versions := make([]string, 0)
+	if crd.Spec.Version != "" {
+		versions = append(versions, crd.Spec.Version)
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Served {
+			versions = append(versions, version.Name)
+		}
+	}
+
+	return versions
+}
+
+func servesVersions(crd apiextensionsv1beta1.CustomResourceDefinition, versions []string) bool {
+	served := make(map[string]bool)
+	for _, version := range crdVersions(crd) {
+		served[version] = true
+	}
+	for _, version := range versions {
+		if !served[version] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatResourceAttributes(attrs authorizationv1.ResourceAttributes) string {
+	resource := attrs.Resource
+	if attrs.Group != "" {
+		resource += "." + attrs.Group
+	}
+	if attrs.Namespace != "" {
+		return fmt.Sprintf("%s %s in %s", attrs.Verb, resource, attrs.Namespace)
+	}
+
+	return fmt.Sprintf("%s %s", attrs.Verb, resource)
+}
+
+func limitedList(items []string) string {
+	if len(items) > listLimit {
+		return fmt.Sprintf("%s and %d more", strings.Join(items[:listLimit], ", "), len(items)-listLimit)
+	}
+
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,224 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	internalk8s "github.com/banzaicloud/backyards-cli/internal/k8s"
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+type serverVersion string
+
+func (v serverVersion) ServerVersion() (*version.Info, error) {
+	if v == "" {
+		return nil, errors.New("connection refused")
+	}
+	return &version.Info{GitVersion: string(v)}, nil
+}
+
+func runCheck(t *testing.T, check Check) Result {
+	t.Helper()
+	results := Run([]Check{check})
+	if len(results) != 1 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	return results[0]
+}
+
+func TestKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected Status
+	}{
+		{"v1.15.7-gke.23", Pass},
+		{"v1.12.10", Fail},
+		{"v1.18.0", Warn},
+		{"", Fail},
+	}
+
+	for _, test := range tests {
+		if result := runCheck(t, KubernetesVersion(serverVersion(test.version))); result.Status != test.expected {
+			t.Errorf("unexpected result for version '%s': %+v", test.version, result)
+		}
+	}
+}
+
+func TestDefaultStorageClass(t *testing.T) {
+	standard := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}}
+	if result := runCheck(t, DefaultStorageClass(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), standard))); result.Status != Warn {
+		t.Errorf("missing default storage class should warn: %+v", result)
+	}
+
+	standard.Annotations = map[string]string{defaultStorageClassKey: "true"}
+	if result := runCheck(t, DefaultStorageClass(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), standard))); result.Status != Pass {
+		t.Errorf("default storage class should pass: %+v", result)
+	}
+}
+
+func TestWebhookReachability(t *testing.T) {
+	fail := admissionregistrationv1beta1.Fail
+	webhooks := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validator"},
+		Webhooks: []admissionregistrationv1beta1.Webhook{
+			{
+				Name:          "validator.example.com",
+				FailurePolicy: &fail,
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service: &admissionregistrationv1beta1.ServiceReference{Namespace: "default", Name: "validator"},
+				},
+			},
+		},
+	}
+
+	result := runCheck(t, WebhookReachability(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), webhooks)))
+	if result.Status != Fail {
+		t.Errorf("webhook without endpoints should fail: %+v", result)
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "validator"},
+		Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+	}
+	result = runCheck(t, WebhookReachability(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), webhooks, endpoints)))
+	if result.Status != Pass {
+		t.Errorf("webhook with ready endpoints should pass: %+v", result)
+	}
+}
+
+func TestCRDConflicts(t *testing.T) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: istios.istio.banzaicloud.io
+spec:
+  group: istio.banzaicloud.io
+  version: v1beta1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "istios.istio.banzaicloud.io"},
+		Spec:       apiextensionsv1beta1.CustomResourceDefinitionSpec{Version: "v1alpha1"},
+	}
+	if result := runCheck(t, CRDConflicts(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), existing), objects)); result.Status != Fail {
+		t.Errorf("CRD serving a different version should fail: %+v", result)
+	}
+
+	existing.Labels = map[string]string{internalk8s.CLIVersionLabel: "1.0.0"}
+	if result := runCheck(t, CRDConflicts(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), existing), objects)); result.Status != Pass {
+		t.Errorf("managed CRD serving a different version should pass: %+v", result)
+	}
+
+	existing.Labels = nil
+	existing.Spec.Version = "v1beta1"
+	if result := runCheck(t, CRDConflicts(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), existing), objects)); result.Status != Warn {
+		t.Errorf("unmanaged CRD should warn: %+v", result)
+	}
+
+	if result := runCheck(t, CRDConflicts(fake.NewFakeClientWithScheme(k8sclient.GetScheme()), objects)); result.Status != Pass {
+		t.Errorf("missing CRD should pass: %+v", result)
+	}
+}
+
+func TestNodeCapacity(t *testing.T) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backyards
+  namespace: backyards-system
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: backyards
+        resources:
+          requests:
+            cpu: 500m
+            memory: 256Mi
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := func(cpu string) runtime.Object {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+			},
+		}
+	}
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: "node",
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	tests := []struct {
+		cpu      string
+		expected Status
+	}{
+		{"4", Pass},
+		{"2200m", Warn},
+		{"1500m", Fail},
+	}
+
+	for _, test := range tests {
+		result := runCheck(t, NodeCapacity(fake.NewFakeClientWithScheme(k8sclient.GetScheme(), node(test.cpu), running), objects))
+		if result.Status != test.expected {
+			t.Errorf("unexpected result for allocatable cpu %s: %+v", test.cpu, result)
+		}
+	}
+}
+
+func TestFailed(t *testing.T) {
+	results := Run([]Check{
+		{Name: "pass", Run: func() (Status, string, error) { return Pass, "", nil }},
+		{Name: "warn", Run: func() (Status, string, error) { return Warn, "", nil }},
+		{Name: "error", Run: func() (Status, string, error) { return Pass, "", errors.New("boom") }},
+	})
+
+	if Failed(results) != 1 || results[2].Status != Fail {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
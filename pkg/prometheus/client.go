@@ -0,0 +1,113 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Sample is the value of a series at a point in time
+type Sample struct {
+	Metric model.Metric
+	Time   time.Time
+	Value  model.SampleValue
+}
+
+// Client runs PromQL queries against the Prometheus HTTP API
+type Client struct {
+	api v1.API
+}
+
+func NewClient(address string, httpClient *http.Client) (*Client, error) {
+	roundTripper := httpClient.Transport
+	if roundTripper == nil {
+		roundTripper = api.DefaultRoundTripper
+	}
+
+	client, err := api.NewClient(api.Config{
+		Address:      address,
+		RoundTripper: roundTripper,
+	})
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create Prometheus client")
+	}
+
+	return &Client{
+		api: v1.NewAPI(client),
+	}, nil
+}
+
+// Query evaluates an instant query at the given time
+func (c *Client) Query(ctx context.Context, query string, ts time.Time) ([]Sample, error) {
+	value, warnings, err := c.api.Query(ctx, query, ts)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "query failed", "query", query)
+	}
+	logWarnings(warnings)
+
+	return Samples(value)
+}
+
+// QueryRange evaluates a range query between start and end with the given resolution
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Sample, error) {
+	value, warnings, err := c.api.QueryRange(ctx, query, v1.Range{
+		Start: start,
+		End:   end,
+		Step:  step,
+	})
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "query failed", "query", query)
+	}
+	logWarnings(warnings)
+
+	return Samples(value)
+}
+
+// Samples flattens a query result to samples ordered by series
+func Samples(value model.Value) ([]Sample, error) {
+	samples := make([]Sample, 0)
+	switch v := value.(type) {
+	case *model.Scalar:
+		samples = append(samples, Sample{Metric: model.Metric{}, Time: v.Timestamp.Time(), Value: v.Value})
+	case model.Vector:
+		for _, s := range v {
+			samples = append(samples, Sample{Metric: s.Metric, Time: s.Timestamp.Time(), Value: s.Value})
+		}
+	case model.Matrix:
+		for _, series := range v {
+			for _, p := range series.Values {
+				samples = append(samples, Sample{Metric: series.Metric, Time: p.Timestamp.Time(), Value: p.Value})
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported result type: %s", value.Type())
+	}
+
+	return samples, nil
+}
+
+func logWarnings(warnings api.Warnings) {
+	for _, warning := range warnings {
+		logrus.Warn(warning)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/prometheus/api/v1/query":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"up","job":"a"},"value":[1580000000,"1"]},
+				{"metric":{"__name__":"up","job":"b"},"value":[1580000000,"0"]}]}}`)
+		case "/prometheus/api/v1/query_range":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"job":"a"},"values":[[1580000000,"1"],[1580000060,"2"],[1580000120,"NaN"]]}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL+"/prometheus", http.DefaultClient)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	samples, err := client.Query(context.Background(), "up", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(samples) != 2 || samples[0].Metric.String() != `up{job="a"}` || samples[1].Value != 0 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+
+	samples, err = client.QueryRange(context.Background(), "up", time.Now().Add(-time.Hour), time.Now(), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(samples) != 3 || samples[1].Value != 2 || !samples[1].Time.Equal(time.Unix(1580000060, 0)) {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	"istio.io/operator/pkg/object"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Action string
+
+const (
+	InstallAction   Action = "install"
+	UpgradeAction   Action = "upgrade"
+	UninstallAction Action = "uninstall"
+	RollbackAction  Action = "rollback"
+)
+
+const (
+	ReleaseLabel  = "backyards.banzaicloud.io/release"
+	RevisionLabel = "backyards.banzaicloud.io/release-revision"
+
+	secretType = "backyards.banzaicloud.io/release.v1"
+	secretKey  = "release"
+
+	// maximum number of revisions kept, the oldest ones are deleted when a new one is saved
+	HistoryLimit = 10
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Release is a snapshot of the objects applied or removed by a CLI operation
+type Release struct {
+	Name       string    `json:"name"`
+	Revision   int       `json:"revision"`
+	Action     Action    `json:"action"`
+	Source     int       `json:"source,omitempty"`
+	CLIVersion string    `json:"cliVersion"`
+	Time       time.Time `json:"time"`
+	Values     string    `json:"values"`
+	Manifest   string    `json:"manifest"`
+}
+
+// Objects parses the objects of the snapshot
+func (r *Release) Objects() (object.K8sObjects, error) {
+	objects, err := object.ParseK8sObjectsFromYAMLManifest(r.Manifest)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not parse release manifest", "revision", r.Revision)
+	}
+
+	return objects, nil
+}
+
+// Store keeps the revisions of a release in Secrets, one Secret per revision
+type Store struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+func NewStore(client client.Client, namespace, name string) *Store {
+	return &Store{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// List returns the stored revisions ordered by revision number
+func (s *Store) List() ([]Release, error) {
+	var secrets corev1.SecretList
+	err := s.client.List(context.Background(), &secrets, client.InNamespace(s.namespace), client.MatchingLabels(map[string]string{
+		ReleaseLabel: s.name,
+	}))
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not list release secrets", "namespace", s.namespace)
+	}
+
+	releases := make([]Release, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		release, err := decode(secret.Data[secretKey])
+		if err != nil {
+			return nil, errors.WrapIfWithDetails(err, "could not decode release", "secret", secret.Name)
+		}
+		releases = append(releases, *release)
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Revision < releases[j].Revision
+	})
+
+	return releases, nil
+}
+
+// Get returns the given revision
+func (s *Store) Get(revision int) (*Release, error) {
+	var secret corev1.Secret
+	err := s.client.Get(context.Background(), client.ObjectKey{
+		Namespace: s.namespace,
+		Name:      s.secretName(revision),
+	}, &secret)
+	if k8serrors.IsNotFound(err) {
+		return nil, errors.WithDetails(ErrRevisionNotFound, "revision", revision)
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not get release secret", "revision", revision)
+	}
+
+	return decode(secret.Data[secretKey])
+}
+
+// Save stores the release as the next revision and prunes the revisions over the history limit
+func (s *Store) Save(release *Release) error {
+	releases, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	release.Name = s.name
+	release.Revision = 1
+	if len(releases) > 0 {
+		release.Revision = releases[len(releases)-1].Revision + 1
+	}
+	if release.Time.IsZero() {
+		release.Time = time.Now()
+	}
+
+	data, err := encode(release)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      s.secretName(release.Revision),
+			Labels: map[string]string{
+				ReleaseLabel:  s.name,
+				RevisionLabel: strconv.Itoa(release.Revision),
+			},
+		},
+		Type: secretType,
+		Data: map[string][]byte{
+			secretKey: data,
+		},
+	})
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not create release secret", "revision", release.Revision)
+	}
+
+	releases = append(releases, *release)
+	for len(releases) > HistoryLimit {
+		err = s.client.Delete(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.secretName(releases[0].Revision),
+			},
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.WrapIfWithDetails(err, "could not delete old release secret", "revision", releases[0].Revision)
+		}
+		releases = releases[1:]
+	}
+
+	return nil
+}
+
+func (s *Store) secretName(revision int) string {
+	return fmt.Sprintf("%s-release.v%d", s.name, revision)
+}
+
+func encode(release *Release) ([]byte, error) {
+	content, err := json.Marshal(release)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not marshal release")
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write(content)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not compress release")
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not compress release")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte) (*Release, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not decompress release")
+	}
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not decompress release")
+	}
+
+	release := &Release{}
+	err = json.Unmarshal(content, release)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not unmarshal release")
+	}
+
+	return release, nil
+}
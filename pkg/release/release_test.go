@@ -0,0 +1,81 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	k8sclient "github.com/banzaicloud/backyards-cli/pkg/k8s/client"
+)
+
+const manifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backyards
+  namespace: backyards-system
+data:
+  key: value
+`
+
+func TestStore(t *testing.T) {
+	store := NewStore(fake.NewFakeClientWithScheme(k8sclient.GetScheme()), "backyards-system", "backyards")
+
+	for i := 0; i < HistoryLimit+2; i++ {
+		err := store.Save(&Release{
+			Action:     InstallAction,
+			CLIVersion: "1.1.3",
+			Values:     "replicaCount: 1\n",
+			Manifest:   manifest,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	releases, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != HistoryLimit {
+		t.Fatalf("expected %d revisions, got %d", HistoryLimit, len(releases))
+	}
+	if releases[0].Revision != 3 || releases[len(releases)-1].Revision != HistoryLimit+2 {
+		t.Errorf("unexpected revisions kept: %d-%d", releases[0].Revision, releases[len(releases)-1].Revision)
+	}
+
+	_, err = store.Get(1)
+	if !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("pruned revision should not be found: %v", err)
+	}
+
+	release, err := store.Get(HistoryLimit + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Name != "backyards" || release.CLIVersion != "1.1.3" || release.Values != "replicaCount: 1\n" {
+		t.Errorf("unexpected release: %+v", release)
+	}
+
+	objects, err := release.Objects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Kind != "ConfigMap" || objects[0].Name != "backyards" {
+		t.Errorf("unexpected objects: %+v", objects)
+	}
+}
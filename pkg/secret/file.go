@@ -0,0 +1,206 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"emperror.dev/errors"
+)
+
+const keySize = 32
+
+// fileStore keeps the secrets in a file encrypted with AES-GCM. The key is generated on first use and saved
+// to a separate file readable only by the user. As the key is stored next to the secrets, this is obfuscation
+// rather than encryption: the secrets do not leak with the config file alone, but anyone who can read
+// both files can decrypt them.
+type fileStore struct {
+	path    string
+	keyPath string
+
+	mu sync.Mutex
+}
+
+func NewFileStore(path, keyPath string) Store {
+	return &fileStore{
+		path:    path,
+		keyPath: keyPath,
+	}
+}
+
+func (s *fileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[key]
+	if !ok {
+		return "", errors.WithStack(ErrNotFound)
+	}
+
+	return value, nil
+}
+
+func (s *fileStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+
+	return s.write(secrets)
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+
+	return s.write(secrets)
+}
+
+func (s *fileStore) Name() string {
+	return "obfuscated file " + s.path
+}
+
+func (s *fileStore) read() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	content, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read secrets", "file", s.path)
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < gcm.NonceSize() {
+		return nil, errors.NewWithDetails("invalid secrets file", "file", s.path)
+	}
+
+	nonce, ciphertext := content[:gcm.NonceSize()], content[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not decrypt secrets", "file", s.path)
+	}
+
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not parse secrets", "file", s.path)
+	}
+
+	return secrets, nil
+}
+
+func (s *fileStore) write(secrets map[string]string) error {
+	if len(secrets) == 0 {
+		err := os.Remove(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WrapIfWithDetails(err, "could not remove secrets", "file", s.path)
+		}
+		return nil
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return errors.WrapIf(err, "could not marshal secrets")
+	}
+
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return errors.WrapIf(err, "could not generate nonce")
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return errors.WrapIf(err, "could not create secrets dir")
+	}
+
+	err = ioutil.WriteFile(s.path, gcm.Seal(nonce, nonce, plaintext, nil), 0600)
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write secrets", "file", s.path)
+	}
+
+	return nil
+}
+
+// cipher returns the AES-GCM cipher of the store, the key is generated if it does not exist yet and create is set
+func (s *fileStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := ioutil.ReadFile(s.keyPath)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, keySize)
+		_, err = io.ReadFull(rand.Reader, key)
+		if err != nil {
+			return nil, errors.WrapIf(err, "could not generate key")
+		}
+
+		err = os.MkdirAll(filepath.Dir(s.keyPath), 0700)
+		if err != nil {
+			return nil, errors.WrapIf(err, "could not create secrets dir")
+		}
+		err = ioutil.WriteFile(s.keyPath, key, 0600)
+	}
+	if err != nil {
+		return nil, errors.WrapIfWithDetails(err, "could not read secrets key", "file", s.keyPath)
+	}
+	if len(key) != keySize {
+		return nil, errors.NewWithDetails("invalid secrets key", "file", s.keyPath)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create cipher")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WrapIf(err, "could not create cipher")
+	}
+
+	return gcm, nil
+}
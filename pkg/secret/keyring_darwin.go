@@ -0,0 +1,95 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// exit code of the security tool if the item could not be found
+const securityItemNotFound = 44
+
+// keychainStore keeps the secrets in the macOS keychain through the security tool
+type keychainStore struct {
+	service string
+	tool    string
+}
+
+func NewKeyringStore(service string) (Store, bool) {
+	tool, err := exec.LookPath("security")
+	if err != nil {
+		return nil, false
+	}
+
+	return &keychainStore{
+		service: service,
+		tool:    tool,
+	}, true
+}
+
+func (s *keychainStore) Get(key string) (string, error) {
+	out, err := exec.Command(s.tool, "find-generic-password", "-s", s.service, "-a", key, "-w").Output()
+	if exitCode(err) == securityItemNotFound {
+		return "", errors.WithStack(ErrNotFound)
+	}
+	if err != nil {
+		return "", errors.WrapIf(err, "could not read secret from keychain")
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (s *keychainStore) Set(key, value string) error {
+	// the command is passed on the standard input, so that the secret does not show up in the process list
+	cmd := exec.Command(s.tool, "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		strconv.Quote(s.service), strconv.Quote(key), strconv.Quote(value)))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write secret to keychain", "output", stderr.String())
+	}
+
+	return nil
+}
+
+func (s *keychainStore) Delete(key string) error {
+	err := exec.Command(s.tool, "delete-generic-password", "-s", s.service, "-a", key).Run()
+	if err != nil && exitCode(err) != securityItemNotFound {
+		return errors.WrapIf(err, "could not delete secret from keychain")
+	}
+
+	return nil
+}
+
+func (s *keychainStore) Name() string {
+	return "macOS keychain"
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return 0
+}
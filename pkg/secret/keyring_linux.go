@@ -0,0 +1,88 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// secretServiceStore keeps the secrets in the freedesktop secret service (e.g. GNOME Keyring, KWallet)
+// through the secret-tool of libsecret
+type secretServiceStore struct {
+	service string
+	tool    string
+}
+
+func NewKeyringStore(service string) (Store, bool) {
+	tool, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, false
+	}
+
+	return &secretServiceStore{
+		service: service,
+		tool:    tool,
+	}, true
+}
+
+func (s *secretServiceStore) Get(key string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.tool, "lookup", "service", s.service, "account", key)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits with 1 without any output if the item could not be found
+		if _, ok := err.(*exec.ExitError); ok && stderr.Len() == 0 {
+			return "", errors.WithStack(ErrNotFound)
+		}
+		return "", errors.WrapIfWithDetails(err, "could not read secret from secret service", "output", stderr.String())
+	}
+
+	return string(out), nil
+}
+
+func (s *secretServiceStore) Set(key, value string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.tool, "store", "--label", s.service+" "+key, "service", s.service, "account", key)
+	// the secret is read from the standard input, so that it does not show up in the process list
+	cmd.Stdin = strings.NewReader(value)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.WrapIfWithDetails(err, "could not write secret to secret service", "output", stderr.String())
+	}
+
+	return nil
+}
+
+func (s *secretServiceStore) Delete(key string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.tool, "clear", "service", s.service, "account", key)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil && stderr.Len() > 0 {
+		return errors.WrapIfWithDetails(err, "could not delete secret from secret service", "output", stderr.String())
+	}
+
+	return nil
+}
+
+func (s *secretServiceStore) Name() string {
+	return "secret service"
+}
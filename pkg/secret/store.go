@@ -0,0 +1,92 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"path/filepath"
+
+	"emperror.dev/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps secrets like authentication tokens out of the plain text config files
+type Store interface {
+	// Get returns the secret stored under the key, or ErrNotFound
+	Get(key string) (string, error)
+	Set(key, value string) error
+	// Delete removes the secret, deleting a missing secret is not an error
+	Delete(key string) error
+	// Name describes the backend of the store
+	Name() string
+}
+
+// New returns the OS keyring store if there is one on the system, falling back to an obfuscated file in the given directory
+func New(service string, dir string) Store {
+	file := NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key"))
+
+	keyring, ok := NewKeyringStore(service)
+	if !ok {
+		return file
+	}
+
+	return &fallbackStore{
+		primary:   keyring,
+		secondary: file,
+	}
+}
+
+// fallbackStore uses the secondary store if the primary one fails, e.g. a keyring without a running session
+type fallbackStore struct {
+	primary   Store
+	secondary Store
+}
+
+func (s *fallbackStore) Get(key string) (string, error) {
+	value, err := s.primary.Get(key)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		logrus.Debugf("could not read secret from %s, falling back to %s: %s", s.primary.Name(), s.secondary.Name(), err)
+	}
+
+	return s.secondary.Get(key)
+}
+
+func (s *fallbackStore) Set(key, value string) error {
+	err := s.primary.Set(key, value)
+	if err == nil {
+		// remove the stale copy left by an earlier fallback
+		return s.secondary.Delete(key)
+	}
+	logrus.Debugf("could not write secret to %s, falling back to %s: %s", s.primary.Name(), s.secondary.Name(), err)
+
+	return s.secondary.Set(key, value)
+}
+
+func (s *fallbackStore) Delete(key string) error {
+	err := s.primary.Delete(key)
+	if err != nil {
+		logrus.Debugf("could not delete secret from %s: %s", s.primary.Name(), err)
+	}
+
+	return s.secondary.Delete(key)
+}
+
+func (s *fallbackStore) Name() string {
+	return s.primary.Name()
+}
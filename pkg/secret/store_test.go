@@ -0,0 +1,104 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"emperror.dev/errors"
+)
+
+type failingStore struct{}
+
+func (failingStore) Get(key string) (string, error) { return "", errors.New("no session") }
+func (failingStore) Set(key, value string) error    { return errors.New("no session") }
+func (failingStore) Delete(key string) error        { return errors.New("no session") }
+func (failingStore) Name() string                   { return "failing" }
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.enc")
+	store := NewFileStore(path, filepath.Join(dir, "secrets.key"))
+
+	if _, err := store.Get("cluster"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	err = store.Set("cluster", "secret-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(content, []byte("secret-token")) {
+		t.Error("the secret should be encrypted")
+	}
+
+	value, err := NewFileStore(path, filepath.Join(dir, "secrets.key")).Get("cluster")
+	if err != nil || value != "secret-token" {
+		t.Errorf("unexpected secret: %s, %v", value, err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "other.key"), bytes.Repeat([]byte{1}, keySize), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path, filepath.Join(dir, "other.key")).Get("cluster"); err == nil {
+		t.Error("decrypting with another key should fail")
+	}
+
+	err = store.Delete("cluster")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the empty secrets file should be removed")
+	}
+}
+
+func TestFallbackStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &fallbackStore{
+		primary:   failingStore{},
+		secondary: NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key")),
+	}
+
+	err = store.Set("cluster", "secret-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	value, err := store.Get("cluster")
+	if err != nil || value != "secret-token" {
+		t.Errorf("unexpected secret: %s, %v", value, err)
+	}
+}
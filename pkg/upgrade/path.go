@@ -0,0 +1,53 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"emperror.dev/errors"
+	"github.com/Masterminds/semver"
+)
+
+var (
+	ErrUnknownVersion = errors.New("unknown version")
+	ErrDowngrade      = errors.New("downgrade is not supported")
+	ErrMajorUpgrade   = errors.New("major version upgrade is not supported")
+	ErrMinorSkew      = errors.New("too many minor versions skipped")
+)
+
+// CheckPath checks whether upgrading from the installed version to the target version is supported.
+// Patch versions can be skipped freely, minor versions at most maxMinorSkew at once.
+// ErrUnknownVersion is returned when any of the versions is not a semantic version, e.g. in development builds.
+func CheckPath(installed, target string, maxMinorSkew int64) error {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return errors.WithDetails(ErrUnknownVersion, "version", installed)
+	}
+
+	to, err := semver.NewVersion(target)
+	if err != nil {
+		return errors.WithDetails(ErrUnknownVersion, "version", target)
+	}
+
+	switch {
+	case to.LessThan(from):
+		return errors.WithDetails(ErrDowngrade, "installed", installed, "target", target)
+	case to.Major() != from.Major():
+		return errors.WithDetails(ErrMajorUpgrade, "installed", installed, "target", target)
+	case to.Minor()-from.Minor() > maxMinorSkew:
+		return errors.WithDetails(ErrMinorSkew, "installed", installed, "target", target, "max", maxMinorSkew)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright © 2020 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+)
+
+func TestCheckPath(t *testing.T) {
+	tests := []struct {
+		installed string
+		target    string
+		expected  error
+	}{
+		{"1.1.3", "1.1.3", nil},
+		{"1.1.0", "1.1.5", nil},
+		{"1.0.2", "1.1.0", nil},
+		{"v1.0.2", "1.1.0", nil},
+		{"1.0.2", "1.2.0", ErrMinorSkew},
+		{"1.1.3", "1.1.2", ErrDowngrade},
+		{"1.4.4", "2.0.0", ErrMajorUpgrade},
+		{"master", "1.1.3", ErrUnknownVersion},
+		{"1.1.3", "", ErrUnknownVersion},
+	}
+
+	for _, test := range tests {
+		err := CheckPath(test.installed, test.target, 1)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("unexpected result upgrading from '%s' to '%s': got %v, want %v", test.installed, test.target, err, test.expected)
+		}
+	}
+}